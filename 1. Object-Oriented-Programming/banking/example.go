@@ -0,0 +1,2972 @@
+// Banking Demo - Go
+// Flow: Account -> Roles & Policy -> Protection Proxy -> Authentication & Sessions -> CLI -> Wallet -> Undoable Commands -> Mementos -> Observers -> Transactions -> Savings & Checking -> Interest -> Overdraft Policies -> Atomic Transfer -> Persistence -> Event Stream -> Lifecycle -> Audit Decorator -> Foreign Currency -> Standing Orders -> Limits -> Idempotency -> Account Numbers -> Snapshots
+//
+// Run:         go run example.go [-policy policy.json] [-store accounts.json]
+// Interactive: go run example.go -interactive   (login alice alice-pw, balance ACC001, ...)
+
+package main
+
+import (
+	"bufio"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// 1. ACCOUNT - the encapsulated BankAccount from the OOP tour, with errors
+// ============================================================================
+
+var (
+	ErrInvalidAmount     = errors.New("amount must be positive")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrNoFeeToWaive      = errors.New("no fee to waive")
+	ErrPermissionDenied  = errors.New("permission denied")
+)
+
+// Account is the behavior every account type and every wrapper offers
+type Account interface {
+	Number() string
+	Balance() (float64, error)
+	Deposit(amount float64) error
+	Withdraw(amount float64) error
+	AssessFee(amount float64) error
+	WaiveFee() error
+}
+
+type BankAccount struct {
+	accountNumber string  // unexported (private)
+	balance       float64 // unexported (private)
+	fees          []float64
+	observers     []AccountObserver // see section 9
+	lowBalance    float64           // LowBalance fires when balance drops below this
+	clock         Clock             // timestamps transactions, see section 10
+	transactions  []Transaction
+	overdraft     OverdraftPolicy // consulted when a withdrawal exceeds the balance, see section 13
+	mu            sync.Mutex      // held by every exported method, see section 14
+	sink          EventSink       // full event stream, see section 16
+	status        AccountStatus   // Active, Frozen or Closed, see section 17
+	currency      Currency        // every amount on the account is in this currency
+	limits        []LimitPolicy   // checked by Withdraw, see section 21
+	idempotency   map[string]int  // key -> index in transactions, see section 22
+}
+
+// NewBankAccount rejects a malformed number (see ValidateAccountNumber in
+// section 23), so numbers read from users or files cannot open an account
+func NewBankAccount(accountNumber string, initialBalance float64) (*BankAccount, error) {
+	if err := ValidateAccountNumber(accountNumber); err != nil {
+		return nil, err
+	}
+	balance := initialBalance
+	if balance < 0 {
+		balance = 0
+	}
+	return &BankAccount{
+		accountNumber: accountNumber,
+		balance:       balance,
+		clock:         SystemClock{},
+		overdraft:     DenyOverdraft{},
+		status:        StatusActive,
+		currency:      USD,
+	}, nil
+}
+
+// MustNewBankAccount panics instead, like regexp.MustCompile, for numbers fixed
+// in the source. The savings, checking and foreign-currency constructors build
+// on it, so they panic the same way.
+func MustNewBankAccount(accountNumber string, initialBalance float64) *BankAccount {
+	account, err := NewBankAccount(accountNumber, initialBalance)
+	if err != nil {
+		panic(err)
+	}
+	return account
+}
+
+func (ba *BankAccount) Number() string { return ba.accountNumber }
+
+func (ba *BankAccount) Balance() (float64, error) {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	return ba.balance, nil
+}
+
+func (ba *BankAccount) Deposit(amount float64) error {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	return ba.deposit(amount)
+}
+
+// deposit, withdraw and the other lower-case methods expect the lock held
+func (ba *BankAccount) deposit(amount float64) error {
+	if err := ba.checkStatus(false); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	ba.balance += amount
+	ba.record(TxDeposit, amount)
+	ba.notify(Deposited, amount)
+	return nil
+}
+
+// Withdraw also locks the accounts its overdraft policy may draw on
+func (ba *BankAccount) Withdraw(amount float64) error {
+	unlock := lockAll(ba.lockScope(nil))
+	defer unlock()
+	return ba.withdraw(amount)
+}
+
+func (ba *BankAccount) withdraw(amount float64) error { return ba.withdrawWithFee(amount, 0) }
+
+// withdrawWithFee debits amount and charges fee with it (a conversion fee, see
+// section 19). Limits judge the amount; the overdraft policy has to cover both,
+// so the fee cannot take the balance past what the policy allows.
+func (ba *BankAccount) withdrawWithFee(amount, fee float64) error {
+	if err := ba.checkStatus(true); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	for _, limit := range ba.limits {
+		if err := limit.Check(ba, amount); err != nil {
+			return err
+		}
+	}
+	if amount+fee > ba.balance {
+		if err := ba.overdraft.Cover(ba, amount+fee); err != nil {
+			return err
+		}
+	}
+	ba.debit(amount)
+	if fee > 0 {
+		return ba.assessFee(fee)
+	}
+	return nil
+}
+
+// debit moves money out after the caller has decided the withdrawal is allowed
+func (ba *BankAccount) debit(amount float64) {
+	ba.balance -= amount
+	ba.record(TxWithdrawal, -amount)
+	ba.notify(Withdrawn, amount)
+	ba.checkLowBalance(amount)
+}
+
+// AssessFee charges a fee; fees may take the balance negative
+func (ba *BankAccount) AssessFee(amount float64) error {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	return ba.assessFee(amount)
+}
+
+func (ba *BankAccount) assessFee(amount float64) error {
+	if err := ba.checkStatus(false); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	ba.balance -= amount
+	ba.fees = append(ba.fees, amount)
+	ba.record(TxFee, -amount)
+	ba.checkLowBalance(amount)
+	return nil
+}
+
+// WaiveFee refunds the most recent fee
+func (ba *BankAccount) WaiveFee() error {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	if err := ba.checkStatus(false); err != nil {
+		return err
+	}
+	if len(ba.fees) == 0 {
+		return ErrNoFeeToWaive
+	}
+	ba.refundLastFee()
+	return nil
+}
+
+func (ba *BankAccount) refundLastFee() {
+	last := ba.fees[len(ba.fees)-1]
+	ba.fees = ba.fees[:len(ba.fees)-1]
+	ba.balance += last
+	ba.record(TxFeeWaived, last)
+}
+
+// ============================================================================
+// 2. ROLE-BASED ACCESS CONTROL - Principal, Role, Permission, Policy
+// ============================================================================
+
+type Permission string
+
+const (
+	PermView      Permission = "view"
+	PermDeposit   Permission = "deposit"
+	PermWithdraw  Permission = "withdraw"
+	PermAssessFee Permission = "assess_fee"
+	PermWaiveFee  Permission = "waive_fee"
+)
+
+type Role string
+
+// Principal is whoever is calling: a person or a service
+type Principal struct {
+	Name  string
+	Roles []Role
+}
+
+// Policy maps roles to the permissions they grant
+type Policy struct {
+	grants map[Role]map[Permission]bool
+}
+
+// policyFile is the on-disk format: {"roles": {"teller": ["view", "deposit"]}}
+type policyFile struct {
+	Roles map[Role][]Permission `json:"roles"`
+}
+
+func ParsePolicy(r io.Reader) (*Policy, error) {
+	var file policyFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+	policy := &Policy{grants: make(map[Role]map[Permission]bool)}
+	for role, permissions := range file.Roles {
+		policy.grants[role] = make(map[Permission]bool)
+		for _, permission := range permissions {
+			policy.grants[role][permission] = true
+		}
+	}
+	return policy, nil
+}
+
+func LoadPolicyFile(path string) (*Policy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("load policy: %w", err)
+	}
+	defer file.Close()
+	return ParsePolicy(file)
+}
+
+// Allows is true if any of the principal's roles grants the permission
+func (p *Policy) Allows(principal Principal, permission Permission) bool {
+	for _, role := range principal.Roles {
+		if p.grants[role][permission] {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// 3. PROTECTION PROXY - same Account interface, checks before delegating
+// ============================================================================
+
+// SecuredAccount is a protection proxy: callers hold an Account and cannot
+// tell it apart from the real one, except that forbidden calls fail
+type SecuredAccount struct {
+	account   Account
+	principal Principal
+	policy    *Policy
+}
+
+func NewSecuredAccount(account Account, principal Principal, policy *Policy) *SecuredAccount {
+	return &SecuredAccount{account: account, principal: principal, policy: policy}
+}
+
+func (s *SecuredAccount) check(permission Permission) error {
+	if !s.policy.Allows(s.principal, permission) {
+		return fmt.Errorf("%w: %s may not %s on %s", ErrPermissionDenied, s.principal.Name, permission, s.account.Number())
+	}
+	return nil
+}
+
+func (s *SecuredAccount) Number() string { return s.account.Number() }
+
+func (s *SecuredAccount) Balance() (float64, error) {
+	if err := s.check(PermView); err != nil {
+		return 0, err
+	}
+	return s.account.Balance()
+}
+
+func (s *SecuredAccount) Deposit(amount float64) error {
+	if err := s.check(PermDeposit); err != nil {
+		return err
+	}
+	return s.account.Deposit(amount)
+}
+
+func (s *SecuredAccount) Withdraw(amount float64) error {
+	if err := s.check(PermWithdraw); err != nil {
+		return err
+	}
+	return s.account.Withdraw(amount)
+}
+
+func (s *SecuredAccount) AssessFee(amount float64) error {
+	if err := s.check(PermAssessFee); err != nil {
+		return err
+	}
+	return s.account.AssessFee(amount)
+}
+
+func (s *SecuredAccount) WaiveFee() error {
+	if err := s.check(PermWaiveFee); err != nil {
+		return err
+	}
+	return s.account.WaiveFee()
+}
+
+// ============================================================================
+// 4. AUTHENTICATION - credential store, password hashing, expiring sessions
+// ============================================================================
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrNotLoggedIn        = errors.New("not logged in")
+	ErrSessionExpired     = errors.New("session expired")
+)
+
+// Clock lets tests and demos control time (sessions expire without sleeping)
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+type ManualClock struct {
+	now time.Time
+}
+
+func (c *ManualClock) Now() time.Time          { return c.now }
+func (c *ManualClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// Credential never holds the password, only a salted PBKDF2 hash
+type Credential struct {
+	Username string
+	Salt     []byte
+	Hash     []byte
+	Roles    []Role
+}
+
+const hashIterations = 100_000
+
+func HashPassword(password string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, password, salt, hashIterations, 32)
+}
+
+func NewCredential(username, password string, roles ...Role) (Credential, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return Credential{}, err
+	}
+	hash, err := HashPassword(password, salt)
+	if err != nil {
+		return Credential{}, err
+	}
+	return Credential{Username: username, Salt: salt, Hash: hash, Roles: roles}, nil
+}
+
+// CredentialStore is the DIP seam: in-memory here, a database in real life
+type CredentialStore interface {
+	Find(username string) (Credential, bool)
+}
+
+type InMemoryCredentialStore struct {
+	credentials map[string]Credential
+}
+
+func NewInMemoryCredentialStore(credentials ...Credential) *InMemoryCredentialStore {
+	store := &InMemoryCredentialStore{credentials: make(map[string]Credential)}
+	for _, credential := range credentials {
+		store.credentials[credential.Username] = credential
+	}
+	return store
+}
+
+func (s *InMemoryCredentialStore) Find(username string) (Credential, bool) {
+	credential, ok := s.credentials[username]
+	return credential, ok
+}
+
+type Session struct {
+	Token     string
+	Principal Principal
+	ExpiresAt time.Time
+}
+
+type SessionManager struct {
+	mu       sync.Mutex
+	store    CredentialStore
+	clock    Clock
+	ttl      time.Duration
+	sessions map[string]Session
+}
+
+func NewSessionManager(store CredentialStore, clock Clock, ttl time.Duration) *SessionManager {
+	return &SessionManager{store: store, clock: clock, ttl: ttl, sessions: make(map[string]Session)}
+}
+
+// Login checks the password in constant time and issues a random session token
+func (m *SessionManager) Login(username, password string) (Session, error) {
+	credential, ok := m.store.Find(username)
+	if !ok {
+		return Session{}, ErrInvalidCredentials // same error: do not reveal which part was wrong
+	}
+	hash, err := HashPassword(password, credential.Salt)
+	if err != nil {
+		return Session{}, err
+	}
+	if subtle.ConstantTimeCompare(hash, credential.Hash) != 1 {
+		return Session{}, ErrInvalidCredentials
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return Session{}, err
+	}
+	session := Session{
+		Token:     hex.EncodeToString(raw),
+		Principal: Principal{Name: username, Roles: credential.Roles},
+		ExpiresAt: m.clock.Now().Add(m.ttl),
+	}
+	m.mu.Lock()
+	m.sessions[session.Token] = session
+	m.mu.Unlock()
+	return session, nil
+}
+
+// Authenticate turns a token back into the Principal the RBAC layer needs
+func (m *SessionManager) Authenticate(token string) (Principal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[token]
+	if !ok {
+		return Principal{}, ErrNotLoggedIn
+	}
+	if !m.clock.Now().Before(session.ExpiresAt) {
+		delete(m.sessions, token)
+		return Principal{}, ErrSessionExpired
+	}
+	return session.Principal, nil
+}
+
+func (m *SessionManager) Logout(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, token)
+}
+
+// ============================================================================
+// 5. BANKING CLI - auth -> RBAC proxy -> account, composed per command
+// ============================================================================
+
+type BankingCLI struct {
+	sessions *SessionManager
+	policy   *Policy
+	accounts map[string]Account
+	token    string
+	out      io.Writer
+}
+
+func NewBankingCLI(sessions *SessionManager, policy *Policy, out io.Writer, accounts ...Account) *BankingCLI {
+	cli := &BankingCLI{sessions: sessions, policy: policy, accounts: make(map[string]Account), out: out}
+	for _, account := range accounts {
+		cli.accounts[account.Number()] = account
+	}
+	return cli
+}
+
+// account authenticates the session and wraps the account in the RBAC proxy
+func (c *BankingCLI) account(number string) (Account, error) {
+	principal, err := c.sessions.Authenticate(c.token)
+	if err != nil {
+		return nil, err
+	}
+	account, ok := c.accounts[number]
+	if !ok {
+		return nil, fmt.Errorf("unknown account %q", number)
+	}
+	return NewSecuredAccount(account, principal, c.policy), nil
+}
+
+// Execute runs one command line: login, logout, balance, deposit, withdraw, waive
+func (c *BankingCLI) Execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	command, args := fields[0], fields[1:]
+	switch command {
+	case "login":
+		if len(args) != 2 {
+			return errors.New("usage: login <user> <password>")
+		}
+		session, err := c.sessions.Login(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		c.token = session.Token
+		fmt.Fprintf(c.out, "logged in as %s until %s\n", args[0], session.ExpiresAt.Format("15:04"))
+		return nil
+	case "logout":
+		c.sessions.Logout(c.token)
+		c.token = ""
+		fmt.Fprintln(c.out, "logged out")
+		return nil
+	}
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: %s <account> [amount]", command)
+	}
+	account, err := c.account(args[0])
+	if err != nil {
+		return err
+	}
+	amount := 0.0
+	if len(args) > 1 {
+		if amount, err = strconv.ParseFloat(args[1], 64); err != nil {
+			return fmt.Errorf("bad amount %q", args[1])
+		}
+	}
+	switch command {
+	case "balance":
+		balance, err := account.Balance()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "%s balance: %.2f\n", account.Number(), balance)
+		return nil
+	case "deposit":
+		return account.Deposit(amount)
+	case "withdraw":
+		return account.Withdraw(amount)
+	case "waive":
+		return account.WaiveFee()
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// Run reads commands until EOF or "quit"
+func (c *BankingCLI) Run(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(c.out, "> ")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "quit" {
+			return
+		}
+		if err := c.Execute(line); err != nil {
+			fmt.Fprintf(c.out, "error: %v\n", err)
+		}
+		fmt.Fprint(c.out, "> ")
+	}
+}
+
+// ============================================================================
+// 6. MULTI-CURRENCY WALLET - an aggregate guarding per-currency invariants
+// ============================================================================
+
+type Currency string
+
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	JPY Currency = "JPY"
+)
+
+var ErrNoExchangeRate = errors.New("no exchange rate")
+
+// ExchangeRateProvider hides where rates come from (static table, API, cache)
+type ExchangeRateProvider interface {
+	Rate(from, to Currency) (float64, error)
+}
+
+// StaticRates quotes every currency against USD and derives cross rates
+type StaticRates struct {
+	perUSD map[Currency]float64
+}
+
+func NewStaticRates(perUSD map[Currency]float64) *StaticRates {
+	rates := &StaticRates{perUSD: map[Currency]float64{USD: 1}}
+	for currency, rate := range perUSD {
+		rates.perUSD[currency] = rate
+	}
+	return rates
+}
+
+func (r *StaticRates) Rate(from, to Currency) (float64, error) {
+	fromRate, okFrom := r.perUSD[from]
+	toRate, okTo := r.perUSD[to]
+	if !okFrom || !okTo {
+		return 0, fmt.Errorf("%w: %s -> %s", ErrNoExchangeRate, from, to)
+	}
+	return toRate / fromRate, nil
+}
+
+func roundCents(amount float64) float64 { return math.Round(amount*100) / 100 }
+
+// Wallet is the only way to change its balances, so no currency can go
+// negative and a failed conversion never leaves money half-moved
+type Wallet struct {
+	owner    string
+	base     Currency
+	rates    ExchangeRateProvider
+	balances map[Currency]float64
+}
+
+func NewWallet(owner string, base Currency, rates ExchangeRateProvider) *Wallet {
+	return &Wallet{owner: owner, base: base, rates: rates, balances: make(map[Currency]float64)}
+}
+
+func (w *Wallet) Balance(currency Currency) float64 { return w.balances[currency] }
+
+// Currencies lists held currencies in a stable order
+func (w *Wallet) Currencies() []Currency {
+	currencies := make([]Currency, 0, len(w.balances))
+	for currency := range w.balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+	return currencies
+}
+
+func (w *Wallet) Deposit(currency Currency, amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	w.balances[currency] = roundCents(w.balances[currency] + amount)
+	return nil
+}
+
+func (w *Wallet) Withdraw(currency Currency, amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if amount > w.balances[currency] {
+		return fmt.Errorf("%w: %.2f %s available", ErrInsufficientFunds, w.balances[currency], currency)
+	}
+	w.balances[currency] = roundCents(w.balances[currency] - amount)
+	if w.balances[currency] == 0 {
+		delete(w.balances, currency)
+	}
+	return nil
+}
+
+// Convert moves amount of from into to; the rate is fetched before anything changes
+func (w *Wallet) Convert(from, to Currency, amount float64) (float64, error) {
+	rate, err := w.rates.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.Withdraw(from, amount); err != nil {
+		return 0, err
+	}
+	converted := roundCents(amount * rate)
+	w.balances[to] = roundCents(w.balances[to] + converted)
+	return converted, nil
+}
+
+// Valuation prices every holding in the wallet's base currency
+func (w *Wallet) Valuation() (float64, error) {
+	total := 0.0
+	for _, currency := range w.Currencies() {
+		rate, err := w.rates.Rate(currency, w.base)
+		if err != nil {
+			return 0, err
+		}
+		total += w.balances[currency] * rate
+	}
+	return roundCents(total), nil
+}
+
+// ============================================================================
+// 7. COMMANDS - account operations as objects that can be undone
+// ============================================================================
+
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+type Command interface {
+	Execute() error
+	Undo() error
+	String() string
+}
+
+type DepositCommand struct {
+	account Account
+	amount  float64
+}
+
+func NewDepositCommand(account Account, amount float64) *DepositCommand {
+	return &DepositCommand{account: account, amount: amount}
+}
+
+func (c *DepositCommand) Execute() error { return c.account.Deposit(c.amount) }
+
+// Undo can fail if the money was spent in the meantime
+func (c *DepositCommand) Undo() error { return c.account.Withdraw(c.amount) }
+
+func (c *DepositCommand) String() string {
+	return fmt.Sprintf("deposit %.2f to %s", c.amount, c.account.Number())
+}
+
+type WithdrawCommand struct {
+	account Account
+	amount  float64
+}
+
+func NewWithdrawCommand(account Account, amount float64) *WithdrawCommand {
+	return &WithdrawCommand{account: account, amount: amount}
+}
+
+func (c *WithdrawCommand) Execute() error { return c.account.Withdraw(c.amount) }
+func (c *WithdrawCommand) Undo() error    { return c.account.Deposit(c.amount) }
+
+func (c *WithdrawCommand) String() string {
+	return fmt.Sprintf("withdraw %.2f from %s", c.amount, c.account.Number())
+}
+
+// TransferCommand is two steps; if the second fails the first is reversed
+type TransferCommand struct {
+	from, to Account
+	amount   float64
+}
+
+func NewTransferCommand(from, to Account, amount float64) *TransferCommand {
+	return &TransferCommand{from: from, to: to, amount: amount}
+}
+
+func (c *TransferCommand) move(from, to Account) error {
+	if err := from.Withdraw(c.amount); err != nil {
+		return err
+	}
+	if err := to.Deposit(c.amount); err != nil {
+		if rollbackErr := from.Deposit(c.amount); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *TransferCommand) Execute() error { return c.move(c.from, c.to) }
+func (c *TransferCommand) Undo() error    { return c.move(c.to, c.from) }
+
+func (c *TransferCommand) String() string {
+	return fmt.Sprintf("transfer %.2f %s -> %s", c.amount, c.from.Number(), c.to.Number())
+}
+
+// CommandHistory is the invoker: only commands that succeeded are recorded
+type CommandHistory struct {
+	done []Command
+}
+
+func (h *CommandHistory) Execute(command Command) error {
+	if err := command.Execute(); err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	h.done = append(h.done, command)
+	return nil
+}
+
+// UndoLast rolls back the newest n commands, stopping at the first failure
+// so history and balances stay consistent
+func (h *CommandHistory) UndoLast(n int) error {
+	for ; n > 0; n-- {
+		if len(h.done) == 0 {
+			return ErrNothingToUndo
+		}
+		last := h.done[len(h.done)-1]
+		if err := last.Undo(); err != nil {
+			return fmt.Errorf("undo %s: %w", last, err)
+		}
+		h.done = h.done[:len(h.done)-1]
+	}
+	return nil
+}
+
+func (h *CommandHistory) Len() int { return len(h.done) }
+
+// ============================================================================
+// 8. MEMENTO - capture and restore account state without exposing it
+// ============================================================================
+
+var ErrForeignMemento = errors.New("memento belongs to another account")
+
+// Memento is opaque outside this package: only BankAccount reads its fields
+type Memento struct {
+	accountNumber string
+	balance       float64
+	fees          []float64
+	label         string
+}
+
+func (m Memento) Label() string { return m.label }
+
+// Snapshot copies the fee slice so later fees cannot leak into the memento
+func (ba *BankAccount) Snapshot(label string) Memento {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	return Memento{
+		accountNumber: ba.accountNumber,
+		balance:       ba.balance,
+		fees:          append([]float64(nil), ba.fees...),
+		label:         label,
+	}
+}
+
+func (ba *BankAccount) Restore(m Memento) error {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	if m.accountNumber != ba.accountNumber {
+		return fmt.Errorf("%w: %s is not %s", ErrForeignMemento, m.accountNumber, ba.accountNumber)
+	}
+	delta := m.balance - ba.balance
+	ba.balance = m.balance
+	ba.fees = append([]float64(nil), m.fees...)
+	// history is append-only: a restore is recorded as an adjustment, not erased
+	ba.record(TxRestore, delta)
+	return nil
+}
+
+// AccountHistory is the caretaker: it stores mementos but never looks inside
+type AccountHistory struct {
+	account   *BankAccount
+	snapshots []Memento
+	limit     int
+}
+
+func NewAccountHistory(account *BankAccount, limit int) *AccountHistory {
+	return &AccountHistory{account: account, limit: limit}
+}
+
+// Save keeps at most limit snapshots, dropping the oldest
+func (h *AccountHistory) Save(label string) {
+	h.snapshots = append(h.snapshots, h.account.Snapshot(label))
+	if len(h.snapshots) > h.limit {
+		h.snapshots = h.snapshots[len(h.snapshots)-h.limit:]
+	}
+}
+
+// Rollback restores the newest snapshot and removes it from history
+func (h *AccountHistory) Rollback() (string, error) {
+	if len(h.snapshots) == 0 {
+		return "", ErrNothingToUndo
+	}
+	last := h.snapshots[len(h.snapshots)-1]
+	if err := h.account.Restore(last); err != nil {
+		return "", err
+	}
+	h.snapshots = h.snapshots[:len(h.snapshots)-1]
+	return last.Label(), nil
+}
+
+func (h *AccountHistory) Labels() []string {
+	labels := make([]string, len(h.snapshots))
+	for i, m := range h.snapshots {
+		labels[i] = m.Label()
+	}
+	return labels
+}
+
+// ============================================================================
+// 9. OBSERVERS - the account announces what happened, subscribers react
+// ============================================================================
+
+type AccountEventKind string
+
+const (
+	Deposited  AccountEventKind = "Deposited"
+	Withdrawn  AccountEventKind = "Withdrawn"
+	LowBalance AccountEventKind = "LowBalance"
+)
+
+type AccountEvent struct {
+	Kind    AccountEventKind
+	Account string
+	Amount  float64
+	Balance float64 // after the change
+	At      time.Time
+}
+
+type AccountObserver interface {
+	OnAccountEvent(event AccountEvent)
+}
+
+// AccountObserverFunc lets a plain function subscribe
+type AccountObserverFunc func(event AccountEvent)
+
+func (f AccountObserverFunc) OnAccountEvent(event AccountEvent) { f(event) }
+
+func (ba *BankAccount) Subscribe(observer AccountObserver) {
+	ba.observers = append(ba.observers, observer)
+}
+
+func (ba *BankAccount) SetLowBalanceThreshold(threshold float64) {
+	ba.lowBalance = threshold
+}
+
+func (ba *BankAccount) notify(kind AccountEventKind, amount float64) {
+	event := AccountEvent{Kind: kind, Account: ba.accountNumber, Amount: amount, Balance: ba.balance, At: ba.clock.Now()}
+	for _, observer := range ba.observers {
+		observer.OnAccountEvent(event)
+	}
+	if ba.sink != nil {
+		ba.sink.Emit(event)
+	}
+}
+
+// checkLowBalance fires only when this change crossed the threshold
+func (ba *BankAccount) checkLowBalance(amount float64) {
+	if ba.lowBalance > 0 && ba.balance < ba.lowBalance && ba.balance+amount >= ba.lowBalance {
+		ba.notify(LowBalance, amount)
+	}
+}
+
+type ConsoleNotifier struct {
+	out io.Writer
+}
+
+func (n ConsoleNotifier) OnAccountEvent(event AccountEvent) {
+	fmt.Fprintf(n.out, "  [notify] %s %s %.2f, balance %.2f\n", event.Account, event.Kind, event.Amount, event.Balance)
+}
+
+type AuditLogger struct {
+	entries []AccountEvent
+}
+
+func (a *AuditLogger) OnAccountEvent(event AccountEvent) { a.entries = append(a.entries, event) }
+
+// FraudDetector flags single large withdrawals and bursts of withdrawals
+type FraudDetector struct {
+	maxSingle  float64
+	maxInARow  int
+	inARow     int
+	suspicious []string
+}
+
+func NewFraudDetector(maxSingle float64, maxInARow int) *FraudDetector {
+	return &FraudDetector{maxSingle: maxSingle, maxInARow: maxInARow}
+}
+
+func (f *FraudDetector) OnAccountEvent(event AccountEvent) {
+	switch event.Kind {
+	case Deposited:
+		f.inARow = 0
+	case Withdrawn:
+		f.inARow++
+		if event.Amount > f.maxSingle {
+			f.suspicious = append(f.suspicious, fmt.Sprintf("%s: large withdrawal %.2f", event.Account, event.Amount))
+		}
+		if f.inARow == f.maxInARow {
+			f.suspicious = append(f.suspicious, fmt.Sprintf("%s: %d withdrawals in a row", event.Account, f.inARow))
+		}
+	}
+}
+
+// ============================================================================
+// 10. TRANSACTIONS - every balance change leaves an entry in the ledger
+// ============================================================================
+
+type TransactionKind string
+
+const (
+	TxDeposit    TransactionKind = "deposit"
+	TxWithdrawal TransactionKind = "withdrawal"
+	TxFee        TransactionKind = "fee"
+	TxFeeWaived  TransactionKind = "fee-waived"
+	TxRestore    TransactionKind = "restore"
+	TxInterest   TransactionKind = "interest"
+)
+
+type Transaction struct {
+	ID      string
+	At      time.Time
+	Kind    TransactionKind
+	Amount  float64 // signed: negative when money leaves the account
+	Balance float64 // resulting balance
+	Key     string  // idempotency key, if the caller gave one
+}
+
+func (ba *BankAccount) SetClock(clock Clock) { ba.clock = clock }
+
+func (ba *BankAccount) record(kind TransactionKind, amount float64) {
+	ba.transactions = append(ba.transactions, Transaction{
+		ID:      fmt.Sprintf("%s-%04d", ba.accountNumber, len(ba.transactions)+1),
+		At:      ba.clock.Now(),
+		Kind:    kind,
+		Amount:  amount,
+		Balance: ba.balance,
+	})
+}
+
+// Transactions returns a copy so callers cannot rewrite history
+func (ba *BankAccount) Transactions() []Transaction {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	return append([]Transaction(nil), ba.transactions...)
+}
+
+// TransactionsBetween returns entries with from <= At < to
+func (ba *BankAccount) TransactionsBetween(from, to time.Time) []Transaction {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	return ba.transactionsBetween(from, to)
+}
+
+func (ba *BankAccount) transactionsBetween(from, to time.Time) []Transaction {
+	var found []Transaction
+	for _, tx := range ba.transactions {
+		if !tx.At.Before(from) && tx.At.Before(to) {
+			found = append(found, tx)
+		}
+	}
+	return found
+}
+
+func printTransactions(w io.Writer, transactions []Transaction) {
+	for _, tx := range transactions {
+		fmt.Fprintf(w, "  %s %s %-10s %+9.2f %10.2f\n", tx.ID, tx.At.Format("Jan 02"), tx.Kind, tx.Amount, tx.Balance)
+	}
+}
+
+// ============================================================================
+// 11. ACCOUNT TYPES - savings and checking are-a BankAccount with their own rules
+// ============================================================================
+
+var (
+	ErrWithdrawalLimit = errors.New("withdrawal limit reached for this period")
+	ErrAccrualInPast   = errors.New("interest already accrued past this date")
+)
+
+// SavingsAccount earns interest but allows only a few withdrawals per period
+type SavingsAccount struct {
+	*BankAccount
+	interest        InterestCalculator // see section 12
+	accruedThrough  time.Time
+	withdrawalLimit int
+	withdrawals     int
+}
+
+// NewSavingsAccount starts accruing interest from clock.Now()
+func NewSavingsAccount(accountNumber string, initialBalance float64, interest InterestCalculator, withdrawalLimit int, clock Clock) *SavingsAccount {
+	account := MustNewBankAccount(accountNumber, initialBalance)
+	account.SetClock(clock)
+	return &SavingsAccount{
+		BankAccount:     account,
+		interest:        interest,
+		accruedThrough:  clock.Now(),
+		withdrawalLimit: withdrawalLimit,
+	}
+}
+
+// Withdraw overrides the promoted method; the base still guards the balance
+func (s *SavingsAccount) Withdraw(amount float64) error {
+	if s.withdrawals >= s.withdrawalLimit {
+		return fmt.Errorf("%w (%d)", ErrWithdrawalLimit, s.withdrawalLimit)
+	}
+	if err := s.BankAccount.Withdraw(amount); err != nil {
+		return err
+	}
+	s.withdrawals++
+	return nil
+}
+
+// StartPeriod resets the withdrawal counter, e.g. at the start of a month
+func (s *SavingsAccount) StartPeriod() { s.withdrawals = 0 }
+
+// CheckingAccount is always opened with an overdraft policy (section 13)
+type CheckingAccount struct {
+	*BankAccount
+}
+
+func NewCheckingAccount(accountNumber string, initialBalance float64, overdraft OverdraftPolicy) *CheckingAccount {
+	account := MustNewBankAccount(accountNumber, initialBalance)
+	account.SetOverdraftPolicy(overdraft)
+	return &CheckingAccount{BankAccount: account}
+}
+
+// Both types still satisfy Account, so proxies and the CLI accept them unchanged
+var (
+	_ Account = (*SavingsAccount)(nil)
+	_ Account = (*CheckingAccount)(nil)
+)
+
+// ============================================================================
+// 12. INTEREST - how interest is computed is a strategy the account is given
+// ============================================================================
+
+const daysPerYear = 365
+
+// InterestCalculator returns the interest earned by balance over days
+type InterestCalculator interface {
+	Interest(balance float64, days int) float64
+}
+
+type SimpleInterest struct{ AnnualRate float64 }
+
+func (c SimpleInterest) Interest(balance float64, days int) float64 {
+	return balance * c.AnnualRate * float64(days) / daysPerYear
+}
+
+// DailyCompound adds each day's interest to the base for the next day
+type DailyCompound struct{ AnnualRate float64 }
+
+func (c DailyCompound) Interest(balance float64, days int) float64 {
+	return balance * (math.Pow(1+c.AnnualRate/daysPerYear, float64(days)) - 1)
+}
+
+type RateTier struct {
+	UpTo       float64 // balance ceiling of this tier; math.Inf(1) for the top tier
+	AnnualRate float64
+}
+
+// TieredRate pays each slice of the balance at its own tier's rate
+type TieredRate struct{ Tiers []RateTier }
+
+func (c TieredRate) Interest(balance float64, days int) float64 {
+	var interest, floor float64
+	for _, tier := range c.Tiers {
+		if balance <= floor {
+			break
+		}
+		slice := math.Min(balance, tier.UpTo) - floor
+		interest += SimpleInterest{tier.AnnualRate}.Interest(slice, days)
+		floor = tier.UpTo
+	}
+	return interest
+}
+
+// AccrueInterest credits interest for the whole days since the last accrual.
+// A partial day carries over to the next call.
+func (s *SavingsAccount) AccrueInterest(asOf time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if asOf.Before(s.accruedThrough) {
+		return 0, fmt.Errorf("%w: %s", ErrAccrualInPast, s.accruedThrough.Format(time.DateOnly))
+	}
+	days := int(asOf.Sub(s.accruedThrough).Hours() / 24)
+	if days == 0 {
+		return 0, nil
+	}
+	s.accruedThrough = s.accruedThrough.AddDate(0, 0, days)
+	if s.balance <= 0 {
+		return 0, nil
+	}
+	interest := math.Round(s.interest.Interest(s.balance, days)*100) / 100
+	if interest > 0 {
+		s.balance += interest
+		s.record(TxInterest, interest)
+	}
+	return interest, nil
+}
+
+// ============================================================================
+// 13. OVERDRAFT POLICIES - what happens when a withdrawal exceeds the balance
+// ============================================================================
+
+// OverdraftPolicy either makes the withdrawal possible (by charging a fee or
+// moving money in) or refuses it. Withdraw debits the amount only after nil.
+// Cover runs with the account locked, so it uses the lower-case methods.
+type OverdraftPolicy interface {
+	Cover(account *BankAccount, amount float64) error
+}
+
+func (ba *BankAccount) SetOverdraftPolicy(policy OverdraftPolicy) { ba.overdraft = policy }
+
+// DenyOverdraft is the default: never go below zero
+type DenyOverdraft struct{}
+
+func (DenyOverdraft) Cover(account *BankAccount, amount float64) error { return ErrInsufficientFunds }
+
+// FeeBasedOverdraft allows a negative balance down to -Limit for a flat fee per use
+type FeeBasedOverdraft struct {
+	Limit float64
+	Fee   float64
+}
+
+func (p FeeBasedOverdraft) Cover(account *BankAccount, amount float64) error {
+	if amount+p.Fee > account.balance+p.Limit {
+		return fmt.Errorf("%w: overdraft limit %.2f", ErrInsufficientFunds, p.Limit)
+	}
+	if p.Fee > 0 {
+		return account.assessFee(p.Fee)
+	}
+	return nil
+}
+
+// LinkedAccountOverdraft sweeps the shortfall in from another account. The
+// source is locked along with the account (see lockScope), so the sweep is
+// one step even while others use the source.
+type LinkedAccountOverdraft struct {
+	Source *BankAccount
+}
+
+func (p LinkedAccountOverdraft) Cover(account *BankAccount, amount float64) error {
+	shortfall := amount - account.balance
+	if err := p.Source.withdraw(shortfall); err != nil {
+		return fmt.Errorf("%w: linked account %s cannot cover %.2f: %w", ErrInsufficientFunds, p.Source.accountNumber, shortfall, err)
+	}
+	return account.deposit(shortfall)
+}
+
+// ============================================================================
+// 14. ATOMIC TRANSFER - debit and credit together, or not at all
+// ============================================================================
+
+var ErrSameAccount = errors.New("cannot transfer to the same account")
+
+// A rolled-back step is reversed by a new entry, since history is append-only
+const (
+	TxDepositReversed    TransactionKind = "deposit-reversed"
+	TxWithdrawalReversed TransactionKind = "withdrawal-reversed"
+)
+
+// Money is an amount in a currency, so a transfer cannot treat dollars as euros
+type Money struct {
+	Amount   float64
+	Currency Currency
+}
+
+func NewMoney(amount float64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func (m Money) String() string { return fmt.Sprintf("%.2f %s", m.Amount, m.Currency) }
+
+// Every exported method locks its account, so accounts can be shared between
+// goroutines. An operation over several accounts (a transfer, or a withdrawal
+// that sweeps from a linked account) locks all of them before changing any,
+// always in account-number order: two transfers A->B and B->A cannot each
+// hold one lock and wait for the other.
+
+// lockScope adds ba and every account its overdraft policy draws on to scope
+func (ba *BankAccount) lockScope(scope []*BankAccount) []*BankAccount {
+	for _, account := range scope {
+		if account == ba {
+			return scope
+		}
+	}
+	scope = append(scope, ba)
+	if linked, ok := ba.overdraft.(LinkedAccountOverdraft); ok {
+		scope = linked.Source.lockScope(scope)
+	}
+	return scope
+}
+
+// lockAll sorts accounts into lock order, locks them and returns the unlock
+func lockAll(accounts []*BankAccount) func() {
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].accountNumber < accounts[j].accountNumber })
+	for _, account := range accounts {
+		account.mu.Lock()
+	}
+	return func() {
+		for i := len(accounts) - 1; i >= 0; i-- {
+			accounts[i].mu.Unlock()
+		}
+	}
+}
+
+// Transfer moves amount between two accounts that hold its currency: the
+// debit and the credit happen together, or not at all
+func Transfer(from, to *BankAccount, amount Money) error {
+	if amount.Currency != from.currency || amount.Currency != to.currency {
+		return fmt.Errorf("%w: %s from %s (%s) to %s (%s), use TransferForeign",
+			ErrCurrencyMismatch, amount, from.accountNumber, from.currency, to.accountNumber, to.currency)
+	}
+	return transfer(from, to, amount.Amount, amount.Amount, 0)
+}
+
+// transfer takes debit and fee out of from, then puts credit into to. If a
+// step fails, every change the earlier steps made is reversed, including an
+// overdraft fee and a sweep from a linked account.
+func transfer(from, to *BankAccount, debit, credit, fee float64) error {
+	if from.accountNumber == to.accountNumber {
+		return fmt.Errorf("%w: %s", ErrSameAccount, from.accountNumber)
+	}
+	scope := to.lockScope(from.lockScope(nil))
+	unlock := lockAll(scope)
+	defer unlock()
+
+	marks := make([]int, len(scope))
+	for i, account := range scope {
+		marks[i] = len(account.transactions)
+	}
+	err := from.withdrawWithFee(debit, fee)
+	if err == nil {
+		err = to.deposit(credit)
+	}
+	if err != nil {
+		for i, account := range scope {
+			account.rollback(marks[i])
+		}
+		return fmt.Errorf("transfer %s -> %s: %w", from.accountNumber, to.accountNumber, err)
+	}
+	return nil
+}
+
+// rollback reverses the entries recorded after mark, newest first
+func (ba *BankAccount) rollback(mark int) {
+	for i := len(ba.transactions) - 1; i >= mark; i-- {
+		tx := ba.transactions[i]
+		switch tx.Kind {
+		case TxFee:
+			ba.refundLastFee()
+		case TxDeposit:
+			ba.balance -= tx.Amount
+			ba.record(TxDepositReversed, -tx.Amount)
+			ba.notify(Withdrawn, tx.Amount)
+		case TxWithdrawal:
+			ba.balance -= tx.Amount
+			ba.record(TxWithdrawalReversed, -tx.Amount)
+			ba.notify(Deposited, -tx.Amount)
+		}
+	}
+}
+
+// ============================================================================
+// 15. PERSISTENCE - the demo depends on AccountRepository, not on a storage engine
+// ============================================================================
+
+var ErrAccountNotFound = errors.New("account not found")
+
+type AccountRepository interface {
+	Save(account *BankAccount) error
+	FindByNumber(number string) (*BankAccount, error)
+	List() ([]*BankAccount, error)
+}
+
+// Accounts are stored as AccountSnapshots (section 24)
+func sortedAccounts(snapshots map[string]AccountSnapshot) ([]*BankAccount, error) {
+	accounts := make([]*BankAccount, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		account, err := RestoreFromSnapshot(snapshot)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].accountNumber < accounts[j].accountNumber })
+	return accounts, nil
+}
+
+// InMemoryAccountRepository stores copies, so callers get the same
+// "load a fresh object" behavior as from the file repository
+type InMemoryAccountRepository struct {
+	mu      sync.Mutex
+	records map[string]AccountSnapshot
+}
+
+func NewInMemoryAccountRepository() *InMemoryAccountRepository {
+	return &InMemoryAccountRepository{records: make(map[string]AccountSnapshot)}
+}
+
+func (r *InMemoryAccountRepository) Save(account *BankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[account.accountNumber] = account.Export()
+	return nil
+}
+
+func (r *InMemoryAccountRepository) FindByNumber(number string) (*BankAccount, error) {
+	if err := ValidateAccountNumber(number); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[number]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, number)
+	}
+	return RestoreFromSnapshot(record)
+}
+
+func (r *InMemoryAccountRepository) List() ([]*BankAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedAccounts(r.records)
+}
+
+// JSONFileAccountRepository keeps every account in one JSON file
+type JSONFileAccountRepository struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewJSONFileAccountRepository(path string) *JSONFileAccountRepository {
+	return &JSONFileAccountRepository{path: path}
+}
+
+func (r *JSONFileAccountRepository) load() (map[string]AccountSnapshot, error) {
+	records := make(map[string]AccountSnapshot)
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("%s: %w", r.path, err)
+	}
+	for number := range records {
+		if err := ValidateAccountNumber(number); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.path, err)
+		}
+	}
+	return records, nil
+}
+
+func (r *JSONFileAccountRepository) Save(account *BankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records, err := r.load()
+	if err != nil {
+		return err
+	}
+	records[account.accountNumber] = account.Export()
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, data)
+}
+
+// writeFileAtomic writes then renames, so a crash never leaves a half-written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (r *JSONFileAccountRepository) FindByNumber(number string) (*BankAccount, error) {
+	if err := ValidateAccountNumber(number); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	record, ok := records[number]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, number)
+	}
+	return RestoreFromSnapshot(record)
+}
+
+func (r *JSONFileAccountRepository) List() ([]*BankAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	return sortedAccounts(records)
+}
+
+// loadOrOpen finds an account or opens and saves a new one
+func loadOrOpen(repo AccountRepository, number string, initialBalance float64) (*BankAccount, error) {
+	account, err := repo.FindByNumber(number)
+	if !errors.Is(err, ErrAccountNotFound) {
+		return account, err
+	}
+	if account, err = NewBankAccount(number, initialBalance); err != nil {
+		return nil, err
+	}
+	return account, repo.Save(account)
+}
+
+// ============================================================================
+// 16. EVENT STREAM - one sink sees an account's whole life, opening to closing
+// ============================================================================
+
+const (
+	AccountOpened AccountEventKind = "AccountOpened"
+	Closed        AccountEventKind = "Closed"
+)
+
+// EventSink differs from an observer in when it is attached: it is injected
+// at opening, so the stream starts with AccountOpened and nothing is missed.
+type EventSink interface {
+	Emit(event AccountEvent)
+}
+
+func OpenAccount(accountNumber string, initialBalance float64, clock Clock, sink EventSink) *BankAccount {
+	account := MustNewBankAccount(accountNumber, initialBalance)
+	account.clock = clock
+	account.sink = sink
+	account.notify(AccountOpened, account.balance)
+	return account
+}
+
+type ConsoleSink struct {
+	out io.Writer
+}
+
+func (s ConsoleSink) Emit(event AccountEvent) {
+	fmt.Fprintf(s.out, "  [event] %s %-13s %s %8.2f -> %.2f\n",
+		event.At.Format(time.DateOnly), event.Kind, event.Account, event.Amount, event.Balance)
+}
+
+// EventLog keeps every event in memory, in order, for any number of accounts
+type EventLog struct {
+	mu     sync.Mutex
+	events []AccountEvent
+}
+
+func (l *EventLog) Emit(event AccountEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *EventLog) For(account string) []AccountEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var found []AccountEvent
+	for _, event := range l.events {
+		if event.Account == account {
+			found = append(found, event)
+		}
+	}
+	return found
+}
+
+// MultiSink fans one stream out to several sinks
+type MultiSink []EventSink
+
+func (m MultiSink) Emit(event AccountEvent) {
+	for _, sink := range m {
+		sink.Emit(event)
+	}
+}
+
+// StatementGenerator builds statements from the event log, not from the account
+type StatementGenerator struct {
+	log *EventLog
+}
+
+func NewStatementGenerator(log *EventLog) *StatementGenerator {
+	return &StatementGenerator{log: log}
+}
+
+func (g *StatementGenerator) Write(w io.Writer, account string) error {
+	events := g.log.For(account)
+	if len(events) == 0 {
+		return fmt.Errorf("%w: no events for %s", ErrAccountNotFound, account)
+	}
+	fmt.Fprintf(w, "Statement for %s\n", account)
+	for _, event := range events {
+		label, ok := statementLabel(event.Kind)
+		if !ok {
+			continue // e.g. LowBalance is a signal, not a movement
+		}
+		amount := fmt.Sprintf("%10.2f", event.Amount)
+		if event.Kind == AccountOpened || event.Kind == Closed {
+			amount = fmt.Sprintf("%10s", "")
+		}
+		fmt.Fprintf(w, "  %s  %-6s %s  balance %10.2f\n", event.At.Format(time.DateOnly), label, amount, event.Balance)
+	}
+	return nil
+}
+
+func statementLabel(kind AccountEventKind) (string, bool) {
+	switch kind {
+	case AccountOpened:
+		return "opened", true
+	case Deposited:
+		return "credit", true
+	case Withdrawn:
+		return "debit", true
+	case Closed:
+		return "closed", true
+	}
+	return "", false
+}
+
+// AppendStatement appends what Write writes to dst. It reads the log in place
+// and formats with strconv and time.AppendFormat instead of fmt, so rendering
+// into a reused buffer does not allocate, however long the statement is.
+func (g *StatementGenerator) AppendStatement(dst []byte, account string) ([]byte, error) {
+	g.log.mu.Lock()
+	defer g.log.mu.Unlock()
+	start, found := len(dst), false
+	dst = append(dst, "Statement for "...)
+	dst = append(dst, account...)
+	dst = append(dst, '\n')
+	for _, event := range g.log.events {
+		if event.Account != account {
+			continue
+		}
+		found = true
+		label, ok := statementLabel(event.Kind)
+		if !ok {
+			continue
+		}
+		dst = append(dst, "  "...)
+		dst = event.At.AppendFormat(dst, time.DateOnly)
+		dst = append(dst, "  "...)
+		dst = append(dst, label...)
+		dst = append(dst, "       "[len(label):]...) // %-6s plus the separating space
+		if event.Kind == AccountOpened || event.Kind == Closed {
+			dst = append(dst, "          "...)
+		} else {
+			dst = appendFixed(dst, event.Amount, 10)
+		}
+		dst = append(dst, "  balance "...)
+		dst = appendFixed(dst, event.Balance, 10)
+		dst = append(dst, '\n')
+	}
+	if !found {
+		return dst[:start], fmt.Errorf("%w: no events for %s", ErrAccountNotFound, account)
+	}
+	return dst, nil
+}
+
+// appendFixed is %*.2f; the scratch array stays on the stack
+func appendFixed(dst []byte, amount float64, width int) []byte {
+	var scratch [32]byte
+	num := strconv.AppendFloat(scratch[:0], amount, 'f', 2, 64)
+	for i := len(num); i < width; i++ {
+		dst = append(dst, ' ')
+	}
+	return append(dst, num...)
+}
+
+// Statement formats for Generate, built from the account's transaction history
+type StatementFormat string
+
+const (
+	FormatText StatementFormat = "text"
+	FormatCSV  StatementFormat = "csv"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// Statement covers from <= At < to
+type Statement struct {
+	Account      string
+	From, To     time.Time
+	Opening      float64
+	Closing      float64
+	Transactions []Transaction
+	Totals       map[TransactionKind]float64
+}
+
+func BuildStatement(account *BankAccount, from, to time.Time) Statement {
+	account.mu.Lock()
+	defer account.mu.Unlock()
+	statement := Statement{Account: account.accountNumber, From: from, To: to, Totals: make(map[TransactionKind]float64)}
+	all := account.transactions
+	// opening is the balance after the last transaction before the range,
+	// or the balance before the first transaction in it
+	statement.Opening = account.balance
+	for i, tx := range all {
+		if !tx.At.Before(from) {
+			statement.Opening = tx.Balance - tx.Amount
+			break
+		}
+		if i == len(all)-1 {
+			statement.Opening = tx.Balance
+		}
+	}
+	statement.Closing = statement.Opening
+	statement.Transactions = account.transactionsBetween(from, to)
+	for _, tx := range statement.Transactions {
+		statement.Totals[tx.Kind] += tx.Amount
+		statement.Closing = tx.Balance
+	}
+	return statement
+}
+
+func (s Statement) categories() []TransactionKind {
+	kinds := make([]TransactionKind, 0, len(s.Totals))
+	for kind := range s.Totals {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
+
+func (g *StatementGenerator) Generate(w io.Writer, account *BankAccount, from, to time.Time, format StatementFormat) error {
+	statement := BuildStatement(account, from, to)
+	switch format {
+	case FormatText:
+		return writeTextStatement(w, statement)
+	case FormatCSV:
+		return writeCSVStatement(w, statement)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+func writeTextStatement(w io.Writer, s Statement) error {
+	fmt.Fprintf(w, "Statement %s  %s to %s\n", s.Account, s.From.Format(time.DateOnly), s.To.Format(time.DateOnly))
+	fmt.Fprintf(w, "  %-12s %-10s %-10s %10s %10s\n", "ID", "DATE", "CATEGORY", "AMOUNT", "BALANCE")
+	fmt.Fprintf(w, "  %-12s %-10s %-10s %10s %10.2f\n", "", "", "opening", "", s.Opening)
+	for _, tx := range s.Transactions {
+		fmt.Fprintf(w, "  %-12s %-10s %-10s %+10.2f %10.2f\n", tx.ID, tx.At.Format(time.DateOnly), tx.Kind, tx.Amount, tx.Balance)
+	}
+	fmt.Fprintf(w, "  %-12s %-10s %-10s %10s %10.2f\n", "", "", "closing", "", s.Closing)
+	for _, kind := range s.categories() {
+		fmt.Fprintf(w, "  total %-17s %-10s %+10.2f\n", "", kind, s.Totals[kind])
+	}
+	return nil
+}
+
+// writeCSVStatement puts opening, closing and totals in the category column
+// so the file stays one table that a spreadsheet can sum
+func writeCSVStatement(w io.Writer, s Statement) error {
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "date", "category", "amount", "balance"})
+	out.Write([]string{"", s.From.Format(time.DateOnly), "opening", "", money(s.Opening)})
+	for _, tx := range s.Transactions {
+		out.Write([]string{tx.ID, tx.At.Format(time.DateOnly), string(tx.Kind), money(tx.Amount), money(tx.Balance)})
+	}
+	out.Write([]string{"", s.To.Format(time.DateOnly), "closing", "", money(s.Closing)})
+	for _, kind := range s.categories() {
+		out.Write([]string{"", "", "total:" + string(kind), money(s.Totals[kind]), ""})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// ============================================================================
+// 17. LIFECYCLE - Active, Frozen, Closed and the moves between them
+// ============================================================================
+
+type AccountStatus string
+
+const (
+	StatusActive AccountStatus = "Active"
+	StatusFrozen AccountStatus = "Frozen"
+	StatusClosed AccountStatus = "Closed"
+)
+
+const (
+	Frozen   AccountEventKind = "Frozen"
+	Unfrozen AccountEventKind = "Unfrozen"
+)
+
+var (
+	ErrAccountFrozen       = errors.New("account frozen")
+	ErrAccountClosed       = errors.New("account closed")
+	ErrInvalidStatusChange = errors.New("invalid account status change")
+)
+
+// accountTransitions lists the legal next statuses; Closed is terminal
+var accountTransitions = map[AccountStatus][]AccountStatus{
+	StatusActive: {StatusFrozen, StatusClosed},
+	StatusFrozen: {StatusActive, StatusClosed},
+}
+
+func (ba *BankAccount) Status() AccountStatus {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	return ba.status
+}
+
+// checkStatus: Closed rejects everything; Frozen rejects only money leaving
+func (ba *BankAccount) checkStatus(debit bool) error {
+	switch {
+	case ba.status == StatusClosed:
+		return fmt.Errorf("%w: %s", ErrAccountClosed, ba.accountNumber)
+	case ba.status == StatusFrozen && debit:
+		return fmt.Errorf("%w: %s", ErrAccountFrozen, ba.accountNumber)
+	}
+	return nil
+}
+
+func (ba *BankAccount) moveTo(next AccountStatus, kind AccountEventKind) error {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	for _, allowed := range accountTransitions[ba.status] {
+		if allowed == next {
+			ba.status = next
+			ba.notify(kind, 0)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s %s -> %s", ErrInvalidStatusChange, ba.accountNumber, ba.status, next)
+}
+
+func (ba *BankAccount) Freeze() error   { return ba.moveTo(StatusFrozen, Frozen) }
+func (ba *BankAccount) Unfreeze() error { return ba.moveTo(StatusActive, Unfrozen) }
+func (ba *BankAccount) Close() error    { return ba.moveTo(StatusClosed, Closed) }
+
+// ============================================================================
+// 18. AUDIT DECORATOR - same Account interface, records every call
+// ============================================================================
+
+// AuditRecord answers who did what to which account, when, and how it went
+type AuditRecord struct {
+	When    time.Time
+	Who     string
+	What    string
+	Account string
+	Amount  float64
+	Err     error
+}
+
+type Logger interface {
+	Log(record AuditRecord)
+}
+
+// WriterLogger prints one line per record
+type WriterLogger struct {
+	out io.Writer
+}
+
+func (l WriterLogger) Log(r AuditRecord) {
+	outcome := "ok"
+	if r.Err != nil {
+		outcome = "error: " + r.Err.Error()
+	}
+	fmt.Fprintf(l.out, "  [audit] %s %-6s %-9s %s %8.2f %s\n", r.When.Format(time.TimeOnly), r.Who, r.What, r.Account, r.Amount, outcome)
+}
+
+// AuditedAccount is a decorator: unlike the proxy in section 3 it never
+// refuses a call, it only adds the audit trail around it
+type AuditedAccount struct {
+	account Account
+	who     string
+	logger  Logger
+	clock   Clock
+}
+
+func NewAuditedAccount(account Account, who string, logger Logger, clock Clock) *AuditedAccount {
+	return &AuditedAccount{account: account, who: who, logger: logger, clock: clock}
+}
+
+func (a *AuditedAccount) log(what string, amount float64, err error) error {
+	a.logger.Log(AuditRecord{When: a.clock.Now(), Who: a.who, What: what, Account: a.account.Number(), Amount: amount, Err: err})
+	return err
+}
+
+func (a *AuditedAccount) Number() string { return a.account.Number() }
+
+func (a *AuditedAccount) Balance() (float64, error) {
+	balance, err := a.account.Balance()
+	return balance, a.log("balance", 0, err)
+}
+
+func (a *AuditedAccount) Deposit(amount float64) error {
+	return a.log("deposit", amount, a.account.Deposit(amount))
+}
+
+func (a *AuditedAccount) Withdraw(amount float64) error {
+	return a.log("withdraw", amount, a.account.Withdraw(amount))
+}
+
+func (a *AuditedAccount) AssessFee(amount float64) error {
+	return a.log("fee", amount, a.account.AssessFee(amount))
+}
+
+func (a *AuditedAccount) WaiveFee() error {
+	return a.log("waive-fee", 0, a.account.WaiveFee())
+}
+
+// ============================================================================
+// 19. FOREIGN CURRENCY - convert, round, charge a fee, then touch the balance
+// ============================================================================
+
+var ErrCurrencyMismatch = errors.New("accounts hold different currencies")
+
+func NewBankAccountIn(accountNumber string, initialBalance float64, currency Currency) *BankAccount {
+	account := MustNewBankAccount(accountNumber, initialBalance)
+	account.currency = currency
+	return account
+}
+
+func (ba *BankAccount) Currency() Currency { return ba.currency }
+
+// Conversion is what the account sees (Amount) and what the bank keeps (Fee)
+type Conversion struct {
+	Amount float64
+	Fee    float64
+	Rate   float64
+}
+
+type CurrencyConverter interface {
+	Convert(amount float64, from, to Currency) (Conversion, error)
+}
+
+// minorUnits: digits after the decimal point; currencies not listed use 2
+var minorUnits = map[Currency]int{JPY: 0}
+
+// RoundingRule rounds an amount to the currency's smallest unit
+type RoundingRule func(amount float64, currency Currency) float64
+
+func scaled(currency Currency) float64 {
+	units, ok := minorUnits[currency]
+	if !ok {
+		units = 2
+	}
+	return math.Pow10(units)
+}
+
+func RoundHalfUp(amount float64, currency Currency) float64 {
+	return math.Round(amount*scaled(currency)) / scaled(currency)
+}
+
+// RoundHalfEven ("banker's rounding") does not drift upward over many conversions
+func RoundHalfEven(amount float64, currency Currency) float64 {
+	return math.RoundToEven(amount*scaled(currency)) / scaled(currency)
+}
+
+func RoundDown(amount float64, currency Currency) float64 {
+	return math.Floor(amount*scaled(currency)) / scaled(currency)
+}
+
+// ConversionFee is the fee strategy, charged in the target currency
+type ConversionFee interface {
+	Fee(converted float64) float64
+}
+
+type NoFee struct{}
+
+func (NoFee) Fee(float64) float64 { return 0 }
+
+type FlatFee struct{ Amount float64 }
+
+func (f FlatFee) Fee(float64) float64 { return f.Amount }
+
+// PercentFee charges Percent of the converted amount, but at least Min
+type PercentFee struct {
+	Percent float64
+	Min     float64
+}
+
+func (f PercentFee) Fee(converted float64) float64 {
+	return math.Max(converted*f.Percent/100, f.Min)
+}
+
+// FixedRateConverter uses a rate table (e.g. StaticRates); same-currency amounts pass through free
+type FixedRateConverter struct {
+	rates ExchangeRateProvider
+	round RoundingRule
+	fee   ConversionFee
+}
+
+func NewFixedRateConverter(rates ExchangeRateProvider, round RoundingRule, fee ConversionFee) *FixedRateConverter {
+	return &FixedRateConverter{rates: rates, round: round, fee: fee}
+}
+
+func (c *FixedRateConverter) Convert(amount float64, from, to Currency) (Conversion, error) {
+	if from == to {
+		return Conversion{Amount: amount, Rate: 1}, nil
+	}
+	rate, err := c.rates.Rate(from, to)
+	if err != nil {
+		return Conversion{}, err
+	}
+	converted := c.round(amount*rate, to)
+	return Conversion{Amount: converted, Fee: c.round(c.fee.Fee(converted), to), Rate: rate}, nil
+}
+
+// DepositForeign credits the converted amount, then charges the fee as a fee transaction
+func (ba *BankAccount) DepositForeign(amount float64, currency Currency, converter CurrencyConverter) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	conversion, err := converter.Convert(amount, currency, ba.currency)
+	if err != nil {
+		return err
+	}
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	if err := ba.deposit(conversion.Amount); err != nil {
+		return err
+	}
+	if conversion.Fee > 0 {
+		return ba.assessFee(conversion.Fee)
+	}
+	return nil
+}
+
+// WithdrawForeign pays out amount in currency; the account is debited the
+// converted amount plus the fee, through the same limit and overdraft
+// policies as Withdraw
+func (ba *BankAccount) WithdrawForeign(amount float64, currency Currency, converter CurrencyConverter) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	conversion, err := converter.Convert(amount, currency, ba.currency)
+	if err != nil {
+		return err
+	}
+	unlock := lockAll(ba.lockScope(nil))
+	defer unlock()
+	if err := ba.withdrawWithFee(conversion.Amount, conversion.Fee); err != nil {
+		return fmt.Errorf("withdraw %.2f %s (%.2f %s with fee): %w", amount, currency, conversion.Amount+conversion.Fee, ba.currency, err)
+	}
+	return nil
+}
+
+// TransferForeign moves amount (in any currency) between accounts of any
+// currencies. Each side pays for its own conversion, in its own currency:
+// from is charged its fee, to receives the converted amount net of its fee.
+func TransferForeign(from, to *BankAccount, amount Money, converter CurrencyConverter) error {
+	if amount.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	debit, err := converter.Convert(amount.Amount, amount.Currency, from.currency)
+	if err != nil {
+		return err
+	}
+	credit, err := converter.Convert(amount.Amount, amount.Currency, to.currency)
+	if err != nil {
+		return err
+	}
+	if credit.Amount <= credit.Fee {
+		return fmt.Errorf("%w: fee %.2f %s exceeds the amount", ErrInvalidAmount, credit.Fee, to.currency)
+	}
+	return transfer(from, to, debit.Amount, credit.Amount-credit.Fee, debit.Fee)
+}
+
+// ============================================================================
+// 20. STANDING ORDERS - recurring transfers run by a clock-driven scheduler
+// ============================================================================
+
+// Schedule is the cron-like part: when is the next run after t?
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Daily runs every day at Hour:00 (like cron "0 H * * *")
+type Daily struct{ Hour int }
+
+func (d Daily) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.Hour, 0, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Weekly runs on Weekday at Hour:00 (like cron "0 H * * D")
+type Weekly struct {
+	Weekday time.Weekday
+	Hour    int
+}
+
+func (w Weekly) Next(after time.Time) time.Time {
+	next := Daily{w.Hour}.Next(after)
+	for next.Weekday() != w.Weekday {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// MonthlyOn runs on Day of each month at Hour:00 (like cron "0 H D * *");
+// Day must be 1-28 so every month has it
+type MonthlyOn struct {
+	Day  int
+	Hour int
+}
+
+func (m MonthlyOn) Next(after time.Time) time.Time {
+	next := time.Date(after.Year(), after.Month(), m.Day, m.Hour, 0, 0, 0, after.Location())
+	if !next.After(after) {
+		next = next.AddDate(0, 1, 0)
+	}
+	return next
+}
+
+type StandingOrder struct {
+	ID       string
+	From, To *BankAccount
+	Amount   Money
+	Schedule Schedule
+
+	due      time.Time // the occurrence being paid
+	next     time.Time // when to try it: due, or a retry after it
+	attempts int
+}
+
+// Execution is one attempt, successful or not
+type Execution struct {
+	Order   string
+	Due     time.Time
+	At      time.Time
+	Attempt int
+	Err     error
+	GaveUp  bool
+}
+
+// Scheduler retries an occurrence that failed for lack of funds every
+// retryDelay, up to maxRetries times, then skips to the next occurrence.
+// Any other error (closed account, ...) skips the occurrence at once.
+type Scheduler struct {
+	clock      Clock
+	orders     []*StandingOrder
+	retryDelay time.Duration
+	maxRetries int
+}
+
+func NewScheduler(clock Clock, retryDelay time.Duration, maxRetries int) *Scheduler {
+	return &Scheduler{clock: clock, retryDelay: retryDelay, maxRetries: maxRetries}
+}
+
+func (s *Scheduler) Add(order *StandingOrder) {
+	order.due = order.Schedule.Next(s.clock.Now())
+	order.next = order.due
+	s.orders = append(s.orders, order)
+}
+
+// RunDue executes everything that has come due by now, catching up on
+// occurrences missed while the scheduler was not running
+func (s *Scheduler) RunDue() []Execution {
+	now := s.clock.Now()
+	var executions []Execution
+	for _, order := range s.orders {
+		for !order.next.After(now) {
+			order.attempts++
+			err := Transfer(order.From, order.To, order.Amount)
+			execution := Execution{Order: order.ID, Due: order.due, At: order.next, Attempt: order.attempts, Err: err}
+			if errors.Is(err, ErrInsufficientFunds) && order.attempts <= s.maxRetries {
+				order.next = order.next.Add(s.retryDelay)
+			} else {
+				execution.GaveUp = err != nil
+				order.due = order.Schedule.Next(order.due)
+				order.next = order.due
+				order.attempts = 0
+			}
+			executions = append(executions, execution)
+		}
+	}
+	return executions
+}
+
+// ============================================================================
+// 21. LIMIT POLICIES - caps checked before any money leaves the account
+// ============================================================================
+
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitPolicy vetoes a withdrawal of amount; Transfer goes through Withdraw, so
+// it is covered too. Check runs with the account locked.
+type LimitPolicy interface {
+	Check(account *BankAccount, amount float64) error
+}
+
+func (ba *BankAccount) AddLimit(policy LimitPolicy) { ba.limits = append(ba.limits, policy) }
+
+type MaxPerTransaction struct{ Max float64 }
+
+func (p MaxPerTransaction) Check(account *BankAccount, amount float64) error {
+	if amount > p.Max {
+		return fmt.Errorf("%w: %.2f is over the %.2f per-transaction maximum", ErrLimitExceeded, amount, p.Max)
+	}
+	return nil
+}
+
+// DailyWithdrawalCap sums withdrawals in the rolling Window before now
+// (24h, not "since midnight"), so the cap cannot be doubled around midnight
+type DailyWithdrawalCap struct {
+	Cap    float64
+	Window time.Duration
+}
+
+func (p DailyWithdrawalCap) Check(account *BankAccount, amount float64) error {
+	now := account.clock.Now()
+	var spent float64
+	for _, tx := range account.transactionsBetween(now.Add(-p.Window), now.Add(time.Nanosecond)) {
+		if tx.Kind == TxWithdrawal || tx.Kind == TxWithdrawalReversed {
+			spent -= tx.Amount
+		}
+	}
+	if spent+amount > p.Cap {
+		return fmt.Errorf("%w: %.2f withdrawn in the last %v, cap %.2f", ErrLimitExceeded, spent, p.Window, p.Cap)
+	}
+	return nil
+}
+
+// ============================================================================
+// 22. IDEMPOTENCY - a retried request with the same key is applied only once
+// ============================================================================
+
+var ErrIdempotencyConflict = errors.New("idempotency key already used for a different operation")
+
+// DepositOnce credits amount unless key was already applied; replaying the
+// same request is a successful no-op, so clients can retry blindly
+func (ba *BankAccount) DepositOnce(key string, amount float64) error {
+	return ba.once(key, TxDeposit, amount, ba.deposit)
+}
+
+func (ba *BankAccount) WithdrawOnce(key string, amount float64) error {
+	return ba.once(key, TxWithdrawal, -amount, ba.withdraw)
+}
+
+// The seen-keys store is the ledger itself: the key is saved on the
+// transaction, so it survives persistence (see RestoreFromSnapshot)
+func (ba *BankAccount) once(key string, kind TransactionKind, signed float64, apply func(float64) error) error {
+	unlock := lockAll(ba.lockScope(nil))
+	defer unlock()
+	if i, seen := ba.idempotency[key]; seen {
+		if tx := ba.transactions[i]; tx.Kind != kind || tx.Amount != signed {
+			return fmt.Errorf("%w: %q was %s %.2f", ErrIdempotencyConflict, key, tx.Kind, tx.Amount)
+		}
+		return nil
+	}
+	// a failed attempt leaves no key behind, so the client may retry it
+	if err := apply(math.Abs(signed)); err != nil {
+		return err
+	}
+	// the movement is always the last entry (overdraft fees and sweeps come before it)
+	last := len(ba.transactions) - 1
+	ba.transactions[last].Key = key
+	ba.rememberKey(key, last)
+	return nil
+}
+
+func (ba *BankAccount) rememberKey(key string, index int) {
+	if ba.idempotency == nil {
+		ba.idempotency = make(map[string]int)
+	}
+	ba.idempotency[key] = index
+}
+
+// ============================================================================
+// 23. ACCOUNT NUMBERS - generated, checksummed, validated
+// ============================================================================
+
+var ErrInvalidAccountNumber = errors.New("invalid account number")
+
+type AccountNumberGenerator interface {
+	Next() string
+}
+
+// SequentialGenerator hands out PREFIX000001, PREFIX000002, ...
+type SequentialGenerator struct {
+	mu     sync.Mutex
+	prefix string
+	last   int
+}
+
+func NewSequentialGenerator(prefix string) *SequentialGenerator {
+	return &SequentialGenerator{prefix: prefix}
+}
+
+func (g *SequentialGenerator) Next() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.last++
+	return fmt.Sprintf("%s%06d", g.prefix, g.last)
+}
+
+// Mod97Generator builds IBAN-style numbers: 2-letter bank code, 2 check
+// digits, 10-digit serial. A single mistyped digit, or two swapped ones,
+// changes the mod-97 remainder, so typos are caught before any lookup.
+type Mod97Generator struct {
+	mu     sync.Mutex
+	bank   string
+	serial int
+}
+
+func NewMod97Generator(bank string) *Mod97Generator {
+	return &Mod97Generator{bank: bank}
+}
+
+func (g *Mod97Generator) Next() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.serial++
+	serial := fmt.Sprintf("%010d", g.serial)
+	check := 98 - mod97(serial+g.bank+"00")
+	return fmt.Sprintf("%s%02d%s", g.bank, check, serial)
+}
+
+// mod97 reads letters as numbers (A=10 ... Z=35) and reduces digit by digit,
+// so the value never overflows however long the number is
+func mod97(s string) int {
+	remainder := 0
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		}
+	}
+	return remainder
+}
+
+// checksummed reports whether number has the Mod97Generator shape: AA99 + digits
+func checksummed(number string) bool {
+	if len(number) < 5 {
+		return false
+	}
+	for i, r := range number {
+		letter, digit := r >= 'A' && r <= 'Z', r >= '0' && r <= '9'
+		if (i < 2 && !letter) || (i >= 2 && !digit) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateAccountNumber accepts 6-34 upper-case letters, digits and single
+// inner hyphens, starting with a letter; IBAN-shaped numbers must also pass
+// the mod-97 check
+func ValidateAccountNumber(number string) error {
+	if len(number) < 6 || len(number) > 34 {
+		return fmt.Errorf("%w: %q must be 6-34 characters", ErrInvalidAccountNumber, number)
+	}
+	if number[0] < 'A' || number[0] > 'Z' {
+		return fmt.Errorf("%w: %q must start with an upper-case letter", ErrInvalidAccountNumber, number)
+	}
+	for i, r := range number {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' && i < len(number)-1 && number[i-1] != '-':
+		default:
+			return fmt.Errorf("%w: %q has %q at position %d", ErrInvalidAccountNumber, number, r, i+1)
+		}
+	}
+	if checksummed(number) && mod97(number[4:]+number[:4]) != 1 {
+		return fmt.Errorf("%w: %q fails the mod-97 check", ErrInvalidAccountNumber, number)
+	}
+	return nil
+}
+
+// ============================================================================
+// 24. SNAPSHOTS - every private field out and back in, for recovery
+// ============================================================================
+
+// AccountSnapshot is the account's complete data. A Memento (section 8) is
+// opaque and only rolls back money; a snapshot is meant to be stored and
+// rebuilds the whole account. Wiring is not data and is not included:
+// observers, sinks, clocks, overdraft and limit policies are set up again
+// by whoever restores.
+type AccountSnapshot struct {
+	Number       string        `json:"number"`
+	Status       AccountStatus `json:"status"`
+	Currency     Currency      `json:"currency"`
+	Balance      float64       `json:"balance"`
+	Fees         []float64     `json:"fees,omitempty"`
+	LowBalance   float64       `json:"low_balance,omitempty"`
+	Transactions []Transaction `json:"transactions,omitempty"`
+}
+
+func (ba *BankAccount) Export() AccountSnapshot {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	return AccountSnapshot{
+		Number:       ba.accountNumber,
+		Status:       ba.status,
+		Currency:     ba.currency,
+		Balance:      ba.balance,
+		Fees:         append([]float64(nil), ba.fees...),
+		LowBalance:   ba.lowBalance,
+		Transactions: append([]Transaction(nil), ba.transactions...),
+	}
+}
+
+// RestoreFromSnapshot validates as it builds, since snapshots come from disk
+func RestoreFromSnapshot(s AccountSnapshot) (*BankAccount, error) {
+	account, err := NewBankAccount(s.Number, 0)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status != "" {
+		if _, known := accountTransitions[s.Status]; !known && s.Status != StatusClosed {
+			return nil, fmt.Errorf("snapshot %s: unknown status %q", s.Number, s.Status)
+		}
+		account.status = s.Status
+	}
+	if s.Currency != "" {
+		account.currency = s.Currency
+	}
+	account.balance = s.Balance
+	account.fees = append([]float64(nil), s.Fees...)
+	account.lowBalance = s.LowBalance
+	account.transactions = append([]Transaction(nil), s.Transactions...)
+	for i, tx := range account.transactions {
+		if tx.Key != "" {
+			account.rememberKey(tx.Key, i)
+		}
+	}
+	return account, nil
+}
+
+func SaveSnapshot(path string, snapshot AccountSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func LoadSnapshot(path string) (AccountSnapshot, error) {
+	var snapshot AccountSnapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("%s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// ============================================================================
+// 25. MAIN FUNCTION
+// ============================================================================
+
+func printResult(action string, err error) {
+	switch {
+	case err == nil:
+		fmt.Printf("  %-28s ok\n", action)
+	case errors.Is(err, ErrPermissionDenied):
+		fmt.Printf("  %-28s DENIED (%v)\n", action, err)
+	default:
+		fmt.Printf("  %-28s failed: %v\n", action, err)
+	}
+}
+
+func main() {
+	policyPath := flag.String("policy", "policy.json", "RBAC policy file")
+	interactive := flag.Bool("interactive", false, "read CLI commands from stdin")
+	storePath := flag.String("store", "", "JSON file for persisted accounts (default: a temp file)")
+	flag.Parse()
+
+	fmt.Println("=== Banking Demo in Go ===")
+
+	policy, err := LoadPolicyFile(*policyPath)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	account := MustNewBankAccount("ACC001", 1000)
+	if err := account.AssessFee(25); err != nil {
+		fmt.Println(err)
+	}
+
+	fmt.Println("\n1. RBAC through a protection proxy:")
+	customer := NewSecuredAccount(account, Principal{Name: "alice", Roles: []Role{"customer"}}, policy)
+	teller := NewSecuredAccount(account, Principal{Name: "tom", Roles: []Role{"teller"}}, policy)
+	manager := NewSecuredAccount(account, Principal{Name: "maria", Roles: []Role{"manager"}}, policy)
+
+	balance, err := customer.Balance()
+	printResult(fmt.Sprintf("alice views (%.2f)", balance), err)
+	printResult("alice deposits 100", customer.Deposit(100))
+	printResult("tom deposits 100", teller.Deposit(100))
+	printResult("tom waives the fee", teller.WaiveFee())
+	printResult("maria waives the fee", manager.WaiveFee())
+
+	balance, _ = account.Balance()
+	fmt.Printf("Final balance: %.2f\n", balance)
+
+	fmt.Println("\n2. Multi-currency wallet:")
+	rates := NewStaticRates(map[Currency]float64{EUR: 0.92, GBP: 0.79, JPY: 150})
+	wallet := NewWallet("alice", USD, rates)
+	printResult("deposit 500 USD", wallet.Deposit(USD, 500))
+	printResult("deposit 200 EUR", wallet.Deposit(EUR, 200))
+	converted, err := wallet.Convert(USD, JPY, 100)
+	printResult(fmt.Sprintf("convert 100 USD -> %.0f JPY", converted), err)
+	printResult("withdraw 300 EUR", wallet.Withdraw(EUR, 300))
+	_, err = wallet.Convert(EUR, "CHF", 50)
+	printResult("convert 50 EUR -> CHF", err)
+	for _, currency := range wallet.Currencies() {
+		fmt.Printf("  %s %10.2f\n", currency, wallet.Balance(currency))
+	}
+	valuation, err := wallet.Valuation()
+	printResult(fmt.Sprintf("valuation %.2f %s", valuation, USD), err)
+
+	fmt.Println("\n3. Undoable commands:")
+	checking := MustNewBankAccount("CHK001", 500)
+	savings := MustNewBankAccount("SAV001", 2000)
+	history := &CommandHistory{}
+	for _, command := range []Command{
+		NewDepositCommand(checking, 200),
+		NewTransferCommand(savings, checking, 300),
+		NewWithdrawCommand(checking, 5000),
+		NewWithdrawCommand(checking, 100),
+	} {
+		printResult(command.String(), history.Execute(command))
+	}
+	printBalances := func() {
+		c, _ := checking.Balance()
+		s, _ := savings.Balance()
+		fmt.Printf("  CHK001 %.2f  SAV001 %.2f  (history %d)\n", c, s, history.Len())
+	}
+	printBalances()
+	printResult("undo last 2", history.UndoLast(2))
+	printBalances()
+	printResult("undo last 5", history.UndoLast(5))
+	printBalances()
+
+	fmt.Println("\n4. Mementos before risky operations:")
+	risky := MustNewBankAccount("RSK001", 1000)
+	snapshots := NewAccountHistory(risky, 2)
+	for i, fee := range []float64{10, 20, 30} {
+		snapshots.Save(fmt.Sprintf("before fee %d", i+1))
+		printResult(fmt.Sprintf("assess fee %.0f", fee), risky.AssessFee(fee))
+	}
+	balance, _ = risky.Balance()
+	fmt.Printf("  balance %.2f, snapshots kept: %v\n", balance, snapshots.Labels())
+	for i := 0; i < 3; i++ {
+		label, err := snapshots.Rollback()
+		balance, _ = risky.Balance()
+		printResult(fmt.Sprintf("rollback to %q (%.2f)", label, balance), err)
+	}
+	printResult("restore RSK001 from CHK001", risky.Restore(checking.Snapshot("wrong account")))
+
+	fmt.Println("\n5. Observers on account events:")
+	observed := MustNewBankAccount("OBS001", 1000)
+	observed.SetLowBalanceThreshold(200)
+	audit := &AuditLogger{}
+	fraud := NewFraudDetector(500, 3)
+	observed.Subscribe(ConsoleNotifier{out: os.Stdout})
+	observed.Subscribe(audit)
+	observed.Subscribe(fraud)
+	printResult("deposit 100", observed.Deposit(100))
+	printResult("withdraw 600", observed.Withdraw(600))
+	printResult("withdraw 250", observed.Withdraw(250))
+	printResult("withdraw 100", observed.Withdraw(100))
+	fmt.Printf("  audit entries: %d, fraud flags: %v\n", len(audit.entries), fraud.suspicious)
+
+	fmt.Println("\n6. Transaction history:")
+	ledgerClock := &ManualClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	ledger := MustNewBankAccount("LED001", 500)
+	ledger.SetClock(ledgerClock)
+	for _, step := range []func() error{
+		func() error { return ledger.Deposit(1200) },
+		func() error { return ledger.Withdraw(300) },
+		func() error { return ledger.AssessFee(15) },
+		func() error { return ledger.WaiveFee() },
+		func() error { return ledger.Withdraw(5000) },
+		func() error { return ledger.Deposit(80) },
+	} {
+		if err := step(); err != nil {
+			fmt.Printf("  (rejected, not recorded: %v)\n", err)
+		}
+		ledgerClock.Advance(24 * time.Hour)
+	}
+	printTransactions(os.Stdout, ledger.Transactions())
+	from, to := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	fmt.Printf("  between %s and %s:\n", from.Format("Jan 02"), to.Format("Jan 02"))
+	printTransactions(os.Stdout, ledger.TransactionsBetween(from, to))
+
+	fmt.Println("\n7. Savings vs checking (same calls, different rules):")
+	bankClock := &ManualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	accounts := []Account{
+		MustNewBankAccount("BAS001", 300),
+		NewSavingsAccount("SAV002", 300, SimpleInterest{AnnualRate: 0.04}, 2, bankClock),
+		NewCheckingAccount("CHK002", 300, FeeBasedOverdraft{Limit: 300, Fee: 15}),
+	}
+	for _, acct := range accounts {
+		for _, amount := range []float64{100, 100, 350} {
+			printResult(fmt.Sprintf("%s withdraw %.0f", acct.Number(), amount), acct.Withdraw(amount))
+		}
+		balance, _ := acct.Balance()
+		fmt.Printf("  %-26T balance %.2f\n", acct, balance)
+	}
+	if savingsAccount, ok := accounts[1].(*SavingsAccount); ok {
+		bankClock.Advance(31 * 24 * time.Hour)
+		interest, err := savingsAccount.AccrueInterest(bankClock.Now())
+		printResult(fmt.Sprintf("SAV002 accrue %.2f interest", interest), err)
+		savingsAccount.StartPeriod()
+		printResult("SAV002 withdraw 50 (reset)", savingsAccount.Withdraw(50))
+	}
+
+	fmt.Println("\n8. Interest strategies (10,000 for one year):")
+	calculators := []struct {
+		name       string
+		calculator InterestCalculator
+	}{
+		{"simple 4%", SimpleInterest{AnnualRate: 0.04}},
+		{"daily compound 4%", DailyCompound{AnnualRate: 0.04}},
+		{"tiered 1%/3%/5%", TieredRate{Tiers: []RateTier{{2000, 0.01}, {8000, 0.03}, {math.Inf(1), 0.05}}}},
+	}
+	for _, c := range calculators {
+		opened := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		savings := NewSavingsAccount("SAV-INT", 10000, c.calculator, 3, &ManualClock{now: opened})
+		var total float64
+		for month := 1; month <= 12; month++ {
+			interest, _ := savings.AccrueInterest(opened.AddDate(0, month, 0))
+			total += interest
+		}
+		fmt.Printf("  %-18s monthly accruals %8.2f\n", c.name, total)
+	}
+	_, err = NewSavingsAccount("SAV-OLD", 100, SimpleInterest{0.04}, 1, bankClock).AccrueInterest(bankClock.Now().AddDate(0, 0, -1))
+	printResult("accrue for yesterday", err)
+
+	fmt.Println("\n9. Overdraft policies (balance 100, withdraw 180):")
+	reserve := MustNewBankAccount("RES001", 100)
+	for _, c := range []struct {
+		label  string
+		policy OverdraftPolicy
+	}{
+		{"deny", DenyOverdraft{}},
+		{"fee 20, limit 100", FeeBasedOverdraft{Limit: 100, Fee: 20}},
+		{"fee 20, limit 50", FeeBasedOverdraft{Limit: 50, Fee: 20}},
+		{"linked RES001", LinkedAccountOverdraft{Source: reserve}},
+		{"linked again", LinkedAccountOverdraft{Source: reserve}},
+	} {
+		checkingAccount := NewCheckingAccount("CHK003", 100, c.policy)
+		err := checkingAccount.Withdraw(180)
+		balance, _ := checkingAccount.Balance()
+		printResult(fmt.Sprintf("%s -> %.2f", c.label, balance), err)
+	}
+	balance, _ = reserve.Balance()
+	fmt.Printf("  RES001 left with %.2f\n", balance)
+
+	fmt.Println("\n10. Concurrent atomic transfers:")
+	pool := []*BankAccount{
+		MustNewBankAccount("TRF001", 1000), MustNewBankAccount("TRF002", 1000),
+		MustNewBankAccount("TRF003", 1000), MustNewBankAccount("TRF004", 1000),
+	}
+	total := func() float64 {
+		var sum float64
+		for _, a := range pool {
+			balance, _ := a.Balance()
+			sum += balance
+		}
+		return sum
+	}
+	before := total()
+	var wg sync.WaitGroup
+	for i := 0; i < 2000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// every pair is used in both directions, the deadlock-prone case
+			from, to := pool[i%len(pool)], pool[(i+i/len(pool)%(len(pool)-1)+1)%len(pool)]
+			Transfer(from, to, NewMoney(float64(i%250+1), USD))
+		}(i)
+	}
+	wg.Wait()
+	fmt.Printf("  2000 transfers: total before %.2f, after %.2f, conserved: %v\n", before, total(), before == total())
+	printResult("TRF001 -> TRF001", Transfer(pool[0], pool[0], NewMoney(10, USD)))
+	printResult("TRF001 -> TRF002 1,000,000", Transfer(pool[0], pool[1], NewMoney(1000000, USD)))
+
+	fmt.Println("\n11. Persistence through AccountRepository:")
+	path := *storePath
+	if path == "" {
+		dir, err := os.MkdirTemp("", "banking")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "accounts.json")
+	}
+	for _, r := range []struct {
+		name string
+		repo AccountRepository
+	}{{"in-memory", NewInMemoryAccountRepository()}, {"json-file", NewJSONFileAccountRepository(path)}} {
+		stored, err := loadOrOpen(r.repo, "PER001", 500)
+		if err == nil {
+			stored.Deposit(100)
+			err = r.repo.Save(stored)
+		}
+		printResult(r.name+" deposit 100 + save", err)
+	}
+	// a new repository on the same file stands in for a process restart
+	restarted := NewJSONFileAccountRepository(path)
+	if stored, err := restarted.FindByNumber("PER001"); err == nil {
+		balance, _ = stored.Balance()
+		fmt.Printf("  after restart PER001 has %.2f and %d transactions (rerun with -store to keep it)\n", balance, len(stored.Transactions()))
+	}
+	_, err = restarted.FindByNumber("NOPE01")
+	printResult("find NOPE01", err)
+	if listed, err := restarted.List(); err == nil {
+		fmt.Printf("  %d account(s) on file\n", len(listed))
+	}
+
+	fmt.Println("\n12. Event stream and statements:")
+	eventLog := &EventLog{}
+	streamClock := &ManualClock{now: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)}
+	streamed := OpenAccount("EVT001", 250, streamClock, MultiSink{ConsoleSink{out: os.Stdout}, eventLog})
+	for _, step := range []func() error{
+		func() error { return streamed.Deposit(400) },
+		func() error { return streamed.Withdraw(120) },
+		func() error { return streamed.Withdraw(530) },
+		streamed.Close,
+		func() error { return streamed.Deposit(10) },
+	} {
+		streamClock.Advance(48 * time.Hour)
+		if err := step(); err != nil {
+			fmt.Printf("  (rejected: %v)\n", err)
+		}
+	}
+	statements := NewStatementGenerator(eventLog)
+	if err := statements.Write(os.Stdout, "EVT001"); err != nil {
+		fmt.Println(err)
+	}
+	from, to = time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)
+	for _, format := range []StatementFormat{FormatText, FormatCSV, "pdf"} {
+		if err := statements.Generate(os.Stdout, ledger, from, to, format); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	fmt.Println("\n13. Account lifecycle:")
+	lifecycle := MustNewBankAccount("LIF001", 300)
+	for _, step := range []struct {
+		action string
+		run    func() error
+	}{
+		{"freeze", lifecycle.Freeze},
+		{"deposit 50 while frozen", func() error { return lifecycle.Deposit(50) }},
+		{"withdraw 20 while frozen", func() error { return lifecycle.Withdraw(20) }},
+		{"freeze again", lifecycle.Freeze},
+		{"unfreeze", lifecycle.Unfreeze},
+		{"withdraw 20", func() error { return lifecycle.Withdraw(20) }},
+		{"close", lifecycle.Close},
+		{"deposit 10 when closed", func() error { return lifecycle.Deposit(10) }},
+		{"reopen (unfreeze)", lifecycle.Unfreeze},
+	} {
+		printResult(step.action, step.run())
+	}
+	balance, _ = lifecycle.Balance()
+	fmt.Printf("  LIF001 %s with %.2f\n", lifecycle.Status(), balance)
+
+	fmt.Println("\n14. Audit decorator around the RBAC proxy:")
+	auditClock := &ManualClock{now: time.Date(2024, 6, 3, 14, 0, 0, 0, time.UTC)}
+	auditLog := WriterLogger{out: os.Stdout}
+	audited := MustNewBankAccount("AUD001", 400)
+	for _, who := range []Principal{{Name: "alice", Roles: []Role{"customer"}}, {Name: "tom", Roles: []Role{"teller"}}} {
+		// audit outside the proxy, so refused calls are recorded too
+		var acct Account = NewAuditedAccount(NewSecuredAccount(audited, who, policy), who.Name, auditLog, auditClock)
+		acct.Balance()
+		acct.Deposit(75)
+		acct.Withdraw(1000)
+		auditClock.Advance(90 * time.Second)
+	}
+
+	fmt.Println("\n15. Foreign-currency deposits, withdrawals and transfers:")
+	fx := NewFixedRateConverter(rates, RoundHalfEven, PercentFee{Percent: 1.5, Min: 1})
+	usdAccount := MustNewBankAccount("FX-USD", 1000)
+	eurAccount := NewBankAccountIn("FX-EUR", 500, EUR)
+	jpyAccount := NewBankAccountIn("FX-JPY", 0, JPY)
+	printResult("FX-USD deposit 200 EUR", usdAccount.DepositForeign(200, EUR, fx))
+	printResult("FX-USD withdraw 10000 JPY", usdAccount.WithdrawForeign(10000, JPY, fx))
+	printResult("FX-USD withdraw 5000 GBP", usdAccount.WithdrawForeign(5000, GBP, fx))
+	printResult("FX-USD deposit 10 CHF", usdAccount.DepositForeign(10, "CHF", fx))
+	printResult("FX-USD -> FX-EUR plain", Transfer(usdAccount, eurAccount, NewMoney(100, USD)))
+	printResult("FX-USD -> FX-EUR 100 EUR", TransferForeign(usdAccount, eurAccount, NewMoney(100, EUR), fx))
+	printResult("FX-EUR -> FX-JPY 50 EUR", TransferForeign(eurAccount, jpyAccount, NewMoney(50, EUR), fx))
+	for _, a := range []*BankAccount{usdAccount, eurAccount, jpyAccount} {
+		balance, _ := a.Balance()
+		fmt.Printf("  %s %12.2f %s\n", a.Number(), balance, a.Currency())
+	}
+	for _, rule := range []struct {
+		name  string
+		round RoundingRule
+	}{{"half-up", RoundHalfUp}, {"half-even", RoundHalfEven}, {"down", RoundDown}} {
+		fmt.Printf("  %-9s 2.125 USD -> %.2f, 2.5 JPY -> %.0f\n", rule.name, rule.round(2.125, USD), rule.round(2.5, JPY))
+	}
+
+	fmt.Println("\n16. Standing orders:")
+	schedulerClock := &ManualClock{now: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)}
+	payer := MustNewBankAccount("SO-PAY", 1400)
+	landlord := MustNewBankAccount("SO-RENT", 0)
+	gym := MustNewBankAccount("SO-GYM", 0)
+	scheduler := NewScheduler(schedulerClock, 24*time.Hour, 2)
+	scheduler.Add(&StandingOrder{ID: "rent", From: payer, To: landlord, Amount: NewMoney(1200, USD), Schedule: MonthlyOn{Day: 1, Hour: 9}})
+	scheduler.Add(&StandingOrder{ID: "gym", From: payer, To: gym, Amount: NewMoney(30, USD), Schedule: Weekly{Weekday: time.Monday, Hour: 6}})
+	for hour := 0; hour < 45*24; hour++ {
+		schedulerClock.Advance(time.Hour)
+		if schedulerClock.Now().Equal(time.Date(2024, 8, 2, 12, 0, 0, 0, time.UTC)) {
+			payer.Deposit(1500) // payday lands after rent was due
+		}
+		for _, e := range scheduler.RunDue() {
+			outcome := "paid"
+			switch {
+			case e.GaveUp:
+				outcome = "gave up: " + e.Err.Error()
+			case e.Err != nil:
+				outcome = "will retry: " + e.Err.Error()
+			}
+			if e.Order == "rent" || e.Err != nil {
+				fmt.Printf("  %s %-4s due %s try %d: %s\n", e.At.Format("Jan 02 15:04"), e.Order, e.Due.Format("Jan 02"), e.Attempt, outcome)
+			}
+		}
+	}
+	for _, a := range []*BankAccount{payer, landlord, gym} {
+		balance, _ := a.Balance()
+		fmt.Printf("  %-7s %8.2f\n", a.Number(), balance)
+	}
+
+	fmt.Println("\n17. Transaction limits over a rolling window:")
+	limitClock := &ManualClock{now: time.Date(2024, 9, 2, 20, 0, 0, 0, time.UTC)}
+	limited := MustNewBankAccount("LIM001", 5000)
+	limited.SetClock(limitClock)
+	limited.AddLimit(MaxPerTransaction{Max: 800})
+	limited.AddLimit(DailyWithdrawalCap{Cap: 1000, Window: 24 * time.Hour})
+	target := MustNewBankAccount("LIM002", 0)
+	for _, step := range []struct {
+		after  time.Duration
+		action string
+		run    func() error
+	}{
+		{0, "withdraw 900", func() error { return limited.Withdraw(900) }},
+		{0, "withdraw 600", func() error { return limited.Withdraw(600) }},
+		{3 * time.Hour, "transfer 500", func() error { return Transfer(limited, target, NewMoney(500, USD)) }},
+		{3 * time.Hour, "withdraw 400", func() error { return limited.Withdraw(400) }},
+		{18 * time.Hour, "withdraw 500", func() error { return limited.Withdraw(500) }},
+		{3 * time.Hour, "withdraw 500", func() error { return limited.Withdraw(500) }},
+	} {
+		limitClock.Advance(step.after)
+		printResult(limitClock.Now().Format("Mon 15:04 ")+step.action, step.run())
+	}
+
+	fmt.Println("\n18. Idempotent retries:")
+	idem := MustNewBankAccount("IDM001", 100)
+	// the client times out and retries; the bank applied the first attempt
+	for attempt := 1; attempt <= 3; attempt++ {
+		printResult(fmt.Sprintf("deposit 250 key req-7 #%d", attempt), idem.DepositOnce("req-7", 250))
+	}
+	printResult("withdraw 999 key req-8", idem.WithdrawOnce("req-8", 999))
+	printResult("withdraw 50 key req-8", idem.WithdrawOnce("req-8", 50))
+	printResult("withdraw 50 key req-8 again", idem.WithdrawOnce("req-8", 50))
+	printResult("deposit 50 key req-8", idem.DepositOnce("req-8", 50))
+	balance, _ = idem.Balance()
+	fmt.Printf("  IDM001 balance %.2f after %d transactions\n", balance, len(idem.Transactions()))
+
+	fmt.Println("\n19. Account numbers:")
+	sequential := NewSequentialGenerator("ACC")
+	iban := NewMod97Generator("BK")
+	var generated []string
+	for i := 0; i < 2; i++ {
+		generated = append(generated, sequential.Next(), iban.Next())
+	}
+	fmt.Printf("  generated: %v\n", generated)
+	last := generated[3]
+	typo := last[:len(last)-1] + "9"
+	swapped := last[:len(last)-2] + last[len(last)-1:] + last[len(last)-2:len(last)-1]
+	for _, number := range []string{generated[0], generated[3], typo, swapped, "acc001", "AB", "FX--USD", "ACC 001"} {
+		printResult("validate "+number, ValidateAccountNumber(number))
+	}
+	_, err = NewBankAccount(swapped, 100)
+	printResult("open "+swapped, err)
+	_, err = NewJSONFileAccountRepository(path).FindByNumber(typo)
+	printResult("find "+typo, err)
+
+	fmt.Println("\n20. Crash recovery from snapshots:")
+	snapshotDir, err := os.MkdirTemp("", "snapshots")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(snapshotDir)
+	snapshotPath := filepath.Join(snapshotDir, "CRS001.json")
+	crashy := MustNewBankAccount("CRS001", 1000)
+	crashy.SetClock(&ManualClock{now: time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC)})
+	crashy.SetLowBalanceThreshold(100)
+	for _, op := range []struct {
+		action string
+		run    func() error
+	}{
+		{"deposit 200", func() error { return crashy.Deposit(200) }},
+		{"deposit 75 key pay-42", func() error { return crashy.DepositOnce("pay-42", 75) }},
+		{"withdraw 50", func() error { return crashy.Withdraw(50) }},
+		{"fee 5", func() error { return crashy.AssessFee(5) }},
+	} {
+		// acknowledge an operation only once its snapshot is safely on disk
+		err := op.run()
+		if err == nil {
+			err = SaveSnapshot(snapshotPath, crashy.Export())
+		}
+		printResult(op.action+" + snapshot", err)
+	}
+	acknowledged, _ := json.Marshal(crashy.Export())
+	crashy.Deposit(999)
+	os.WriteFile(snapshotPath+".tmp", []byte(`{"number":"CRS001","bal`), 0o644) // torn write, never renamed
+	crashy = nil
+	fmt.Println("  *** crash during the next save ***")
+
+	snapshot, err := LoadSnapshot(snapshotPath)
+	if err == nil {
+		crashy, err = RestoreFromSnapshot(snapshot)
+	}
+	printResult("recover CRS001", err)
+	if crashy != nil {
+		recovered, _ := json.Marshal(crashy.Export())
+		balance, _ = crashy.Balance()
+		fmt.Printf("  balance %.2f, %d transactions, identical to last acknowledged state: %v\n",
+			balance, len(crashy.Transactions()), string(recovered) == string(acknowledged))
+		printResult("replay pay-42 after recovery", crashy.DepositOnce("pay-42", 75))
+		printResult("deposit 10 after recovery", crashy.Deposit(10))
+		fmt.Printf("  next transaction id: %s\n", crashy.Transactions()[len(crashy.Transactions())-1].ID)
+	}
+
+	fmt.Println("\n21. Authenticated CLI session:")
+	var credentials []Credential
+	for _, user := range []struct {
+		name, password string
+		role           Role
+	}{{"alice", "alice-pw", "customer"}, {"tom", "tom-pw", "teller"}, {"maria", "maria-pw", "manager"}} {
+		credential, err := NewCredential(user.name, user.password, user.role)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		credentials = append(credentials, credential)
+	}
+	manualClock := &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	var clock Clock = manualClock
+	if *interactive {
+		clock = SystemClock{}
+	}
+	sessions := NewSessionManager(NewInMemoryCredentialStore(credentials...), clock, 15*time.Minute)
+	cli := NewBankingCLI(sessions, policy, os.Stdout, account)
+
+	if *interactive {
+		cli.Run(os.Stdin)
+		return
+	}
+	script := []string{
+		"balance ACC001",
+		"login tom wrong-pw",
+		"login tom tom-pw",
+		"deposit ACC001 50",
+		"balance ACC001",
+		"waive ACC001",
+		"logout",
+		"login maria maria-pw",
+	}
+	for _, line := range script {
+		fmt.Printf("> %s\n", line)
+		if err := cli.Execute(line); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	}
+	manualClock.Advance(20 * time.Minute)
+	fmt.Println("(20 minutes later)\n> balance ACC001")
+	if err := cli.Execute("balance ACC001"); err != nil {
+		fmt.Printf("error: %v\n", err)
+	}
+}
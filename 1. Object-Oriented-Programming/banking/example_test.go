@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func statementLog() *EventLog {
+	log := &EventLog{}
+	at := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, event := range []AccountEvent{
+		{Kind: AccountOpened, Account: "ACC001", Balance: 1000},
+		{Kind: Deposited, Account: "ACC001", Amount: 500, Balance: 1500},
+		{Kind: Deposited, Account: "ACC002", Amount: 9, Balance: 9},
+		{Kind: Withdrawn, Account: "ACC001", Amount: 1499.995, Balance: 0.005},
+		{Kind: LowBalance, Account: "ACC001", Balance: 0.005},
+		{Kind: Frozen, Account: "ACC001"},
+		{Kind: Deposited, Account: "ACC001", Amount: 1234567.8, Balance: 1234567.805},
+		{Kind: Closed, Account: "ACC001"},
+	} {
+		event.At = at.Add(time.Duration(i) * 36 * time.Hour)
+		log.Emit(event)
+	}
+	return log
+}
+
+func TestAppendStatementMatchesWrite(t *testing.T) {
+	statements := NewStatementGenerator(statementLog())
+	for _, account := range []string{"ACC001", "ACC002"} {
+		var written bytes.Buffer
+		if err := statements.Write(&written, account); err != nil {
+			t.Fatal(err)
+		}
+		appended, err := statements.AppendStatement([]byte("prefix\n"), account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(appended), "prefix\n"+written.String(); got != want {
+			t.Errorf("%s:\ngot\n%s\nwant\n%s", account, got, want)
+		}
+	}
+
+	appended, err := statements.AppendStatement([]byte("prefix\n"), "NOPE")
+	if !errors.Is(err, ErrAccountNotFound) || string(appended) != "prefix\n" {
+		t.Errorf("unknown account: got %q, %v; want the buffer unchanged and ErrAccountNotFound", appended, err)
+	}
+}
+
+func TestAppendStatementAllocations(t *testing.T) {
+	statements := NewStatementGenerator(statementLog())
+	buf := make([]byte, 0, 1024)
+	allocs := testing.AllocsPerRun(100, func() { buf, _ = statements.AppendStatement(buf[:0], "ACC001") })
+	if allocs != 0 {
+		t.Errorf("AppendStatement into a reused buffer: %.0f allocs/op, want 0", allocs)
+	}
+}
+
+func BenchmarkStatementWrite(b *testing.B) {
+	statements := NewStatementGenerator(statementLog())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		statements.Write(io.Discard, "ACC001")
+	}
+}
+
+func BenchmarkStatementAppend(b *testing.B) {
+	statements := NewStatementGenerator(statementLog())
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ = statements.AppendStatement(buf[:0], "ACC001")
+	}
+}
+
+func totalBalance(accounts []*BankAccount) float64 {
+	var total float64
+	for _, a := range accounts {
+		balance, _ := a.Balance()
+		total += balance
+	}
+	return total
+}
+
+// Many goroutines move money around a pool that includes linked overdrafts
+// (sweeps between pool accounts), a frozen-and-unfrozen account and a closed
+// one (every transfer into it rolls back). Run with -race.
+func TestConcurrentTransfersConserveTotal(t *testing.T) {
+	reserve := MustNewBankAccount("RES001", 2000)
+	pool := []*BankAccount{
+		MustNewBankAccount("TRF001", 1000), MustNewBankAccount("TRF002", 1000),
+		MustNewBankAccount("TRF003", 1000), MustNewBankAccount("TRF004", 1000),
+		NewCheckingAccount("LNK001", 100, LinkedAccountOverdraft{Source: reserve}).BankAccount,
+		NewCheckingAccount("LNK002", 100, LinkedAccountOverdraft{Source: reserve}).BankAccount,
+		MustNewBankAccount("CLS001", 500),
+		reserve,
+	}
+	closed, toggled := pool[6], pool[0]
+	if err := closed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	before := totalBalance(pool)
+
+	const goroutines, transfers = 32, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < transfers; i++ {
+				from := pool[(g+i)%len(pool)]
+				to := pool[(g+2*i+1)%len(pool)]
+				// whole amounts keep the float sums exact
+				_ = Transfer(from, to, NewMoney(float64((g*7+i)%300+1), USD))
+			}
+		}(g)
+	}
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = toggled.Freeze()
+				_ = totalBalance(pool)
+				_ = toggled.Unfreeze()
+				_ = reserve.Transactions()
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+
+	if after := totalBalance(pool); after != before {
+		t.Fatalf("total before %.2f, after %.2f", before, after)
+	}
+	for _, a := range pool {
+		balance, _ := a.Balance()
+		transactions := a.Transactions()
+		if balance < 0 {
+			t.Errorf("%s went negative: %.2f", a.Number(), balance)
+		}
+		if n := len(transactions); n > 0 && transactions[n-1].Balance != balance {
+			t.Errorf("%s: balance %.2f, ledger ends at %.2f", a.Number(), balance, transactions[n-1].Balance)
+		}
+	}
+	if balance, _ := closed.Balance(); balance != 500 {
+		t.Errorf("closed account: balance %.2f, want 500", balance)
+	}
+}
+
+// Exported methods lock, so one account can be used from many goroutines
+func TestAccountMethodsUnderConcurrency(t *testing.T) {
+	account := MustNewBankAccount("CON001", 1000)
+	const goroutines, ops = 16, 100
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				_ = account.Deposit(2)
+				_ = account.Withdraw(1)
+				_ = account.AssessFee(1)
+				_ = account.WaiveFee()
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < ops; i++ {
+				_ = account.Freeze()
+				_ = account.Unfreeze()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every fee was waived again; withdrawals refused while frozen are not in the ledger
+	var deposited, withdrawn float64
+	for _, tx := range account.Transactions() {
+		switch tx.Kind {
+		case TxDeposit:
+			deposited += tx.Amount
+		case TxWithdrawal:
+			withdrawn -= tx.Amount
+		}
+	}
+	balance, _ := account.Balance()
+	if want := 1000 + deposited - withdrawn; balance != want || deposited != goroutines*ops*2 {
+		t.Fatalf("balance %.2f, want %.2f with %.0f deposited", balance, want, deposited)
+	}
+	if err := account.Close(); err != nil || account.Status() != StatusClosed {
+		t.Fatalf("close: %v, status %s", err, account.Status())
+	}
+}
+
+func ledgerKinds(account *BankAccount, from int) string {
+	var kinds []string
+	for _, tx := range account.Transactions()[from:] {
+		kinds = append(kinds, fmt.Sprintf("%s %+.2f", tx.Kind, tx.Amount))
+	}
+	return fmt.Sprint(kinds)
+}
+
+// A transfer into a closed account fails after the debit; the rollback must
+// undo everything the debit did, not just the debit itself
+func TestTransferRollbackReversesSideEffects(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		overdraft     func(reserve *BankAccount) OverdraftPolicy
+		balance       float64
+		from, reserve string
+	}{
+		{
+			name:      "overdraft fee",
+			overdraft: func(*BankAccount) OverdraftPolicy { return FeeBasedOverdraft{Limit: 100, Fee: 15} },
+			balance:   50,
+			from:      "[fee -15.00 withdrawal -100.00 withdrawal-reversed +100.00 fee-waived +15.00]",
+			reserve:   "[]",
+		},
+		{
+			name:      "linked sweep",
+			overdraft: func(reserve *BankAccount) OverdraftPolicy { return LinkedAccountOverdraft{Source: reserve} },
+			balance:   30,
+			from:      "[deposit +70.00 withdrawal -100.00 withdrawal-reversed +100.00 deposit-reversed -70.00]",
+			reserve:   "[withdrawal -70.00 withdrawal-reversed +70.00]",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reserve := MustNewBankAccount("RES001", 100)
+			from := NewCheckingAccount("CHK001", tc.balance, tc.overdraft(reserve)).BankAccount
+			to := MustNewBankAccount("CLS001", 0)
+			if err := to.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Transfer(from, to, NewMoney(100, USD))
+			if !errors.Is(err, ErrAccountClosed) {
+				t.Fatalf("got %v, want ErrAccountClosed", err)
+			}
+			if balance, _ := from.Balance(); balance != tc.balance {
+				t.Errorf("from: balance %.2f, want %.2f", balance, tc.balance)
+			}
+			if balance, _ := reserve.Balance(); balance != 100 {
+				t.Errorf("reserve: balance %.2f, want 100", balance)
+			}
+			if err := from.WaiveFee(); !errors.Is(err, ErrNoFeeToWaive) {
+				t.Errorf("a fee is still on the account: WaiveFee gave %v", err)
+			}
+			if got := ledgerKinds(from, 0); got != tc.from {
+				t.Errorf("from ledger:\n got %s\nwant %s", got, tc.from)
+			}
+			if got := ledgerKinds(reserve, 0); got != tc.reserve {
+				t.Errorf("reserve ledger:\n got %s\nwant %s", got, tc.reserve)
+			}
+		})
+	}
+}
+
+func TestTransferChecksCurrency(t *testing.T) {
+	from, to := MustNewBankAccount("USD001", 100), MustNewBankAccount("USD002", 0)
+	if err := Transfer(from, to, NewMoney(10, EUR)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("EUR between USD accounts: got %v, want ErrCurrencyMismatch", err)
+	}
+	if err := Transfer(from, to, NewMoney(10, USD)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Transfer(from, from, NewMoney(10, USD)); !errors.Is(err, ErrSameAccount) {
+		t.Fatalf("to itself: got %v, want ErrSameAccount", err)
+	}
+	if a, _ := from.Balance(); a != 90 {
+		t.Fatalf("from: balance %.2f, want 90", a)
+	}
+}
+
+// 2 USD per EUR and a flat 5 USD fee: 45 EUR costs 95 USD, 48 EUR costs 101
+func foreignConverter() CurrencyConverter {
+	return NewFixedRateConverter(NewStaticRates(map[Currency]float64{EUR: 0.5}), RoundHalfUp, FlatFee{Amount: 5})
+}
+
+// WithdrawForeign must obey the same overdraft and limit policies as Withdraw,
+// with the conversion fee counted in what the policy has to cover
+func TestWithdrawForeignGoesThroughPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		balance  float64
+		setup    func(account, reserve *BankAccount)
+		eur      float64
+		want     error
+		balances [2]float64 // account, reserve
+	}{
+		{"deny, covered", 100, func(a, r *BankAccount) {}, 45, nil, [2]float64{5, 100}},
+		{"deny, fee not covered", 100, func(a, r *BankAccount) {}, 48, ErrInsufficientFunds, [2]float64{100, 100}},
+		{"fee overdraft", 100, func(a, r *BankAccount) { a.SetOverdraftPolicy(FeeBasedOverdraft{Limit: 50, Fee: 10}) }, 48, nil, [2]float64{-11, 100}},
+		{"fee overdraft over limit", 100, func(a, r *BankAccount) { a.SetOverdraftPolicy(FeeBasedOverdraft{Limit: 5, Fee: 10}) }, 48, ErrInsufficientFunds, [2]float64{100, 100}},
+		{"linked sweep with fee", 50, func(a, r *BankAccount) { a.SetOverdraftPolicy(LinkedAccountOverdraft{Source: r}) }, 45, nil, [2]float64{0, 55}},
+		{"per-transaction maximum", 100, func(a, r *BankAccount) { a.AddLimit(MaxPerTransaction{Max: 80}) }, 45, ErrLimitExceeded, [2]float64{100, 100}},
+		{"frozen", 100, func(a, r *BankAccount) { a.Freeze() }, 45, ErrAccountFrozen, [2]float64{100, 100}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			account, reserve := MustNewBankAccount("FXA001", tc.balance), MustNewBankAccount("RES001", 100)
+			tc.setup(account, reserve)
+			err := account.WithdrawForeign(tc.eur, EUR, foreignConverter())
+			if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+			for i, a := range []*BankAccount{account, reserve} {
+				if balance, _ := a.Balance(); balance != tc.balances[i] {
+					t.Errorf("%s: balance %.2f, want %.2f", a.Number(), balance, tc.balances[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTransferForeignFeeNeedsCover(t *testing.T) {
+	from, to := MustNewBankAccount("FXA001", 100), NewBankAccountIn("FXB001", 0, EUR)
+	if err := TransferForeign(from, to, NewMoney(48, EUR), foreignConverter()); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("48 EUR: got %v, want ErrInsufficientFunds", err)
+	}
+	if err := TransferForeign(from, to, NewMoney(45, EUR), foreignConverter()); err != nil {
+		t.Fatal(err)
+	}
+	// to already holds EUR, so nothing is converted and no fee is charged on its side
+	for _, tc := range []struct {
+		account *BankAccount
+		want    float64
+	}{{from, 5}, {to, 45}} {
+		if balance, _ := tc.account.Balance(); balance != tc.want {
+			t.Errorf("%s: balance %.2f, want %.2f", tc.account.Number(), balance, tc.want)
+		}
+	}
+}
+
+// limitedAccount has a 800 per-transaction maximum and a 1000 cap per rolling
+// 24 hours, on a clock the test moves
+func limitedAccount() (*BankAccount, *ManualClock) {
+	clock := &ManualClock{now: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)}
+	account := MustNewBankAccount("LIM001", 5000)
+	account.SetClock(clock)
+	account.AddLimit(MaxPerTransaction{Max: 800})
+	account.AddLimit(DailyWithdrawalCap{Cap: 1000, Window: 24 * time.Hour})
+	return account, clock
+}
+
+func TestLimitsAcrossTheRollingWindow(t *testing.T) {
+	account, clock := limitedAccount()
+	target := MustNewBankAccount("LIM002", 0)
+	withdraw := func(amount float64) func() error {
+		return func() error { return account.Withdraw(amount) }
+	}
+	for _, step := range []struct {
+		after time.Duration
+		what  string
+		run   func() error
+		want  error
+	}{
+		{0, "withdraw 600", withdraw(600), nil},
+		{time.Hour, "withdraw 500, 1100 in 24h", withdraw(500), ErrLimitExceeded},
+		{time.Hour, "withdraw 400, exactly the cap", withdraw(400), nil},
+		{time.Hour, "withdraw 0.01 over the cap", withdraw(0.01), ErrLimitExceeded},
+		{21 * time.Hour, "24h after the 600: still inside the window", withdraw(1), ErrLimitExceeded},
+		{time.Nanosecond, "the 600 has left the window", withdraw(600), nil},
+		{0, "transfer 1 over the cap", func() error { return Transfer(account, target, NewMoney(1, USD)) }, ErrLimitExceeded},
+		{2 * time.Hour, "the 400 has left the window", withdraw(400), nil},
+		{0, "withdraw 800.01, over the maximum", withdraw(800.01), ErrLimitExceeded},
+		{48 * time.Hour, "withdraw 800.01 with the window empty", withdraw(800.01), ErrLimitExceeded},
+		{0, "transfer 800, exactly the maximum", func() error { return Transfer(account, target, NewMoney(800, USD)) }, nil},
+	} {
+		clock.Advance(step.after)
+		err := step.run()
+		if !errors.Is(err, step.want) || (step.want == nil && err != nil) {
+			t.Errorf("%s %s: got %v, want %v", clock.Now().Format("Mon 15:04:05.000000000"), step.what, err, step.want)
+		}
+	}
+	if balance, _ := target.Balance(); balance != 800 {
+		t.Errorf("target: balance %.2f, want 800", balance)
+	}
+}
+
+// A transfer that rolls back leaves a withdrawal-reversed entry, which gives
+// the cap back
+func TestRolledBackTransferDoesNotUseTheCap(t *testing.T) {
+	account, clock := limitedAccount()
+	closed := MustNewBankAccount("CLS001", 0)
+	if err := closed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Transfer(account, closed, NewMoney(700, USD)); !errors.Is(err, ErrAccountClosed) {
+		t.Fatalf("got %v, want ErrAccountClosed", err)
+	}
+	clock.Advance(time.Minute)
+	if err := account.Withdraw(800); err != nil {
+		t.Fatalf("withdraw 800 after the rollback: %v", err)
+	}
+	if err := account.Withdraw(201); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("withdraw 201 more: got %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestMalformedAccountNumbers(t *testing.T) {
+	for _, number := range []string{"acc001", "AB", "FX--USD", "ACC 001", "BK790000000020"} {
+		if account, err := NewBankAccount(number, 100); !errors.Is(err, ErrInvalidAccountNumber) || account != nil {
+			t.Errorf("NewBankAccount(%q): got %v, %v; want ErrInvalidAccountNumber", number, account, err)
+		}
+		if _, err := RestoreFromSnapshot(AccountSnapshot{Number: number, Balance: 100}); !errors.Is(err, ErrInvalidAccountNumber) {
+			t.Errorf("RestoreFromSnapshot(%q): got %v, want ErrInvalidAccountNumber", number, err)
+		}
+		repo := NewInMemoryAccountRepository()
+		if _, err := loadOrOpen(repo, number, 100); !errors.Is(err, ErrInvalidAccountNumber) {
+			t.Errorf("loadOrOpen(%q): got %v, want ErrInvalidAccountNumber", number, err)
+		}
+		if accounts, _ := repo.List(); len(accounts) != 0 {
+			t.Errorf("loadOrOpen(%q) saved %d accounts", number, len(accounts))
+		}
+	}
+
+	if account, err := NewBankAccount(NewMod97Generator("BK").Next(), 100); err != nil || account == nil {
+		t.Fatalf("generated number: got %v, %v", account, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustNewBankAccount accepted a malformed number")
+		}
+	}()
+	MustNewBankAccount("acc001", 100)
+}
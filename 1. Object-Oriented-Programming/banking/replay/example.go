@@ -0,0 +1,237 @@
+// Event Replay Tool - Go
+// Flow: JSON-lines Event Log -> Event-Sourced Ledger -> Replay up to a point -> Reconstructed State
+//
+// Run:           go run example.go                        (replay everything)
+// Up to event:   go run example.go -upto 5
+// Up to time:    go run example.go -until 2024-01-04T00:00:00Z
+// Step by step:  go run example.go -upto 7 -trace
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// ============================================================================
+// 1. EVENTS - facts that happened, stored one JSON object per line
+// ============================================================================
+
+type EventType string
+
+const (
+	AccountOpened EventType = "AccountOpened"
+	Deposited     EventType = "Deposited"
+	Withdrawn     EventType = "Withdrawn"
+	FeeAssessed   EventType = "FeeAssessed"
+	FeeWaived     EventType = "FeeWaived"
+	AccountClosed EventType = "AccountClosed"
+)
+
+type Event struct {
+	Seq     int       `json:"seq"`
+	At      time.Time `json:"at"`
+	Type    EventType `json:"type"`
+	Account string    `json:"account"`
+	Owner   string    `json:"owner,omitempty"`
+	Amount  float64   `json:"amount,omitempty"`
+}
+
+// ReadEventLog decodes a JSON-lines log and checks sequence numbers are increasing
+func ReadEventLog(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if n := len(events); n > 0 && event.Seq <= events[n-1].Seq {
+			return nil, fmt.Errorf("line %d: sequence %d is not after %d", line, event.Seq, events[n-1].Seq)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// ============================================================================
+// 2. EVENT-SOURCED LEDGER - state is only ever changed by applying events
+// ============================================================================
+
+var (
+	ErrUnknownAccount = errors.New("unknown account")
+	ErrAccountClosed  = errors.New("account closed")
+	ErrOverdrawn      = errors.New("event would overdraw account")
+)
+
+type AccountState struct {
+	Number  string
+	Owner   string
+	Balance float64
+	Fees    float64
+	Closed  bool
+	Events  int
+}
+
+type Ledger struct {
+	accounts map[string]*AccountState
+	applied  int
+	lastAt   time.Time
+}
+
+func NewLedger() *Ledger {
+	return &Ledger{accounts: make(map[string]*AccountState)}
+}
+
+// Apply folds one event into the state, rejecting events that break invariants
+func (l *Ledger) Apply(event Event) error {
+	account, exists := l.accounts[event.Account]
+	if event.Type == AccountOpened {
+		if exists {
+			return fmt.Errorf("seq %d: account %s already open", event.Seq, event.Account)
+		}
+		l.accounts[event.Account] = &AccountState{Number: event.Account, Owner: event.Owner, Balance: event.Amount, Events: 1}
+		l.record(event)
+		return nil
+	}
+	if !exists {
+		return fmt.Errorf("seq %d: %w %s", event.Seq, ErrUnknownAccount, event.Account)
+	}
+	if account.Closed {
+		return fmt.Errorf("seq %d: %w %s", event.Seq, ErrAccountClosed, event.Account)
+	}
+	switch event.Type {
+	case Deposited:
+		account.Balance += event.Amount
+	case Withdrawn:
+		if event.Amount > account.Balance {
+			return fmt.Errorf("seq %d: %w %s", event.Seq, ErrOverdrawn, event.Account)
+		}
+		account.Balance -= event.Amount
+	case FeeAssessed:
+		account.Balance -= event.Amount
+		account.Fees += event.Amount
+	case FeeWaived:
+		account.Balance += event.Amount
+		account.Fees -= event.Amount
+	case AccountClosed:
+		account.Closed = true
+	default:
+		return fmt.Errorf("seq %d: unknown event type %q", event.Seq, event.Type)
+	}
+	account.Events++
+	l.record(event)
+	return nil
+}
+
+func (l *Ledger) record(event Event) {
+	l.applied++
+	l.lastAt = event.At
+}
+
+// Accounts returns a sorted copy of the current state
+func (l *Ledger) Accounts() []AccountState {
+	states := make([]AccountState, 0, len(l.accounts))
+	for _, account := range l.accounts {
+		states = append(states, *account)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Number < states[j].Number })
+	return states
+}
+
+// ============================================================================
+// 3. REPLAY - rebuild state as it was at an event index or a timestamp
+// ============================================================================
+
+// ReplayOptions: zero values mean "no limit"
+type ReplayOptions struct {
+	UpToIndex int       // apply at most this many events
+	Until     time.Time // apply only events at or before this time
+	Trace     func(event Event, ledger *Ledger)
+}
+
+func Replay(events []Event, options ReplayOptions) (*Ledger, error) {
+	ledger := NewLedger()
+	for i, event := range events {
+		if options.UpToIndex > 0 && i >= options.UpToIndex {
+			break
+		}
+		if !options.Until.IsZero() && event.At.After(options.Until) {
+			break
+		}
+		if err := ledger.Apply(event); err != nil {
+			return ledger, err
+		}
+		if options.Trace != nil {
+			options.Trace(event, ledger)
+		}
+	}
+	return ledger, nil
+}
+
+func printLedger(w io.Writer, ledger *Ledger) {
+	fmt.Fprintf(w, "State after %d events (last at %s):\n", ledger.applied, ledger.lastAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "  %-8s %-6s %10s %8s %7s %s\n", "ACCOUNT", "OWNER", "BALANCE", "FEES", "EVENTS", "STATUS")
+	for _, account := range ledger.Accounts() {
+		status := "open"
+		if account.Closed {
+			status = "closed"
+		}
+		fmt.Fprintf(w, "  %-8s %-6s %10.2f %8.2f %7d %s\n",
+			account.Number, account.Owner, account.Balance, account.Fees, account.Events, status)
+	}
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	logPath := flag.String("log", "events.jsonl", "JSON-lines event log")
+	upTo := flag.Int("upto", 0, "replay only the first N events")
+	until := flag.String("until", "", "replay only events at or before this RFC3339 time")
+	trace := flag.Bool("trace", false, "print every event as it is applied")
+	flag.Parse()
+
+	file, err := os.Open(*logPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	events, err := ReadEventLog(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	options := ReplayOptions{UpToIndex: *upTo}
+	if *until != "" {
+		if options.Until, err = time.Parse(time.RFC3339, *until); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if *trace {
+		options.Trace = func(event Event, ledger *Ledger) {
+			fmt.Printf("  #%-3d %s %-13s %-7s %8.2f\n", event.Seq, event.At.Format("Jan 02 15:04"), event.Type, event.Account, event.Amount)
+		}
+	}
+
+	fmt.Printf("=== Replaying %d events from %s ===\n", len(events), *logPath)
+	ledger, err := Replay(events, options)
+	if err != nil {
+		fmt.Printf("replay stopped: %v\n", err)
+	}
+	printLedger(os.Stdout, ledger)
+}
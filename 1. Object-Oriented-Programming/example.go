@@ -1,33 +1,70 @@
 // Complete OOP Demo - Go
-// Flow: Struct -> Access Control -> Constructor -> Embedding -> Composition -> Polymorphism -> Interface -> Encapsulation
+// Flow: Struct -> Access Control -> Constructor -> Embedding -> Composition -> Polymorphism -> Interface -> Encapsulation -> Dispatch Table -> Extension Wrappers -> Mixins -> Diagram Export
 
 package main
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
 
 // ============================================================================
 // 1. STRUCT (like class) with ACCESS CONTROL & CONSTRUCTOR
 // ============================================================================
 
-// Static-like variable (package level)
-var totalEmployees int
+// Static-like counters (package level). A plain "var totalEmployees int" with
+// totalEmployees++ is a data race once constructors run on several goroutines
+// (go run -race reports it), so the counters live in an atomic collector.
+type StatsCollector struct {
+	employees atomic.Int64
+	accounts  atomic.Int64
+}
+
+func NewStatsCollector() *StatsCollector { return &StatsCollector{} }
+
+func (s *StatsCollector) EmployeeCreated() { s.employees.Add(1) }
+func (s *StatsCollector) AccountOpened()   { s.accounts.Add(1) }
+func (s *StatsCollector) Employees() int   { return int(s.employees.Load()) }
+func (s *StatsCollector) Accounts() int    { return int(s.accounts.Load()) }
+
+// stats is the collector constructors report to; swap it with SetStatsCollector
+// (e.g. a fresh one per test). The pointer itself is atomic too.
+var stats atomic.Pointer[StatsCollector]
+
+func init() { stats.Store(NewStatsCollector()) }
+
+func SetStatsCollector(collector *StatsCollector) { stats.Store(collector) }
 
 type Employee struct {
 	name       string  // unexported (private) - lowercase
 	salary     float64 // unexported (private)
 	Department string  // exported (public) - uppercase
+	Taggable           // mixins (see section 11)
+	Auditable
+	Serializable
 }
 
 // NewEmployee is a constructor-like function that creates a new Employee struct.
 func NewEmployee(name string, salary float64, department string) *Employee {
-	totalEmployees++ // like static increment
+	stats.Load().EmployeeCreated() // like static increment, but race-free
 	fmt.Printf("Employee created: %s\n", name)
-	return &Employee{name: name, salary: salary, Department: department}
+	e := &Employee{name: name, salary: salary, Department: department}
+	e.Serializable = NewSerializable(func() map[string]interface{} {
+		return map[string]interface{}{"name": e.name, "department": e.Department, "tags": e.Tags()}
+	})
+	return e
 }
 
 // Getter for private field (encapsulation)
 func (e *Employee) GetName() string { return e.name }
-func GetTotalEmployees() int        { return totalEmployees }
+func GetTotalEmployees() int        { return stats.Load().Employees() }
+func GetTotalAccounts() int         { return stats.Load().Accounts() }
 
 // Method for Employee
 func (e *Employee) Work() {
@@ -171,6 +208,46 @@ func (tl *TeamLead) DoWork() {
 // 2. Interface methods are satisfied by single implementation
 // 3. If embedding conflicts occur, you must explicitly resolve them
 
+// Conflict in action: two embedded types that both provide Describe()
+type Printer struct{ model string }
+
+func (p Printer) Describe() string { return "printer " + p.model }
+
+type Scanner struct{ model string }
+
+func (s Scanner) Describe() string { return "scanner " + s.model }
+
+type Describer interface {
+	Describe() string
+}
+
+// UnresolvedDevice compiles, but Describe is ambiguous at the same depth:
+//
+//	d := UnresolvedDevice{}
+//	d.Describe() // compile error: ambiguous selector d.Describe
+//
+// The ambiguous method is also dropped from the method set, so
+// UnresolvedDevice silently does NOT satisfy Describer.
+type UnresolvedDevice struct {
+	Printer
+	Scanner
+}
+
+// MultiFunctionDevice resolves the conflict explicitly: its own Describe sits at a
+// shallower depth, shadows both promoted ones, and forwards to each parent by name.
+type MultiFunctionDevice struct {
+	Printer
+	Scanner
+}
+
+func NewMultiFunctionDevice(model string) *MultiFunctionDevice {
+	return &MultiFunctionDevice{Printer: Printer{model: model}, Scanner: Scanner{model: model}}
+}
+
+func (m *MultiFunctionDevice) Describe() string {
+	return m.Printer.Describe() + " + " + m.Scanner.Describe()
+}
+
 // ============================================================================
 // 5. POLYMORPHISM - Method Overloading simulation & Runtime polymorphism
 // ============================================================================
@@ -180,7 +257,7 @@ type Calculator struct{}
 // Go doesn't have method overloading, so we simulate with different names
 func (c *Calculator) CalculateInt(a, b int) int           { return a + b }
 func (c *Calculator) CalculateFloat(a, b float64) float64 { return a + b }
-func (c *Calculator) CalculateThree(a, b, c int) int      { return a + b + c }
+func (c *Calculator) CalculateThree(a, b, d int) int      { return a + b + d }
 
 // Alternative: using variadic and type assertion
 func (c *Calculator) Calculate(values ...interface{}) interface{} {
@@ -199,6 +276,24 @@ func (c *Calculator) Calculate(values ...interface{}) interface{} {
 	return nil
 }
 
+// Alternative: generics (Go 1.18+). One body, type-checked at compile time.
+// Methods cannot declare type parameters, so this is a plain function.
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+func Add[T Number](a, b T) T { return a + b }
+
+// Cost of each approach (BenchmarkCalc* in example_test.go, go test -bench Calc):
+// typed methods and Add[T] compile to the same few instructions, while
+// Calculate(...interface{}) boxes values into interfaces and pays for the
+// type switch - several times slower, plus an allocation per call.
+//
+// Calculate is not wired to the interpreter's Calculator.Eval on purpose: it
+// stays as the overloading example those benchmarks measure. Eval is its
+// replacement for real input and lives in "3. Additional Contexts/Design-Patterns/interpreter";
+// every module is its own package main without a go.mod, so this tour cannot import it.
+
 // ============================================================================
 // 6. ABSTRACTION - Interface (pure abstraction/contract)
 // ============================================================================
@@ -215,13 +310,24 @@ type Drivable interface {
 
 // Base struct (like abstract class)
 type Vehicle struct {
-	brand string
+	brand    string
+	Nameable // mixins (see section 11)
+	Taggable
+	Auditable
+	Serializable
 }
 
 func NewVehicle(brand string) Vehicle {
 	return Vehicle{brand: brand}
 }
 
+// bindTraits wires Serializable to this vehicle; call it once the vehicle has its final address
+func (v *Vehicle) bindTraits() {
+	v.Serializable = NewSerializable(func() map[string]interface{} {
+		return map[string]interface{}{"brand": v.brand, "name": v.Name(), "tags": v.Tags()}
+	})
+}
+
 func (v *Vehicle) DisplayInfo() {
 	fmt.Printf("Vehicle: %s\n", v.brand)
 }
@@ -232,7 +338,9 @@ type Car struct {
 }
 
 func NewCar(brand string) *Car {
-	return &Car{Vehicle: NewVehicle(brand)}
+	c := &Car{Vehicle: NewVehicle(brand)}
+	c.bindTraits()
+	return c
 }
 
 func (c *Car) Start() {
@@ -247,12 +355,19 @@ func (c *Car) Drive() {
 // 7. ENCAPSULATION - Data hiding with controlled access
 // ============================================================================
 
+// Sentinel errors: callers branch with errors.Is instead of guessing why a bool was false
+var (
+	ErrNegativeAmount    = errors.New("amount must be positive")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
 type BankAccount struct {
 	balance       float64 // unexported (private)
 	accountNumber string  // unexported (private)
 }
 
 func NewBankAccount(accountNumber string, initialBalance float64) *BankAccount {
+	stats.Load().AccountOpened()
 	balance := initialBalance
 	if balance < 0 {
 		balance = 0
@@ -261,22 +376,26 @@ func NewBankAccount(accountNumber string, initialBalance float64) *BankAccount {
 }
 
 // Controlled access through methods
-func (ba *BankAccount) GetBalance() float64 { return ba.balance }
+func (ba *BankAccount) GetBalance() float64      { return ba.balance }
+func (ba *BankAccount) GetAccountNumber() string { return ba.accountNumber }
 
-func (ba *BankAccount) Deposit(amount float64) bool {
-	if amount > 0 {
-		ba.balance += amount
-		return true
+func (ba *BankAccount) Deposit(amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: deposit %.2f", ErrNegativeAmount, amount)
 	}
-	return false
+	ba.balance += amount
+	return nil
 }
 
-func (ba *BankAccount) Withdraw(amount float64) bool {
-	if amount > 0 && amount <= ba.balance {
-		ba.balance -= amount
-		return true
+func (ba *BankAccount) Withdraw(amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("%w: withdraw %.2f", ErrNegativeAmount, amount)
 	}
-	return false
+	if amount > ba.balance {
+		return fmt.Errorf("%w: balance %.2f, requested %.2f", ErrInsufficientFunds, ba.balance, amount)
+	}
+	ba.balance -= amount
+	return nil
 }
 
 // ============================================================================
@@ -288,11 +407,323 @@ type Worker interface {
 }
 
 // ============================================================================
-// 9. MAIN FUNCTION - Demonstrating all concepts
+// 9. TYPE SWITCH vs DISPATCH TABLE - which one respects OCP?
+// ============================================================================
+
+// Motorcycle is a second vehicle kind so kind-specific handling has something to choose between
+type Motorcycle struct {
+	Vehicle
+}
+
+func NewMotorcycle(brand string) *Motorcycle {
+	m := &Motorcycle{Vehicle: NewVehicle(brand)}
+	m.bindTraits()
+	return m
+}
+
+func (m *Motorcycle) Start() {
+	fmt.Printf("%s motorcycle started\n", m.brand)
+}
+
+// Approach A: type switch.
+// Every new vehicle kind means opening this function and adding a case,
+// so it is closed for extension and open for modification (violates OCP).
+func InspectWithSwitch(v Vehicular) string {
+	switch vt := v.(type) {
+	case *Car:
+		return fmt.Sprintf("%s car: check 4 tyres, brakes and AC", vt.brand)
+	case *Motorcycle:
+		return fmt.Sprintf("%s motorcycle: check 2 tyres and chain", vt.brand)
+	default:
+		return fmt.Sprintf("no inspection for %T", v)
+	}
+}
+
+// Approach B: dispatch table (registry) keyed by reflect.Type.
+// A new kind registers its own handler next to its own type; InspectWithRegistry
+// never changes, so the lookup is open for extension and closed for modification (OCP).
+type InspectionHandler func(v Vehicular) string
+
+var inspectionHandlers = map[reflect.Type]InspectionHandler{}
+
+// RegisterInspection binds a handler to the dynamic type of sample (e.g. (*Car)(nil))
+func RegisterInspection(sample Vehicular, handler InspectionHandler) {
+	inspectionHandlers[reflect.TypeOf(sample)] = handler
+}
+
+func InspectWithRegistry(v Vehicular) string {
+	if handler, ok := inspectionHandlers[reflect.TypeOf(v)]; ok {
+		return handler(v)
+	}
+	return fmt.Sprintf("no inspection for %T", v)
+}
+
+func init() {
+	RegisterInspection((*Car)(nil), func(v Vehicular) string {
+		return fmt.Sprintf("%s car: check 4 tyres, brakes and AC", v.(*Car).brand)
+	})
+	RegisterInspection((*Motorcycle)(nil), func(v Vehicular) string {
+		return fmt.Sprintf("%s motorcycle: check 2 tyres and chain", v.(*Motorcycle).brand)
+	})
+}
+
+// Truck is "added later": it only registers a handler, no existing function is edited
+type Truck struct {
+	Vehicle
+}
+
+func NewTruck(brand string) *Truck {
+	t := &Truck{Vehicle: NewVehicle(brand)}
+	t.bindTraits()
+	return t
+}
+
+func (t *Truck) Start() {
+	fmt.Printf("%s truck started\n", t.brand)
+}
+
+func init() {
+	RegisterInspection((*Truck)(nil), func(v Vehicular) string {
+		return fmt.Sprintf("%s truck: check 6 tyres and cargo straps", v.(*Truck).brand)
+	})
+}
+
+// Trade-off: the switch is simpler and checked by the compiler, good for a closed set of kinds.
+// The table costs a map lookup and a type assertion inside each handler, but new kinds plug in
+// without touching shared code - prefer it when the set of kinds keeps growing.
+
+// ============================================================================
+// 10. EXTENSION METHODS via WRAPPER TYPES
+// ============================================================================
+
+// Go only allows methods on types declared in the same package. If BankAccount
+// lived in another package (pretend it does), "func (ba *BankAccount) Statement()"
+// would not compile. Go's answer to C# extension methods is a wrapper type that
+// embeds the original and adds the new behavior, using only the exported API.
+
+type StatementAccount struct {
+	*BankAccount // embedded pointer: GetBalance, Deposit, Withdraw are promoted
+}
+
+func NewStatementAccount(account *BankAccount) StatementAccount {
+	return StatementAccount{BankAccount: account}
+}
+
+// Statement is the "extension method" (value receiver)
+func (sa StatementAccount) Statement() string {
+	return fmt.Sprintf("Statement for %s: balance %.2f", sa.GetAccountNumber(), sa.GetBalance())
+}
+
+type Statementer interface {
+	Statement() string
+}
+
+type Depositor interface {
+	Deposit(amount float64) error
+}
+
+// AuditedStatementAccount adds the same method with a POINTER receiver (see pitfall 3)
+type AuditedStatementAccount struct {
+	*BankAccount
+	printed int
+}
+
+func (a *AuditedStatementAccount) Statement() string {
+	a.printed++
+	return fmt.Sprintf("Audited statement #%d for %s", a.printed, a.GetAccountNumber())
+}
+
+// Pitfalls:
+// 1. A defined type does NOT keep the methods of its underlying type:
+//      type LegacyAccount BankAccount
+//      var la LegacyAccount
+//      la.GetBalance() // compile error: la.GetBalance undefined
+//    Only embedding promotes methods.
+// 2. The wrapper is a new type. It satisfies interfaces the embedded type satisfies
+//    (Depositor, via promotion), but *BankAccount does not gain Statement(), and a
+//    wrapper stored in an interface cannot be type-asserted back to *BankAccount.
+// 3. Method sets: with a pointer receiver, only *AuditedStatementAccount satisfies
+//    Statementer; a plain AuditedStatementAccount value does not.
+
+// ============================================================================
+// 11. MIXINS - small embedded traits (has-behavior, not is-a)
+// ============================================================================
+
+// Each trait is a tiny struct with its own state and methods, designed to be embedded.
+// Vehicle and Employee both embed them, yet an Employee is not a Vehicle:
+// traits share behavior across unrelated types, embedding for is-a shares identity.
+
+// Nameable gives a type a changeable display name
+type Nameable struct {
+	displayName string
+}
+
+func (n *Nameable) SetName(name string) { n.displayName = name }
+func (n *Nameable) Name() string        { return n.displayName }
+
+// Taggable keeps free-form labels
+type Taggable struct {
+	tags []string
+}
+
+func (t *Taggable) AddTag(tag string) { t.tags = append(t.tags, tag) }
+func (t *Taggable) Tags() []string    { return append([]string(nil), t.tags...) } // copy, keep slice private
+
+// Auditable records what happened to its host
+type Auditable struct {
+	trail []string
+}
+
+func (a *Auditable) Record(event string)  { a.trail = append(a.trail, event) }
+func (a *Auditable) AuditTrail() []string { return append([]string(nil), a.trail...) }
+
+// Serializable renders its host as JSON. An embedded struct cannot see the struct that
+// embeds it (there is no "this" of the outer type), so the host hands over a snapshot func.
+type Serializable struct {
+	snapshot func() map[string]interface{}
+}
+
+func NewSerializable(snapshot func() map[string]interface{}) Serializable {
+	return Serializable{snapshot: snapshot}
+}
+
+func (s Serializable) Serialize() string {
+	if s.snapshot == nil {
+		return "{}"
+	}
+	data, err := json.Marshal(s.snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// Tagger is satisfied by anything that embeds Taggable (through a pointer)
+type Tagger interface {
+	AddTag(tag string)
+	Tags() []string
+}
+
+// ============================================================================
+// 12. DIAGRAM EXPORT - render the structures above as Graphviz DOT or Mermaid
+// ============================================================================
+
+// Paste the output into https://dreampuf.github.io/GraphvizOnline or https://mermaid.live
+
+type Edge struct {
+	From, To, Label string
+}
+
+type Graph struct {
+	Name  string
+	Edges []Edge
+}
+
+// nodes lists every node once, in first-seen order, so output is stable
+func (g Graph) nodes() []string {
+	seen := make(map[string]bool)
+	var nodes []string
+	for _, e := range g.Edges {
+		for _, n := range []string{e.From, e.To} {
+			if !seen[n] {
+				seen[n] = true
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes
+}
+
+// Named is satisfied by Employee and, via promotion, everything that embeds it
+type Named interface {
+	GetName() string
+}
+
+// OrgChart is composition: it only stores who reports to whom
+type OrgChart struct {
+	edges []Edge
+}
+
+func (o *OrgChart) AddReport(manager, report Named) {
+	o.edges = append(o.edges, Edge{From: manager.GetName(), To: report.GetName(), Label: "manages"})
+}
+
+func (o *OrgChart) Graph() Graph {
+	return Graph{Name: "OrgChart", Edges: append([]Edge(nil), o.edges...)}
+}
+
+// EmbeddingGraph walks embedded (anonymous) fields with reflection, so it
+// stays correct when a type gains or loses a mixin
+func EmbeddingGraph(name string, samples ...interface{}) Graph {
+	g := Graph{Name: name}
+	visited := make(map[reflect.Type]bool)
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || visited[t] {
+			return
+		}
+		visited[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.Anonymous {
+				continue
+			}
+			inner := field.Type
+			if inner.Kind() == reflect.Pointer {
+				inner = inner.Elem()
+			}
+			g.Edges = append(g.Edges, Edge{From: t.Name(), To: inner.Name(), Label: "embeds"})
+			walk(inner)
+		}
+	}
+	for _, sample := range samples {
+		walk(reflect.TypeOf(sample))
+	}
+	return g
+}
+
+func WriteDOT(w io.Writer, g Graph) error {
+	if _, err := fmt.Fprintf(w, "digraph %s {\n  node [shape=box];\n", g.Name); err != nil {
+		return err
+	}
+	for _, e := range g.Edges {
+		if _, err := fmt.Fprintf(w, "  %q -> %q [label=%q];\n", e.From, e.To, e.Label); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// WriteMermaid uses generated ids so names with spaces or punctuation stay valid
+func WriteMermaid(w io.Writer, g Graph) error {
+	if _, err := fmt.Fprintf(w, "%%%% %s\ngraph TD\n", g.Name); err != nil {
+		return err
+	}
+	ids := make(map[string]string)
+	for i, n := range g.nodes() {
+		ids[n] = fmt.Sprintf("n%d", i)
+		if _, err := fmt.Fprintf(w, "  %s[\"%s\"]\n", ids[n], n); err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges {
+		if _, err := fmt.Fprintf(w, "  %s -->|%s| %s\n", ids[e.From], e.Label, ids[e.To]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// 13. MAIN FUNCTION - Demonstrating all concepts
 // ============================================================================
 
 func main() {
-	fmt.Println("=== Complete OOP Demo in Go ===\n")
+	fmt.Println("=== Complete OOP Demo in Go ===")
 
 	// 1. STRUCT, CONSTRUCTOR, PACKAGE-LEVEL VARIABLE
 	fmt.Println("1. Structs & Objects:")
@@ -300,10 +731,23 @@ func main() {
 	mgr := NewManager("Bob", 80000, "IT", "DevTeam")
 	fmt.Printf("Total employees: %d\n", GetTotalEmployees())
 
+	// Counters stay exact under concurrency (try: go run -race example.go)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			NewBankAccount(fmt.Sprintf("ACC-%03d", n), 0)
+		}(i)
+	}
+	wg.Wait()
+	fmt.Printf("Accounts opened concurrently: %d\n", GetTotalAccounts())
+
 	// 2. INHERITANCE & RUNTIME POLYMORPHISM
 	fmt.Println("\n2. Embedding (Inheritance) & Runtime Polymorphism:")
-	workers := []Worker{emp, mgr, NewDeveloper("Charlie", 60000, "IT", "Java"),
-		NewSeniorDeveloper("David", 90000, "IT", "Python", 8)}
+	dev := NewDeveloper("Charlie", 60000, "IT", "Java")
+	senior := NewSeniorDeveloper("David", 90000, "IT", "Python", 8)
+	workers := []Worker{emp, mgr, dev, senior}
 	for _, w := range workers {
 		w.Work() // Different behavior based on actual type (runtime polymorphism)
 	}
@@ -321,6 +765,10 @@ func main() {
 	lead.DoWork()
 	fmt.Println("// Go solves diamond problem through interface design")
 	fmt.Println("// Single method implementation satisfies multiple interfaces")
+	_, unresolvedOK := interface{}(UnresolvedDevice{}).(Describer)
+	fmt.Printf("UnresolvedDevice is Describer: %v (ambiguous Describe is not in its method set)\n", unresolvedOK)
+	var describer Describer = NewMultiFunctionDevice("HP-4200")
+	fmt.Printf("Resolved by forwarding: %s\n", describer.Describe())
 
 	// 5. METHOD OVERLOADING SIMULATION (Compile-time like)
 	fmt.Println("\n5. Method Overloading Simulation:")
@@ -329,6 +777,7 @@ func main() {
 	fmt.Printf("CalculateFloat(5.5, 3.2): %.1f\n", calc.CalculateFloat(5.5, 3.2))
 	fmt.Printf("CalculateThree(1, 2, 3): %d\n", calc.CalculateThree(1, 2, 3))
 	fmt.Printf("Calculate(10, 20): %v\n", calc.Calculate(10, 20))
+	fmt.Printf("Add[int](10, 20): %d, Add[float64](1.5, 2.5): %.1f\n", Add(10, 20), Add(1.5, 2.5))
 
 	// 6. ABSTRACTION (Interface)
 	fmt.Println("\n6. Abstraction (Interface contract):")
@@ -343,8 +792,21 @@ func main() {
 	fmt.Println("\n7. Encapsulation (Data hiding & controlled access):")
 	account := NewBankAccount("ACC001", 1000)
 	fmt.Printf("Initial balance: %.0f\n", account.GetBalance())
-	account.Deposit(500)
-	account.Withdraw(200)
+	if err := account.Deposit(500); err != nil {
+		fmt.Println("Deposit failed:", err)
+	}
+	if err := account.Withdraw(200); err != nil {
+		fmt.Println("Withdraw failed:", err)
+	}
+	for _, amount := range []float64{5000, -50} {
+		err := account.Withdraw(amount)
+		switch {
+		case errors.Is(err, ErrInsufficientFunds):
+			fmt.Println("Rejected, not enough money:", err)
+		case errors.Is(err, ErrNegativeAmount):
+			fmt.Println("Rejected, bad input:", err)
+		}
+	}
 	fmt.Printf("Final balance: %.0f\n", account.GetBalance())
 	// account.balance = 9999 // Error: cannot access unexported field
 
@@ -355,5 +817,64 @@ func main() {
 		fmt.Printf("Type assertion success: %s is a manager\n", manager.GetName())
 	}
 
+	// 9. TYPE SWITCH vs DISPATCH TABLE
+	fmt.Println("\n9. Type Switch vs Dispatch Table (OCP):")
+	fleet := []Vehicular{NewCar("Honda"), NewMotorcycle("Yamaha"), NewTruck("Volvo")}
+	for _, v := range fleet {
+		fmt.Printf("switch:   %s\n", InspectWithSwitch(v))
+		fmt.Printf("registry: %s\n", InspectWithRegistry(v))
+	}
+	// The switch misses Truck until someone edits it; the registry already knows it
+
+	// 10. EXTENSION METHODS via WRAPPER TYPES
+	fmt.Println("\n10. Extension Methods via Wrapper Types:")
+	statementAccount := NewStatementAccount(account)
+	fmt.Println(statementAccount.Statement())
+	var depositor Depositor = statementAccount // promoted Deposit satisfies Depositor
+	if err := depositor.Deposit(250); err != nil {
+		fmt.Println("Deposit failed:", err)
+	}
+	fmt.Println(statementAccount.Statement()) // wrapper shares the same underlying account
+
+	_, plainIsStatementer := interface{}(account).(Statementer)
+	fmt.Printf("*BankAccount is Statementer: %v\n", plainIsStatementer)
+	var wrapped interface{} = statementAccount
+	_, wrapperIsAccount := wrapped.(*BankAccount)
+	fmt.Printf("wrapper asserts to *BankAccount: %v (unwrap with .BankAccount instead)\n", wrapperIsAccount)
+
+	audited := AuditedStatementAccount{BankAccount: account}
+	_, valueIsStatementer := interface{}(audited).(Statementer)
+	_, pointerIsStatementer := interface{}(&audited).(Statementer)
+	fmt.Printf("pointer-receiver wrapper: value is Statementer: %v, pointer is Statementer: %v\n",
+		valueIsStatementer, pointerIsStatementer)
+
+	// 11. MIXINS
+	fmt.Println("\n11. Mixins (embedded traits):")
+	familyCar := NewCar("Subaru")
+	familyCar.SetName("Blue Whale")
+	familyCar.Record("oil changed")
+	taggables := []Tagger{familyCar, emp} // a Car and an Employee, no common base type
+	for _, t := range taggables {
+		t.AddTag("2024-review")
+	}
+	emp.Record("promoted")
+	fmt.Println(familyCar.Serialize())
+	fmt.Println(emp.Serialize())
+	fmt.Printf("Audit trails: car=%v employee=%v\n", familyCar.AuditTrail(), emp.AuditTrail())
+
+	// 12. DIAGRAM EXPORT
+	fmt.Println("\n12. Diagram Export (DOT / Mermaid):")
+	org := &OrgChart{}
+	org.AddReport(mgr, emp)
+	org.AddReport(mgr, senior)
+	org.AddReport(senior, dev)
+	if err := WriteDOT(os.Stdout, org.Graph()); err != nil {
+		fmt.Println("export failed:", err)
+	}
+	hierarchy := EmbeddingGraph("VehicleHierarchy", Car{}, Motorcycle{}, Truck{})
+	if err := WriteMermaid(os.Stdout, hierarchy); err != nil {
+		fmt.Println("export failed:", err)
+	}
+
 	fmt.Println("\n=== All OOP concepts demonstrated ===")
 }
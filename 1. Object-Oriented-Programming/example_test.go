@@ -0,0 +1,398 @@
+package main
+
+import (
+	"flag"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The old designs are kept for comparison, so the tests that hold them to the
+// new ones' guarantees fail. They are skipped unless asked for:
+//
+//	go test -race example.go example_test.go -violations
+var violations = flag.Bool("violations", false, "run the tests the old designs are expected to fail")
+
+func skipViolation(t *testing.T, why string) {
+	t.Helper()
+	if !*violations {
+		t.Skip("expected to fail, run with -violations to see it: " + why)
+	}
+}
+
+// scooter is a kind neither dispatcher knows about
+type scooter struct {
+	Vehicle
+}
+
+func (s *scooter) Start() {}
+
+// Every registered kind needs a sample here, so a new registration cannot go untested
+var inspectionSamples = map[reflect.Type]Vehicular{
+	reflect.TypeOf((*Car)(nil)):        NewCar("Honda"),
+	reflect.TypeOf((*Motorcycle)(nil)): NewMotorcycle("Yamaha"),
+	reflect.TypeOf((*Truck)(nil)):      NewTruck("Volvo"),
+}
+
+// addedAfterSwitch are kinds registered without editing InspectWithSwitch;
+// the switch missing them is the OCP point of section 9
+var addedAfterSwitch = map[reflect.Type]bool{reflect.TypeOf((*Truck)(nil)): true}
+
+func TestInspectSwitchAndRegistryAgree(t *testing.T) {
+	for kind := range inspectionHandlers {
+		v, ok := inspectionSamples[kind]
+		if !ok {
+			t.Errorf("%v is registered but has no sample in inspectionSamples", kind)
+			continue
+		}
+		registry, viaSwitch := InspectWithRegistry(v), InspectWithSwitch(v)
+		if addedAfterSwitch[kind] {
+			if want := "no inspection for " + kind.String(); viaSwitch != want {
+				t.Errorf("%v: switch gave %q, want %q until someone edits it", kind, viaSwitch, want)
+			}
+			if registry == viaSwitch {
+				t.Errorf("%v: registry has no handler: %q", kind, registry)
+			}
+			continue
+		}
+		if registry != viaSwitch {
+			t.Errorf("%v: registry %q, switch %q", kind, registry, viaSwitch)
+		}
+	}
+
+	unknown := &scooter{Vehicle: NewVehicle("Vespa")}
+	want := "no inspection for *main.scooter"
+	if got := InspectWithRegistry(unknown); got != want {
+		t.Errorf("registry on an unknown kind: got %q, want %q", got, want)
+	}
+	if got := InspectWithSwitch(unknown); got != want {
+		t.Errorf("switch on an unknown kind: got %q, want %q", got, want)
+	}
+}
+
+// Receivers, constructors and interface boxing on the tutorial's own types.
+//
+//	go test -bench . -benchmem example.go example_test.go
+//	go build -gcflags=-m example.go 2>&1 | grep -E "escapes|moved to heap"
+
+var (
+	sink        interface{}
+	vehicleSink Vehicular
+	totalSink   float64
+)
+
+// Method pairs that differ only in their receiver: the value one copies the
+// whole struct into the receiver on every call, the pointer one its address.
+// They are kept out of line, so the copy is not optimised away.
+//
+//go:noinline
+func (e Employee) annualByValue() float64 { return e.salary * 12 }
+
+//go:noinline
+func (e *Employee) annualByPointer() float64 { return e.salary * 12 }
+
+// payroll is an Employee with ten years of payslips, about 1 KB
+type payroll struct {
+	Employee
+	monthly [120]float64
+}
+
+//go:noinline
+func (p payroll) lastYearByValue() float64 { return sumYear(&p.monthly) }
+
+//go:noinline
+func (p *payroll) lastYearByPointer() float64 { return sumYear(&p.monthly) }
+
+func sumYear(monthly *[120]float64) (total float64) {
+	for _, pay := range monthly[108:] {
+		total += pay
+	}
+	return total
+}
+
+func benchEmployee() *Employee {
+	return &Employee{name: "Alice", salary: 50000, Department: "IT"}
+}
+
+func benchPayroll() *payroll {
+	p := &payroll{Employee: *benchEmployee()}
+	for i := range p.monthly {
+		p.monthly[i] = p.salary
+	}
+	return p
+}
+
+// Each benchmark reports the bytes copied into the receiver per call
+func BenchmarkReceiverValue(b *testing.B) {
+	b.Run("Employee", func(b *testing.B) {
+		e := benchEmployee()
+		b.ReportAllocs()
+		b.ReportMetric(float64(reflect.TypeOf(*e).Size()), "receiver-B")
+		for i := 0; i < b.N; i++ {
+			totalSink += e.annualByValue() // *e copied into the receiver
+		}
+	})
+	b.Run("payroll", func(b *testing.B) {
+		p := benchPayroll()
+		b.ReportAllocs()
+		b.ReportMetric(float64(reflect.TypeOf(*p).Size()), "receiver-B")
+		for i := 0; i < b.N; i++ {
+			totalSink += p.lastYearByValue()
+		}
+	})
+}
+
+func BenchmarkReceiverPointer(b *testing.B) {
+	b.Run("Employee", func(b *testing.B) {
+		e := benchEmployee()
+		b.ReportAllocs()
+		b.ReportMetric(float64(reflect.TypeOf(e).Size()), "receiver-B")
+		for i := 0; i < b.N; i++ {
+			totalSink += e.annualByPointer()
+		}
+	})
+	b.Run("payroll", func(b *testing.B) {
+		p := benchPayroll()
+		b.ReportAllocs()
+		b.ReportMetric(float64(reflect.TypeOf(p).Size()), "receiver-B")
+		for i := 0; i < b.N; i++ {
+			totalSink += p.lastYearByPointer()
+		}
+	})
+}
+
+// NewVehicle returns a value the caller keeps on its stack; NewCar returns a
+// pointer that escapes, and bindTraits adds a closure that escapes with it
+func BenchmarkConstructorValue(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := NewVehicle("Toyota")
+		totalSink += float64(len(v.brand))
+	}
+}
+
+func BenchmarkConstructorPointer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vehicleSink = NewCar("Toyota")
+	}
+}
+
+// An interface holds (type, pointer): a struct value is copied to the heap
+// first, a pointer is stored as is
+func BenchmarkBoxValue(b *testing.B) {
+	v := NewVehicle("Toyota")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sink = v
+	}
+}
+
+func BenchmarkBoxPointer(b *testing.B) {
+	car := NewCar("Toyota")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vehicleSink = car
+	}
+}
+
+// The three "overloading" options from section 5. The interface{} results go
+// to sink, as they would when callers pass them on; if the caller type-asserts
+// right away, inlining can erase most of the cost. Ints 0-255 are boxed for free
+// (the runtime keeps a static table), so the inputs are larger, like real amounts.
+var (
+	calcInts   = [4]int{1000, 2500, 40000, 123456}
+	calcFloats = [4]float64{10.5, 99.99, 1234.5, 0.25}
+	intSink    int
+)
+
+func BenchmarkCalcTypedInt(b *testing.B) {
+	calc := &Calculator{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		intSink += calc.CalculateInt(calcInts[i&3], calcInts[(i+1)&3])
+	}
+}
+
+func BenchmarkCalcGenericInt(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		intSink += Add(calcInts[i&3], calcInts[(i+1)&3])
+	}
+}
+
+func BenchmarkCalcInterfaceInt(b *testing.B) {
+	calc := &Calculator{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sink = calc.Calculate(calcInts[i&3], calcInts[(i+1)&3])
+	}
+}
+
+func BenchmarkCalcTypedFloat(b *testing.B) {
+	calc := &Calculator{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		totalSink += calc.CalculateFloat(calcFloats[i&3], calcFloats[(i+1)&3])
+	}
+}
+
+func BenchmarkCalcGenericFloat(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		totalSink += Add(calcFloats[i&3], calcFloats[(i+1)&3])
+	}
+}
+
+func BenchmarkCalcInterfaceFloat(b *testing.B) {
+	calc := &Calculator{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sink = calc.Calculate(calcFloats[i&3], calcFloats[(i+1)&3])
+	}
+}
+
+type allocBudget struct {
+	name   string
+	budget float64
+	run    func()
+}
+
+// TestAllocationBudgets fails when a change makes one of these paths allocate more
+func TestAllocationBudgets(t *testing.T) {
+	e, p, car, v, calc := benchEmployee(), benchPayroll(), NewCar("Toyota"), NewVehicle("Toyota"), &Calculator{}
+	for _, b := range []allocBudget{
+		{"value receiver", 0, func() { totalSink += e.annualByValue() }},
+		{"pointer receiver", 0, func() { totalSink += e.annualByPointer() }},
+		{"1 KB value receiver", 0, func() { totalSink += p.lastYearByValue() }},
+		{"1 KB pointer receiver", 0, func() { totalSink += p.lastYearByPointer() }},
+		{"constructor returning a value", 0, func() { v := NewVehicle("Toyota"); totalSink += float64(len(v.brand)) }},
+		{"constructor returning a pointer", 2, func() { vehicleSink = NewCar("Toyota") }},
+		{"boxing a struct value", 1, func() { sink = v }},
+		{"boxing a pointer", 0, func() { vehicleSink = car }},
+		{"Calculator.CalculateInt", 0, func() { intSink += calc.CalculateInt(calcInts[0], calcInts[1]) }},
+		{"Add[int]", 0, func() { intSink += Add(calcInts[0], calcInts[1]) }},
+		{"Calculate(...interface{}) int", 1, func() { sink = calc.Calculate(calcInts[0], calcInts[1]) }},
+		{"Calculate(...interface{}) float64", 1, func() { sink = calc.Calculate(calcFloats[0], calcFloats[1]) }},
+	} {
+		if allocs := testing.AllocsPerRun(100, b.run); allocs > b.budget {
+			t.Errorf("%s: %.0f allocs/op, budget %.0f", b.name, allocs, b.budget)
+		}
+	}
+}
+
+// Run these with -race: the detector, not the final count, is what catches the
+// old pattern, because a lost increment only shows up now and then.
+const (
+	hammerGoroutines = 16
+	hammerPerG       = 1000
+)
+
+func hammer(work func()) {
+	var wg sync.WaitGroup
+	for g := 0; g < hammerGoroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < hammerPerG; i++ {
+				work()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// plainStats is the old pattern: "var totalEmployees int" and totalEmployees++
+type plainStats struct{ employees, accounts int }
+
+func (s *plainStats) EmployeeCreated() { s.employees++ }
+func (s *plainStats) AccountOpened()   { s.accounts++ }
+
+func TestPlainCountersUnderConcurrency(t *testing.T) {
+	skipViolation(t, "totalEmployees++ from several goroutines is a data race")
+	s := &plainStats{}
+	hammer(func() { s.EmployeeCreated(); s.AccountOpened() })
+	if want := hammerGoroutines * hammerPerG; s.employees != want || s.accounts != want {
+		t.Fatalf("counted %d employees and %d accounts, want %d of each", s.employees, s.accounts, want)
+	}
+}
+
+// The violation above stays opt-in, but that it races is checked by default:
+// this runs it under the race detector in a child go test and expects a report
+func TestPlainCountersAreReportedAsRacy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the package again with -race")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	out, err := exec.Command(goTool, "test", "-race", "-count=1", "-run", "^TestPlainCountersUnderConcurrency$",
+		"example.go", "example_test.go", "-violations").CombinedOutput()
+	if strings.Contains(string(out), "-race is not supported") {
+		t.Skip("race detector not supported here")
+	}
+	if err == nil || !strings.Contains(string(out), "WARNING: DATA RACE") {
+		t.Fatalf("plain counters ran clean under -race (err %v):\n%s", err, out)
+	}
+}
+
+// The atomic StatsCollector under the same load: exact totals, and clean under -race
+func TestStatsCollectorUnderConcurrency(t *testing.T) {
+	s := NewStatsCollector()
+	hammer(func() {
+		s.EmployeeCreated()
+		s.AccountOpened()
+		_, _ = s.Employees(), s.Accounts()
+	})
+	if want := hammerGoroutines * hammerPerG; s.Employees() != want || s.Accounts() != want {
+		t.Fatalf("counted %d employees and %d accounts, want %d of each", s.Employees(), s.Accounts(), want)
+	}
+}
+
+// The constructors report to whichever collector is installed, while another
+// goroutine swaps it; every increment lands in exactly one of them
+func TestConstructorsCountWhileCollectorIsSwapped(t *testing.T) {
+	defer SetStatsCollector(stats.Load())
+	collectors := []*StatsCollector{NewStatsCollector(), NewStatsCollector()}
+	SetStatsCollector(collectors[0])
+
+	done := make(chan struct{})
+	swapped := make(chan struct{})
+	go func() {
+		defer close(swapped)
+		for i := 1; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				SetStatsCollector(collectors[i%2])
+				_, _ = GetTotalEmployees(), GetTotalAccounts()
+			}
+		}
+	}()
+
+	const perG = 10 // NewEmployee prints a line per call
+	var wg sync.WaitGroup
+	for g := 0; g < hammerGoroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perG; i++ {
+				NewEmployee("Worker", 1000, "Ops")
+				NewBankAccount("ACC-RACE", 10)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	<-swapped
+
+	employees := collectors[0].Employees() + collectors[1].Employees()
+	accounts := collectors[0].Accounts() + collectors[1].Accounts()
+	if want := hammerGoroutines * perG; employees != want || accounts != want {
+		t.Fatalf("counted %d employees and %d accounts across both collectors, want %d of each", employees, accounts, want)
+	}
+}
@@ -0,0 +1,1420 @@
+// Vehicles Demo - Go
+// Flow: Vehicle Hierarchy -> EfficiencyModel -> VehicleFactory -> Telemetry -> VehicleManager -> Fleet ->
+// Maintenance -> Rentals
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// 1. VEHICLES - the Vehicular hierarchy from the OOP tour, with mileage
+// ============================================================================
+
+var (
+	ErrDuplicateVehicle = errors.New("vehicle already in fleet")
+	ErrVehicleNotFound  = errors.New("vehicle not found")
+	ErrInvalidDistance  = errors.New("distance must be positive")
+	ErrOutOfFuel        = errors.New("out of fuel")
+	ErrInvalidCharge    = errors.New("charge must be positive")
+	ErrInvalidQuantity  = errors.New("quantity must be positive")
+	ErrOverloaded       = errors.New("load exceeds cargo capacity")
+	ErrNotEnoughCargo   = errors.New("not that much cargo aboard")
+	ErrBusFull          = errors.New("not enough free seats")
+	ErrNotEnoughRiders  = errors.New("not that many passengers aboard")
+)
+
+type Vehicular interface {
+	ID() string
+	Start()
+	Stop()
+	DisplayBasicInfo()
+	CalculateFuelEfficiency(profile DrivingProfile) float64 // km per liter
+	Telemetry() *Telemetry
+	Mileage() float64
+}
+
+// Vehicle is the embedded base: identity, plus the telemetry unit that knows
+// the engine and burns fuel through its efficiency model
+type Vehicle struct {
+	id        string
+	brand     string
+	model     string
+	telemetry *Telemetry // composition: the vehicle has a telemetry unit
+}
+
+func NewVehicle(id, brand, model string, tankLiters, engineLiters float64, efficiency EfficiencyModel) Vehicle {
+	return Vehicle{id: id, brand: brand, model: model, telemetry: NewTelemetry(id, tankLiters, engineLiters, efficiency)}
+}
+
+func (v *Vehicle) ID() string            { return v.id }
+func (v *Vehicle) Telemetry() *Telemetry { return v.telemetry }
+func (v *Vehicle) Mileage() float64      { return v.telemetry.Odometer() }
+
+func (v *Vehicle) Stop() { fmt.Printf("%s %s stopped\n", v.brand, v.model) }
+
+// CalculateFuelEfficiency asks the vehicle's model, so the concrete types
+// differ by the model they carry rather than by a hard-coded number
+func (v *Vehicle) CalculateFuelEfficiency(profile DrivingProfile) float64 {
+	return v.telemetry.KmPerLiter(profile)
+}
+
+// UseEfficiencyModel swaps the model, e.g. after an engine remap; the trips
+// that follow burn fuel at the new rate
+func (v *Vehicle) UseEfficiencyModel(model EfficiencyModel) { v.telemetry.efficiency = model }
+
+type Car struct {
+	Vehicle
+	doors int
+}
+
+func NewCar(id, brand, model string) *Car {
+	vehicle := NewVehicle(id, brand, model, 50, 1.6, CombustionModel{RatedKmPerLiter: 15.5, ReferenceLiters: 1.6})
+	return &Car{Vehicle: vehicle, doors: 4}
+}
+
+func (c *Car) Start() { fmt.Printf("%s %s car started\n", c.brand, c.model) }
+func (c *Car) DisplayBasicInfo() {
+	fmt.Printf("Car %s: %s %s, %d doors, %.0f km\n", c.id, c.brand, c.model, c.doors, c.Mileage())
+}
+func (c *Car) Class() VehicleClass { return ClassCar }
+
+type Motorcycle struct {
+	Vehicle
+}
+
+func NewMotorcycle(id, brand, model string) *Motorcycle {
+	vehicle := NewVehicle(id, brand, model, 15, 0.7, CombustionModel{RatedKmPerLiter: 35, ReferenceLiters: 0.7})
+	return &Motorcycle{Vehicle: vehicle}
+}
+
+func (m *Motorcycle) Start() { fmt.Printf("%s %s motorcycle started\n", m.brand, m.model) }
+func (m *Motorcycle) DisplayBasicInfo() {
+	fmt.Printf("Motorcycle %s: %s %s, %.0f km\n", m.id, m.brand, m.model, m.Mileage())
+}
+func (m *Motorcycle) Class() VehicleClass { return ClassMotorcycle }
+
+// Chargeable is an optional capability: callers discover it with a type
+// assertion instead of every Vehicular having to pretend it has a battery
+type Chargeable interface {
+	Charge(kwh float64) error
+	RangeRemaining() float64 // km
+}
+
+// ElectricCar reuses Telemetry with the battery as its tank: FuelLevel is kWh
+type ElectricCar struct {
+	Vehicle
+}
+
+func NewElectricCar(id, brand, model string, batteryKWh, kmPerKWh float64) *ElectricCar {
+	vehicle := NewVehicle(id, brand, model, batteryKWh, 0, ElectricModel{KmPerKWh: kmPerKWh})
+	vehicle.telemetry.unitLiters = 1 / kwhPerLiterGasoline
+	return &ElectricCar{Vehicle: vehicle}
+}
+
+func (e *ElectricCar) Start() {
+	fmt.Printf("%s %s electric car powered on silently\n", e.brand, e.model)
+}
+func (e *ElectricCar) DisplayBasicInfo() {
+	fmt.Printf("Electric car %s: %s %s, %.0f km, battery %.1f kWh\n", e.id, e.brand, e.model, e.Mileage(), e.telemetry.FuelLevel())
+}
+func (e *ElectricCar) Class() VehicleClass { return ClassCar }
+
+// Charge tops the battery up; anything beyond its capacity is not taken
+func (e *ElectricCar) Charge(kwh float64) error {
+	if kwh <= 0 {
+		return fmt.Errorf("%w: %.1f kWh", ErrInvalidCharge, kwh)
+	}
+	e.telemetry.addFuel(kwh)
+	return nil
+}
+
+// RangeRemaining is how far the charge goes at highway speed
+func (e *ElectricCar) RangeRemaining() float64 { return e.telemetry.Range(ProfileHighway) }
+
+// CapacityReporter is another optional capability: vehicles that carry
+// something report how much of it they carry
+type CapacityReporter interface {
+	Occupancy() (used, capacity float64, unit string)
+}
+
+// Truck enforces its cargo capacity: a load that does not fit is refused
+// whole, so loadKg never leaves 0..capacityKg
+type Truck struct {
+	Vehicle
+	capacityKg float64
+	loadKg     float64
+}
+
+func NewTruck(id, brand, model string, capacityKg float64) *Truck {
+	vehicle := NewVehicle(id, brand, model, 300, 13, CombustionModel{RatedKmPerLiter: 6, ReferenceLiters: 13})
+	return &Truck{Vehicle: vehicle, capacityKg: capacityKg}
+}
+
+func (t *Truck) Start() { fmt.Printf("%s %s truck started\n", t.brand, t.model) }
+func (t *Truck) DisplayBasicInfo() {
+	fmt.Printf("Truck %s: %s %s, %.0f/%.0f kg cargo, %.0f km\n", t.id, t.brand, t.model, t.loadKg, t.capacityKg, t.Mileage())
+}
+
+func (t *Truck) Occupancy() (float64, float64, string) { return t.loadKg, t.capacityKg, "kg" }
+
+func (t *Truck) Load(kg float64) error {
+	if kg <= 0 {
+		return fmt.Errorf("%w: %.0f kg", ErrInvalidQuantity, kg)
+	}
+	if t.loadKg+kg > t.capacityKg {
+		return fmt.Errorf("%w: %s has %.0f kg free, asked to load %.0f kg", ErrOverloaded, t.id, t.capacityKg-t.loadKg, kg)
+	}
+	t.loadKg += kg
+	return nil
+}
+
+func (t *Truck) Unload(kg float64) error {
+	if kg <= 0 {
+		return fmt.Errorf("%w: %.0f kg", ErrInvalidQuantity, kg)
+	}
+	if kg > t.loadKg {
+		return fmt.Errorf("%w: %s carries %.0f kg, asked to unload %.0f kg", ErrNotEnoughCargo, t.id, t.loadKg, kg)
+	}
+	t.loadKg -= kg
+	return nil
+}
+
+// Bus boards whole groups or none of them, so passengers never exceed seats
+type Bus struct {
+	Vehicle
+	seats      int
+	passengers int
+}
+
+func NewBus(id, brand, model string, seats int) *Bus {
+	vehicle := NewVehicle(id, brand, model, 250, 7.7, CombustionModel{RatedKmPerLiter: 4, ReferenceLiters: 7.7})
+	return &Bus{Vehicle: vehicle, seats: seats}
+}
+
+func (b *Bus) Start() { fmt.Printf("%s %s bus started\n", b.brand, b.model) }
+func (b *Bus) DisplayBasicInfo() {
+	fmt.Printf("Bus %s: %s %s, %d/%d seats taken, %.0f km\n", b.id, b.brand, b.model, b.passengers, b.seats, b.Mileage())
+}
+
+func (b *Bus) Occupancy() (float64, float64, string) {
+	return float64(b.passengers), float64(b.seats), "seats"
+}
+
+func (b *Bus) Board(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("%w: %d passengers", ErrInvalidQuantity, n)
+	}
+	if b.passengers+n > b.seats {
+		return fmt.Errorf("%w: %s has %d free, %d waiting", ErrBusFull, b.id, b.seats-b.passengers, n)
+	}
+	b.passengers += n
+	return nil
+}
+
+func (b *Bus) Alight(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("%w: %d passengers", ErrInvalidQuantity, n)
+	}
+	if n > b.passengers {
+		return fmt.Errorf("%w: %s carries %d, %d getting off", ErrNotEnoughRiders, b.id, b.passengers, n)
+	}
+	b.passengers -= n
+	return nil
+}
+
+// ============================================================================
+// 2. EFFICIENCY - driving profiles and pluggable fuel-efficiency models
+// ============================================================================
+
+type DrivingProfile string
+
+const (
+	ProfileCity    DrivingProfile = "city"
+	ProfileHighway DrivingProfile = "highway"
+	ProfileSport   DrivingProfile = "sport"
+)
+
+var Profiles = []DrivingProfile{ProfileCity, ProfileHighway, ProfileSport}
+
+// ProfileAt is how a trip at speedKmh is driven: stop-and-go below 60 km/h,
+// cruising up to 120 and hard driving above that
+func ProfileAt(speedKmh float64) DrivingProfile {
+	switch {
+	case speedKmh < 60:
+		return ProfileCity
+	case speedKmh <= 120:
+		return ProfileHighway
+	default:
+		return ProfileSport
+	}
+}
+
+// EfficiencyModel turns engine size, mileage and how the vehicle is driven
+// into km per liter. Profiles a model does not know are rated at 1.0.
+type EfficiencyModel interface {
+	KmPerLiter(engineLiters, mileageKm float64, profile DrivingProfile) float64
+}
+
+// CombustionModel: the rated figure holds for an engine of ReferenceLiters on
+// mixed roads. Bigger engines burn more, worn engines lose up to 15%, and
+// stop-and-go or hard driving cost more than cruising.
+type CombustionModel struct {
+	RatedKmPerLiter float64
+	ReferenceLiters float64
+}
+
+var combustionProfiles = map[DrivingProfile]float64{ProfileCity: 0.8, ProfileHighway: 1.1, ProfileSport: 0.7}
+
+func (m CombustionModel) KmPerLiter(engineLiters, mileageKm float64, profile DrivingProfile) float64 {
+	engine := 1.0
+	if engineLiters > 0 {
+		engine = math.Sqrt(m.ReferenceLiters / engineLiters)
+	}
+	wear := 1 - min(0.15, mileageKm/2_000_000) // 1% per 20,000 km
+	return m.RatedKmPerLiter * engine * wear * profileFactor(combustionProfiles, profile)
+}
+
+// kwhPerLiterGasoline converts an EV's km/kWh into a comparable km/l
+const kwhPerLiterGasoline = 8.9
+
+// ElectricModel ignores engine size; regenerative braking makes the city its
+// best profile, and the battery loses up to 10% over its life
+type ElectricModel struct {
+	KmPerKWh float64
+}
+
+var electricProfiles = map[DrivingProfile]float64{ProfileCity: 1.15, ProfileHighway: 0.9, ProfileSport: 0.75}
+
+func (m ElectricModel) KmPerLiter(_, mileageKm float64, profile DrivingProfile) float64 {
+	wear := 1 - min(0.10, mileageKm/5_000_000) // 1% per 50,000 km
+	return m.KmPerKWh * kwhPerLiterGasoline * wear * profileFactor(electricProfiles, profile)
+}
+
+func profileFactor(factors map[DrivingProfile]float64, profile DrivingProfile) float64 {
+	if factor, ok := factors[profile]; ok {
+		return factor
+	}
+	return 1
+}
+
+// ============================================================================
+// 3. FACTORY - concrete vehicles built from declarative specs
+// ============================================================================
+
+var (
+	ErrUnknownKind = errors.New("unknown vehicle kind")
+	ErrInvalidSpec = errors.New("invalid vehicle spec")
+)
+
+// VehicleSpec describes a vehicle as data, so fleets can be loaded from JSON
+// instead of hand-written constructor calls
+type VehicleSpec struct {
+	Kind    string             `json:"kind"`
+	ID      string             `json:"id"`
+	Brand   string             `json:"brand"`
+	Model   string             `json:"model"`
+	Options map[string]float64 `json:"options,omitempty"`
+}
+
+// kindBuilder knows which options a kind accepts and how to construct it
+type kindBuilder struct {
+	options map[string]float64 // accepted options with their defaults; 0 means required
+	whole   []string           // options that must be whole numbers
+	build   func(spec VehicleSpec, opts map[string]float64) Vehicular
+}
+
+var builders = map[string]kindBuilder{
+	"car": {
+		options: map[string]float64{"doors": 4, "engine_liters": 1.6},
+		whole:   []string{"doors"},
+		build: func(s VehicleSpec, opts map[string]float64) Vehicular {
+			car := NewCar(s.ID, s.Brand, s.Model)
+			car.doors, car.telemetry.engineLiters = int(opts["doors"]), opts["engine_liters"]
+			return car
+		},
+	},
+	"motorcycle": {
+		options: map[string]float64{"engine_liters": 0.7},
+		build: func(s VehicleSpec, opts map[string]float64) Vehicular {
+			bike := NewMotorcycle(s.ID, s.Brand, s.Model)
+			bike.telemetry.engineLiters = opts["engine_liters"]
+			return bike
+		},
+	},
+	"electric_car": {
+		options: map[string]float64{"battery_kwh": 0, "km_per_kwh": 0},
+		build: func(s VehicleSpec, opts map[string]float64) Vehicular {
+			return NewElectricCar(s.ID, s.Brand, s.Model, opts["battery_kwh"], opts["km_per_kwh"])
+		},
+	},
+	"truck": {
+		options: map[string]float64{"capacity_kg": 0, "engine_liters": 13},
+		build: func(s VehicleSpec, opts map[string]float64) Vehicular {
+			truck := NewTruck(s.ID, s.Brand, s.Model, opts["capacity_kg"])
+			truck.telemetry.engineLiters = opts["engine_liters"]
+			return truck
+		},
+	},
+	"bus": {
+		options: map[string]float64{"seats": 0, "engine_liters": 7.7},
+		whole:   []string{"seats"},
+		build: func(s VehicleSpec, opts map[string]float64) Vehicular {
+			bus := NewBus(s.ID, s.Brand, s.Model, int(opts["seats"]))
+			bus.telemetry.engineLiters = opts["engine_liters"]
+			return bus
+		},
+	},
+}
+
+// BuildFromSpec validates spec and constructs the concrete type for its kind.
+// Every problem with the spec is reported at once, not just the first.
+func BuildFromSpec(spec VehicleSpec) (Vehicular, error) {
+	builder, ok := builders[spec.Kind]
+	if !ok {
+		kinds := make([]string, 0, len(builders))
+		for kind := range builders {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		return nil, fmt.Errorf("%w: %q (known: %s)", ErrUnknownKind, spec.Kind, strings.Join(kinds, ", "))
+	}
+	var problems []string
+	for field, value := range map[string]string{"id": spec.ID, "brand": spec.Brand, "model": spec.Model} {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, field+" is required")
+		}
+	}
+	opts := make(map[string]float64, len(builder.options))
+	for name, value := range spec.Options {
+		if _, known := builder.options[name]; !known {
+			problems = append(problems, fmt.Sprintf("unknown option %q for %s", name, spec.Kind))
+			continue
+		}
+		opts[name] = value
+	}
+	for name, def := range builder.options {
+		value, set := opts[name]
+		switch {
+		case !set && def == 0:
+			problems = append(problems, fmt.Sprintf("option %q is required", name))
+		case !set:
+			opts[name] = def
+		case value <= 0:
+			problems = append(problems, fmt.Sprintf("option %q must be positive, got %g", name, value))
+		}
+	}
+	for _, name := range builder.whole {
+		if value := opts[name]; value != math.Trunc(value) {
+			problems = append(problems, fmt.Sprintf("option %q must be a whole number, got %g", name, value))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems) // map order would make the message flaky
+		return nil, fmt.Errorf("%w %s %q: %s", ErrInvalidSpec, spec.Kind, spec.ID, strings.Join(problems, "; "))
+	}
+	return builder.build(spec, opts), nil
+}
+
+// AddFromSpecs builds and adds every valid spec, and joins the errors of the
+// ones that could not be built or added
+func (f *Fleet) AddFromSpecs(specs []VehicleSpec) error {
+	var errs []error
+	for _, spec := range specs {
+		v, err := BuildFromSpec(spec)
+		if err == nil {
+			err = f.Add(v)
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ============================================================================
+// 4. TELEMETRY - odometer, speed and fuel, announcing thresholds as events
+// ============================================================================
+
+type TelemetryEventKind string
+
+const (
+	LowFuel        TelemetryEventKind = "LowFuel"
+	MaintenanceDue TelemetryEventKind = "MaintenanceDue" // emitted by the MaintenanceScheduler
+)
+
+type TelemetryEvent struct {
+	Kind       TelemetryEventKind
+	VehicleID  string
+	OdometerKm float64
+	FuelLiters float64
+}
+
+type TelemetryListener func(event TelemetryEvent)
+
+const lowFuelShare = 0.15 // of the tank
+
+// Telemetry replaces a bare mileage float: it knows how far the vehicle has
+// gone, how fast, and how much fuel is left, and tells subscribers when a
+// threshold is crossed. Each alert fires once until refuelling resets it.
+// Fuel burns at the rate the efficiency model gives for the engine, the
+// odometer and the profile the speed implies.
+// When a vehicle is due for service is the MaintenanceScheduler's concern;
+// it announces MaintenanceDue through the same listeners.
+type Telemetry struct {
+	vehicleID      string
+	odometerKm     float64
+	speedKmh       float64
+	fuelLiters     float64
+	tankLiters     float64
+	engineLiters   float64
+	unitLiters     float64 // liters of gasoline one unit in the tank is worth; a battery holds kWh
+	efficiency     EfficiencyModel
+	lowFuelAlerted bool
+	listeners      []TelemetryListener
+}
+
+// NewTelemetry starts with a full tank
+func NewTelemetry(vehicleID string, tankLiters, engineLiters float64, efficiency EfficiencyModel) *Telemetry {
+	return &Telemetry{vehicleID: vehicleID, fuelLiters: tankLiters, tankLiters: tankLiters,
+		engineLiters: engineLiters, unitLiters: 1, efficiency: efficiency}
+}
+
+func (t *Telemetry) Subscribe(listener TelemetryListener) {
+	t.listeners = append(t.listeners, listener)
+}
+
+func (t *Telemetry) Odometer() float64  { return t.odometerKm }
+func (t *Telemetry) Speed() float64     { return t.speedKmh }
+func (t *Telemetry) FuelLevel() float64 { return t.fuelLiters }
+
+// KmPerLiter is the model's figure for this engine at the current odometer
+func (t *Telemetry) KmPerLiter(profile DrivingProfile) float64 {
+	return t.efficiency.KmPerLiter(t.engineLiters, t.odometerKm, profile)
+}
+
+// Range is how far the fuel left goes if driven the whole way in profile
+func (t *Telemetry) Range(profile DrivingProfile) float64 {
+	return t.fuelLiters * t.unitLiters * t.KmPerLiter(profile)
+}
+
+// Drive covers up to km at speedKmh and returns how far it got; if the tank
+// runs dry first it stops there with ErrOutOfFuel
+func (t *Telemetry) Drive(km, speedKmh float64) (float64, error) {
+	if km <= 0 {
+		return 0, fmt.Errorf("%w: %.1f km", ErrInvalidDistance, km)
+	}
+	kmPerUnit := t.unitLiters * t.KmPerLiter(ProfileAt(speedKmh))
+	driven := min(km, t.fuelLiters*kmPerUnit)
+	t.odometerKm += driven
+	t.fuelLiters = max(0, t.fuelLiters-driven/kmPerUnit)
+	t.speedKmh = speedKmh
+	t.check()
+	if driven < km {
+		t.speedKmh = 0
+		return driven, fmt.Errorf("%w: %s stopped after %.0f of %.0f km", ErrOutOfFuel, t.vehicleID, driven, km)
+	}
+	return driven, nil
+}
+
+func (t *Telemetry) Refuel() { t.addFuel(t.tankLiters) }
+
+func (t *Telemetry) addFuel(amount float64) {
+	t.fuelLiters = min(t.tankLiters, t.fuelLiters+amount)
+	if t.fuelLiters >= t.tankLiters*lowFuelShare {
+		t.lowFuelAlerted = false
+	}
+}
+
+func (t *Telemetry) check() {
+	if !t.lowFuelAlerted && t.fuelLiters < t.tankLiters*lowFuelShare {
+		t.lowFuelAlerted = true
+		t.emit(LowFuel)
+	}
+}
+
+func (t *Telemetry) emit(kind TelemetryEventKind) {
+	event := TelemetryEvent{Kind: kind, VehicleID: t.vehicleID, OdometerKm: t.odometerKm, FuelLiters: t.fuelLiters}
+	for _, listener := range t.listeners {
+		listener(event)
+	}
+}
+
+// ============================================================================
+// 5. VEHICLE MANAGER - runtime polymorphism through the interface
+// ============================================================================
+
+type VehicleManager struct{}
+
+func (VehicleManager) TestVehicle(v Vehicular) {
+	v.DisplayBasicInfo()
+	v.Start()
+	fmt.Print("  fuel efficiency km/l:")
+	for _, profile := range Profiles {
+		fmt.Printf(" %s %.1f", profile, v.CalculateFuelEfficiency(profile))
+	}
+	fmt.Println()
+	if chargeable, ok := v.(Chargeable); ok {
+		testCharging(chargeable)
+	}
+	if carrier, ok := v.(CapacityReporter); ok {
+		used, capacity, unit := carrier.Occupancy()
+		fmt.Printf("  occupancy: %.0f of %.0f %s\n", used, capacity, unit)
+	}
+	v.Stop()
+}
+
+// testCharging only runs for vehicles that can charge; the others never see it
+func testCharging(c Chargeable) {
+	before := c.RangeRemaining()
+	if err := c.Charge(-5); err != nil {
+		fmt.Println("  charging test, bad input rejected:", err)
+	}
+	if err := c.Charge(20); err != nil {
+		fmt.Println("  charging test failed:", err)
+		return
+	}
+	fmt.Printf("  charging test: range %.0f km -> %.0f km after 20 kWh\n", before, c.RangeRemaining())
+}
+
+// ============================================================================
+// 6. FLEET - many vehicles managed as one, with aggregate reports
+// ============================================================================
+
+// usage is what the fleet records about each vehicle's trips
+type usage struct {
+	trips int
+	km    float64
+	hours float64
+}
+
+type Fleet struct {
+	name     string
+	vehicles []Vehicular // insertion order, so reports are stable
+	usage    map[string]*usage
+}
+
+func NewFleet(name string) *Fleet {
+	return &Fleet{name: name, usage: make(map[string]*usage)}
+}
+
+func (f *Fleet) Add(vehicles ...Vehicular) error {
+	for _, v := range vehicles {
+		if _, exists := f.usage[v.ID()]; exists {
+			return fmt.Errorf("%w: %s in %s", ErrDuplicateVehicle, v.ID(), f.name)
+		}
+		f.vehicles = append(f.vehicles, v)
+		f.usage[v.ID()] = &usage{}
+	}
+	return nil
+}
+
+func (f *Fleet) Remove(id string) error {
+	for i, v := range f.vehicles {
+		if v.ID() == id {
+			f.vehicles = append(f.vehicles[:i:i], f.vehicles[i+1:]...)
+			delete(f.usage, id)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s in %s", ErrVehicleNotFound, id, f.name)
+}
+
+func (f *Fleet) Get(id string) (Vehicular, error) {
+	for _, v := range f.vehicles {
+		if v.ID() == id {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s in %s", ErrVehicleNotFound, id, f.name)
+}
+
+func (f *Fleet) Size() int { return len(f.vehicles) }
+
+// Vehicles returns a copy, so callers cannot reorder or remove behind the fleet's back
+func (f *Fleet) Vehicles() []Vehicular { return append([]Vehicular(nil), f.vehicles...) }
+
+// ForEach is the polymorphism loop from main, owned by the fleet
+func (f *Fleet) ForEach(fn func(Vehicular)) {
+	for _, v := range f.vehicles {
+		fn(v)
+	}
+}
+
+func (f *Fleet) AverageFuelEfficiency(profile DrivingProfile) float64 {
+	if len(f.vehicles) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range f.vehicles {
+		total += v.CalculateFuelEfficiency(profile)
+	}
+	return total / float64(len(f.vehicles))
+}
+
+// RecordTrip drives the vehicle's telemetry and adds the time to its usage;
+// long trips stop to refuel whenever the tank runs dry
+func (f *Fleet) RecordTrip(id string, km, hours float64) error {
+	v, err := f.Get(id)
+	if err != nil {
+		return err
+	}
+	speed := 0.0
+	if hours > 0 {
+		speed = km / hours
+	}
+	for remaining := km; ; {
+		driven, err := v.Telemetry().Drive(remaining, speed)
+		if errors.Is(err, ErrOutOfFuel) {
+			v.Telemetry().Refuel()
+			remaining -= driven
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("trip for %s: %w", id, err)
+		}
+		break
+	}
+	u := f.usage[id]
+	u.trips++
+	u.km += km
+	u.hours += hours
+	return nil
+}
+
+type UtilizationLine struct {
+	ID          string
+	Trips       int
+	Km          float64
+	Hours       float64
+	Utilization float64 // share of the period the vehicle was in use, 0..1
+}
+
+// UtilizationReport lists every vehicle, busiest first, over a period of periodHours
+func (f *Fleet) UtilizationReport(periodHours float64) []UtilizationLine {
+	lines := make([]UtilizationLine, 0, len(f.vehicles))
+	for _, v := range f.vehicles {
+		u := f.usage[v.ID()]
+		line := UtilizationLine{ID: v.ID(), Trips: u.trips, Km: u.km, Hours: u.hours}
+		if periodHours > 0 {
+			line.Utilization = min(u.hours/periodHours, 1)
+		}
+		lines = append(lines, line)
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Utilization > lines[j].Utilization })
+	return lines
+}
+
+func mustGet(fleet *Fleet, id string) Vehicular {
+	v, err := fleet.Get(id)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func ids(vehicles []Vehicular) string {
+	names := make([]string, len(vehicles))
+	for i, v := range vehicles {
+		names[i] = v.ID()
+	}
+	return strings.Join(names, ", ")
+}
+
+// ============================================================================
+// 7. MAINTENANCE - service intervals, a priority queue of work orders, history
+// ============================================================================
+
+var (
+	ErrNotScheduled      = errors.New("vehicle is not on the maintenance schedule")
+	ErrWorkOrderNotFound = errors.New("work order not found")
+	ErrInvalidIntervalKm = errors.New("service interval must be positive")
+)
+
+const defaultServiceInterval = 10000.0 // km
+
+// Priority orders the work queue: higher is more urgent
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
+)
+
+func (p Priority) String() string {
+	return [...]string{"low", "normal", "high", "critical"}[p]
+}
+
+// Shares of the service interval driven at which a routine order is opened
+// and escalated
+const (
+	serviceSoonShare    = 0.9
+	serviceDueShare     = 1.0
+	serviceOverdueShare = 1.25
+)
+
+type WorkOrder struct {
+	ID         string
+	VehicleID  string
+	Reason     string
+	Priority   Priority
+	OdometerKm float64
+	Opened     time.Time
+	routine    bool // opened by the interval check rather than reported
+	seq        int  // FIFO among equal priorities
+	index      int  // position in the heap
+}
+
+type ServiceRecord struct {
+	OrderID    string
+	Reason     string
+	OdometerKm float64
+	Completed  time.Time
+	Notes      string
+}
+
+// workQueue implements heap.Interface: most urgent first, oldest first within a priority
+type workQueue []*WorkOrder
+
+func (q workQueue) Len() int { return len(q) }
+func (q workQueue) Less(i, j int) bool {
+	if q[i].Priority != q[j].Priority {
+		return q[i].Priority > q[j].Priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q workQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+func (q *workQueue) Push(x any) {
+	order := x.(*WorkOrder)
+	order.index = len(*q)
+	*q = append(*q, order)
+}
+func (q *workQueue) Pop() any {
+	old := *q
+	order := old[len(old)-1]
+	*q = old[:len(old)-1]
+	order.index = -1
+	return order
+}
+
+// servicePlan is what the scheduler knows about one vehicle
+type servicePlan struct {
+	vehicle       Vehicular
+	intervalKm    float64
+	lastServiceKm float64
+	routine       *WorkOrder // the open interval order, so reviews don't duplicate it
+	history       []ServiceRecord
+	dueAnnounced  bool // MaintenanceDue was emitted for the current interval
+}
+
+// MaintenanceScheduler replaces a yes/no "needs maintenance" flag: it knows
+// each vehicle's interval, queues work by urgency and keeps what was done
+type MaintenanceScheduler struct {
+	clock  Clock
+	plans  map[string]*servicePlan
+	order  []string // vehicle IDs in the order they were tracked
+	queue  workQueue
+	orders map[string]*WorkOrder
+	next   int
+}
+
+func NewMaintenanceScheduler(clock Clock) *MaintenanceScheduler {
+	return &MaintenanceScheduler{clock: clock, plans: make(map[string]*servicePlan), orders: make(map[string]*WorkOrder)}
+}
+
+// Track puts v on the schedule, counting its interval from its current odometer
+func (s *MaintenanceScheduler) Track(v Vehicular, intervalKm float64) error {
+	if intervalKm <= 0 {
+		return fmt.Errorf("%w: %s %.0f km", ErrInvalidIntervalKm, v.ID(), intervalKm)
+	}
+	if _, tracked := s.plans[v.ID()]; !tracked {
+		s.order = append(s.order, v.ID())
+	}
+	s.plans[v.ID()] = &servicePlan{vehicle: v, intervalKm: intervalKm, lastServiceKm: v.Mileage()}
+	return nil
+}
+
+func (s *MaintenanceScheduler) plan(vehicleID string) (*servicePlan, error) {
+	plan, ok := s.plans[vehicleID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotScheduled, vehicleID)
+	}
+	return plan, nil
+}
+
+// Review compares every odometer with its interval, opening routine orders
+// and escalating open ones; it returns the orders it opened. A vehicle that
+// has reached its interval gets one MaintenanceDue event on its telemetry.
+func (s *MaintenanceScheduler) Review() []*WorkOrder {
+	var opened []*WorkOrder
+	for _, id := range s.order {
+		plan := s.plans[id]
+		sinceService := plan.vehicle.Mileage() - plan.lastServiceKm
+		share := sinceService / plan.intervalKm
+		if share < serviceSoonShare {
+			continue
+		}
+		priority, reason := PriorityLow, fmt.Sprintf("service in %.0f km", plan.intervalKm-sinceService)
+		switch {
+		case share >= serviceOverdueShare:
+			priority, reason = PriorityHigh, fmt.Sprintf("service overdue by %.0f km", sinceService-plan.intervalKm)
+		case share >= serviceDueShare:
+			priority, reason = PriorityNormal, fmt.Sprintf("service due (%.0f km since last)", sinceService)
+		}
+		if share >= serviceDueShare && !plan.dueAnnounced {
+			plan.dueAnnounced = true
+			plan.vehicle.Telemetry().emit(MaintenanceDue)
+		}
+		if order := plan.routine; order != nil {
+			if priority > order.Priority {
+				order.Priority, order.Reason, order.OdometerKm = priority, reason, plan.vehicle.Mileage()
+				heap.Fix(&s.queue, order.index)
+			}
+			continue
+		}
+		plan.routine = s.open(plan, reason, priority)
+		plan.routine.routine = true
+		opened = append(opened, plan.routine)
+	}
+	return opened
+}
+
+// Report queues unplanned work, such as a fault found by a driver
+func (s *MaintenanceScheduler) Report(vehicleID, reason string, priority Priority) (*WorkOrder, error) {
+	plan, err := s.plan(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.open(plan, reason, priority), nil
+}
+
+func (s *MaintenanceScheduler) open(plan *servicePlan, reason string, priority Priority) *WorkOrder {
+	s.next++
+	order := &WorkOrder{
+		ID: fmt.Sprintf("WO-%03d", s.next), VehicleID: plan.vehicle.ID(), Reason: reason, Priority: priority,
+		OdometerKm: plan.vehicle.Mileage(), Opened: s.clock.Now(), seq: s.next,
+	}
+	heap.Push(&s.queue, order)
+	s.orders[order.ID] = order
+	return order
+}
+
+// Next is the most urgent open order, without taking it off the queue
+func (s *MaintenanceScheduler) Next() (*WorkOrder, bool) {
+	if len(s.queue) == 0 {
+		return nil, false
+	}
+	return s.queue[0], true
+}
+
+// Queue lists the open orders in the order the workshop should take them
+func (s *MaintenanceScheduler) Queue() []WorkOrder {
+	pending := append(workQueue(nil), s.queue...)
+	sort.Slice(pending, pending.Less)
+	orders := make([]WorkOrder, len(pending))
+	for i, order := range pending {
+		orders[i] = *order
+	}
+	return orders
+}
+
+// Complete closes an order and records it; closing a routine order also
+// restarts the vehicle's service interval
+func (s *MaintenanceScheduler) Complete(orderID, notes string) (ServiceRecord, error) {
+	order, ok := s.orders[orderID]
+	if !ok {
+		return ServiceRecord{}, fmt.Errorf("%w: %s", ErrWorkOrderNotFound, orderID)
+	}
+	heap.Remove(&s.queue, order.index)
+	delete(s.orders, orderID)
+	plan := s.plans[order.VehicleID]
+	record := ServiceRecord{OrderID: order.ID, Reason: order.Reason, OdometerKm: plan.vehicle.Mileage(), Completed: s.clock.Now(), Notes: notes}
+	plan.history = append(plan.history, record)
+	if order.routine {
+		plan.lastServiceKm = plan.vehicle.Mileage()
+		plan.routine = nil
+		plan.dueAnnounced = false
+	}
+	return record, nil
+}
+
+// Due reports whether the vehicle has reached its interval or has open work
+func (s *MaintenanceScheduler) Due(vehicleID string) (bool, error) {
+	plan, err := s.plan(vehicleID)
+	if err != nil {
+		return false, err
+	}
+	if plan.vehicle.Mileage()-plan.lastServiceKm >= plan.intervalKm {
+		return true, nil
+	}
+	for _, order := range s.queue {
+		if order.VehicleID == vehicleID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MaintenanceScheduler) History(vehicleID string) ([]ServiceRecord, error) {
+	plan, err := s.plan(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]ServiceRecord(nil), plan.history...), nil
+}
+
+// DueForMaintenance lists the fleet's vehicles the scheduler considers due;
+// vehicles it does not track are never due
+func (f *Fleet) DueForMaintenance(s *MaintenanceScheduler) []Vehicular {
+	var due []Vehicular
+	for _, v := range f.vehicles {
+		if isDue, err := s.Due(v.ID()); err == nil && isDue {
+			due = append(due, v)
+		}
+	}
+	return due
+}
+
+// ============================================================================
+// 8. RENTALS - customers, agreements and a service composed over the fleet
+// ============================================================================
+
+// VehicleClass groups vehicles that share a price and a licence requirement
+type VehicleClass string
+
+const (
+	ClassCar        VehicleClass = "car"
+	ClassMotorcycle VehicleClass = "motorcycle"
+)
+
+// Classifiable is optional: only vehicles that know their class can be rented
+type Classifiable interface {
+	Class() VehicleClass
+}
+
+var (
+	ErrNotRentable         = errors.New("vehicle class cannot be rented")
+	ErrVehicleUnavailable  = errors.New("vehicle is not available")
+	ErrNotLicensed         = errors.New("customer is not licensed for this class")
+	ErrAgreementNotFound   = errors.New("rental agreement not found")
+	ErrAgreementNotActive  = errors.New("rental agreement is not active")
+	ErrInvalidRentalPeriod = errors.New("rental period must be at least one day")
+)
+
+// Clock lets the demo move time forward instead of waiting for it
+type Clock interface {
+	Now() time.Time
+}
+
+type ManualClock struct {
+	now time.Time
+}
+
+func (c *ManualClock) Now() time.Time          { return c.now }
+func (c *ManualClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+type Customer struct {
+	ID          string
+	Name        string
+	LicensedFor []VehicleClass
+}
+
+func (c Customer) licensed(class VehicleClass) bool {
+	for _, licensed := range c.LicensedFor {
+		if licensed == class {
+			return true
+		}
+	}
+	return false
+}
+
+// PricingStrategy prices a rental from its length and the distance driven
+type PricingStrategy interface {
+	Price(days int, km float64) float64
+	DailyRate() float64 // what one late day costs before the penalty multiplier
+}
+
+type DailyPricing struct {
+	PerDay float64
+}
+
+func (p DailyPricing) Price(days int, km float64) float64 { return float64(days) * p.PerDay }
+func (p DailyPricing) DailyRate() float64                 { return p.PerDay }
+
+// WeeklyPricing bills whole weeks at PerWeek and the remaining days at PerDay
+type WeeklyPricing struct {
+	PerDay, PerWeek float64
+}
+
+func (p WeeklyPricing) Price(days int, km float64) float64 {
+	return float64(days/7)*p.PerWeek + float64(days%7)*p.PerDay
+}
+func (p WeeklyPricing) DailyRate() float64 { return p.PerDay }
+
+// MileagePricing includes FreeKmPerDay and charges PerKm beyond it
+type MileagePricing struct {
+	PerDay, PerKm, FreeKmPerDay float64
+}
+
+func (p MileagePricing) Price(days int, km float64) float64 {
+	extra := math.Max(0, km-float64(days)*p.FreeKmPerDay)
+	return float64(days)*p.PerDay + extra*p.PerKm
+}
+func (p MileagePricing) DailyRate() float64 { return p.PerDay }
+
+// LatePolicy: returns later than Grace are charged per started late day at
+// DailyRate times Multiplier
+type LatePolicy struct {
+	Grace      time.Duration
+	Multiplier float64
+}
+
+type AgreementStatus string
+
+const (
+	AgreementActive   AgreementStatus = "Active"
+	AgreementReturned AgreementStatus = "Returned"
+)
+
+type RentalAgreement struct {
+	id       string
+	customer Customer
+	vehicle  Vehicular
+	pricing  PricingStrategy
+	start    time.Time
+	due      time.Time
+	days     int
+	status   AgreementStatus
+	invoice  Invoice
+}
+
+func (a *RentalAgreement) ID() string              { return a.id }
+func (a *RentalAgreement) Status() AgreementStatus { return a.status }
+func (a *RentalAgreement) Due() time.Time          { return a.due }
+
+// Invoice is the settled price of a returned rental
+type Invoice struct {
+	Base     float64
+	LateDays int
+	LateFee  float64
+	Total    float64
+}
+
+type RentalService struct {
+	fleet      *Fleet
+	pricing    map[VehicleClass]PricingStrategy
+	late       LatePolicy
+	clock      Clock
+	agreements map[string]*RentalAgreement
+	rentedBy   map[string]string // vehicle ID -> active agreement ID
+	next       int
+}
+
+func NewRentalService(fleet *Fleet, pricing map[VehicleClass]PricingStrategy, late LatePolicy, clock Clock) *RentalService {
+	return &RentalService{
+		fleet: fleet, pricing: pricing, late: late, clock: clock,
+		agreements: make(map[string]*RentalAgreement),
+		rentedBy:   make(map[string]string),
+	}
+}
+
+func (s *RentalService) IsAvailable(vehicleID string) bool {
+	_, rented := s.rentedBy[vehicleID]
+	return !rented
+}
+
+// Available lists vehicles of class that are free to rent right now
+func (s *RentalService) Available(class VehicleClass) []Vehicular {
+	var free []Vehicular
+	s.fleet.ForEach(func(v Vehicular) {
+		if c, ok := v.(Classifiable); ok && c.Class() == class && s.IsAvailable(v.ID()) {
+			free = append(free, v)
+		}
+	})
+	return free
+}
+
+func (s *RentalService) Rent(customer Customer, vehicleID string, days int) (*RentalAgreement, error) {
+	if days < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidRentalPeriod, days)
+	}
+	v, err := s.fleet.Get(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	classified, ok := v.(Classifiable)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNotRentable, v)
+	}
+	pricing, ok := s.pricing[classified.Class()]
+	if !ok {
+		return nil, fmt.Errorf("%w: no price for %s", ErrNotRentable, classified.Class())
+	}
+	if !customer.licensed(classified.Class()) {
+		return nil, fmt.Errorf("%w: %s for %s", ErrNotLicensed, customer.Name, classified.Class())
+	}
+	if !s.IsAvailable(vehicleID) {
+		return nil, fmt.Errorf("%w: %s is on agreement %s", ErrVehicleUnavailable, vehicleID, s.rentedBy[vehicleID])
+	}
+	s.next++
+	start := s.clock.Now()
+	agreement := &RentalAgreement{
+		id: fmt.Sprintf("RA-%03d", s.next), customer: customer, vehicle: v, pricing: pricing,
+		start: start, due: start.AddDate(0, 0, days), days: days, status: AgreementActive,
+	}
+	s.agreements[agreement.id] = agreement
+	s.rentedBy[vehicleID] = agreement.id
+	return agreement, nil
+}
+
+// Return closes the agreement, records the trip on the fleet and prices it
+func (s *RentalService) Return(agreementID string, km float64) (Invoice, error) {
+	agreement, ok := s.agreements[agreementID]
+	if !ok {
+		return Invoice{}, fmt.Errorf("%w: %s", ErrAgreementNotFound, agreementID)
+	}
+	if agreement.status != AgreementActive {
+		return Invoice{}, fmt.Errorf("%w: %s is %s", ErrAgreementNotActive, agreementID, agreement.status)
+	}
+	returned := s.clock.Now()
+	if err := s.fleet.RecordTrip(agreement.vehicle.ID(), km, returned.Sub(agreement.start).Hours()); err != nil {
+		return Invoice{}, err
+	}
+	invoice := Invoice{Base: agreement.pricing.Price(agreement.days, km)}
+	if late := returned.Sub(agreement.due); late > s.late.Grace {
+		invoice.LateDays = int(math.Ceil(late.Hours() / 24))
+		invoice.LateFee = float64(invoice.LateDays) * agreement.pricing.DailyRate() * s.late.Multiplier
+	}
+	invoice.Total = invoice.Base + invoice.LateFee
+	agreement.status = AgreementReturned
+	agreement.invoice = invoice
+	delete(s.rentedBy, agreement.vehicle.ID())
+	return invoice, nil
+}
+
+// ============================================================================
+// 9. MAIN FUNCTION
+// ============================================================================
+
+// downtownFleet is the demo fleet as data; adding a vehicle needs no code
+const downtownFleet = `[
+	{"kind": "car", "id": "CAR-1", "brand": "Toyota", "model": "Corolla"},
+	{"kind": "car", "id": "CAR-2", "brand": "Honda", "model": "Civic", "options": {"engine_liters": 2.0}},
+	{"kind": "motorcycle", "id": "MC-1", "brand": "Yamaha", "model": "MT-07"},
+	{"kind": "electric_car", "id": "EV-1", "brand": "Tesla", "model": "Model 3", "options": {"battery_kwh": 60, "km_per_kwh": 6.5}},
+	{"kind": "truck", "id": "TRK-1", "brand": "Volvo", "model": "FH16", "options": {"capacity_kg": 12000}},
+	{"kind": "bus", "id": "BUS-1", "brand": "Mercedes", "model": "Citaro", "options": {"seats": 40}}
+]`
+
+func main() {
+	fmt.Println("=== Vehicles Demo in Go ===")
+	manager := VehicleManager{}
+
+	fmt.Println("\n1. Runtime polymorphism through VehicleManager:")
+	var specs []VehicleSpec
+	if err := json.Unmarshal([]byte(downtownFleet), &specs); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fleet := NewFleet("Downtown")
+	if err := fleet.AddFromSpecs(specs); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	clock := &ManualClock{now: time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)}
+	scheduler := NewMaintenanceScheduler(clock)
+	intervals := map[string]float64{"MC-1": 1000, "TRK-1": 1500, "EV-1": 20000}
+	fleet.ForEach(func(v Vehicular) {
+		interval, ok := intervals[v.ID()]
+		if !ok {
+			interval = defaultServiceInterval
+		}
+		if err := scheduler.Track(v, interval); err != nil {
+			fmt.Println("error:", err)
+		}
+	})
+	mustGet(fleet, "EV-1").Telemetry().Drive(250, 80) // so the charging test has room to charge
+	fleet.ForEach(manager.TestVehicle)
+
+	fmt.Println("\n2. Specs the factory rejects:")
+	for _, spec := range []VehicleSpec{
+		{Kind: "hovercraft", ID: "HC-1", Brand: "Griffon", Model: "2000TD"},
+		{Kind: "bus", ID: "BUS-2", Brand: "Volvo", Options: map[string]float64{"seats": 42.5, "doors": 3}},
+		{Kind: "electric_car", ID: "EV-2", Brand: "Nissan", Model: "Leaf", Options: map[string]float64{"battery_kwh": -40}},
+	} {
+		_, err := BuildFromSpec(spec)
+		fmt.Println(" ", err)
+	}
+	van := VehicleSpec{Kind: "car", ID: "CAR-1", Brand: "Ford", Model: "Transit", Options: map[string]float64{"doors": 5}}
+	fmt.Println("  duplicate ID from data:", fleet.AddFromSpecs([]VehicleSpec{van}))
+
+	fmt.Println("\n3. Capacity invariants:")
+	truck := mustGet(fleet, "TRK-1").(*Truck)
+	fmt.Println("  TRK-1 load 8000 kg:", truck.Load(8000))
+	fmt.Println("  TRK-1 load 5000 kg:", truck.Load(5000))
+	fmt.Println("  TRK-1 unload 9000 kg:", truck.Unload(9000))
+	fmt.Println("  TRK-1 unload 3000 kg, load 5000 kg:", truck.Unload(3000), truck.Load(5000))
+	bus := mustGet(fleet, "BUS-1").(*Bus)
+	fmt.Println("  BUS-1 board 35:", bus.Board(35))
+	fmt.Println("  BUS-1 board 10:", bus.Board(10))
+	fmt.Println("  BUS-1 alight 0:", bus.Alight(0))
+	fmt.Println("  BUS-1 alight 5, board 10:", bus.Alight(5), bus.Board(10))
+	for _, carrier := range []CapacityReporter{truck, bus} {
+		used, capacity, unit := carrier.Occupancy()
+		fmt.Printf("  %T: %.0f of %.0f %s\n", carrier, used, capacity, unit)
+	}
+
+	fmt.Println("\n4. Fleet membership:")
+	fmt.Println("  add CAR-1 again:", fleet.Add(NewCar("CAR-1", "Ford", "Focus")))
+	fmt.Println("  add CAR-3:", fleet.Add(NewCar("CAR-3", "Ford", "Focus")))
+	fmt.Println("  add and remove CAR-4:", fleet.Add(NewCar("CAR-4", "Kia", "Rio")), fleet.Remove("CAR-4"))
+	fmt.Println("  remove MC-9:", fleet.Remove("MC-9"))
+	fmt.Printf("  %d vehicles: %s\n", fleet.Size(), ids(fleet.Vehicles()))
+
+	fmt.Println("\n5. Telemetry events:")
+	alerts := map[string]int{}
+	fleet.ForEach(func(v Vehicular) {
+		v.Telemetry().Subscribe(func(event TelemetryEvent) {
+			alerts[event.VehicleID+" "+string(event.Kind)]++
+		})
+	})
+	bike := mustGet(fleet, "MC-1").Telemetry()
+	bike.Drive(400, 90)
+	fmt.Printf("  MC-1 after 400 km: %.0f km, %.1f l left, %.0f km/h\n", bike.Odometer(), bike.FuelLevel(), bike.Speed())
+	_, err := bike.Drive(200, 130) // sport burns the rest faster than cruising would
+	fmt.Printf("  MC-1 200 km more at 130 km/h: %v\n", err)
+	bike.Refuel()
+
+	fmt.Println("\n6. Aggregate reports:")
+	trips := []struct {
+		id        string
+		km, hours float64
+	}{
+		{"CAR-1", 420, 6}, {"CAR-1", 9800, 110}, {"CAR-2", 35, 1.5},
+		{"MC-1", 260, 4}, {"MC-1", 180, 3}, {"TRK-1", 1950, 30},
+		{"CAR-3", -5, 0}, {"VAN-1", 10, 1},
+	}
+	for _, trip := range trips {
+		if err := fleet.RecordTrip(trip.id, trip.km, trip.hours); err != nil {
+			fmt.Println("  trip rejected:", err)
+		}
+	}
+	fmt.Printf("  average fuel efficiency km/l: city %.1f, highway %.1f, sport %.1f\n",
+		fleet.AverageFuelEfficiency(ProfileCity), fleet.AverageFuelEfficiency(ProfileHighway), fleet.AverageFuelEfficiency(ProfileSport))
+	fmt.Println("  utilization over a 7-day week:")
+	for _, line := range fleet.UtilizationReport(7 * 24) {
+		fmt.Printf("    %-6s %2d trips %8.0f km %6.1f h %5.1f%%\n", line.ID, line.Trips, line.Km, line.Hours, line.Utilization*100)
+	}
+
+	keys := make([]string, 0, len(alerts))
+	for key := range alerts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  alerts %-20s %d\n", key, alerts[key])
+	}
+
+	fmt.Println("\n7. Maintenance scheduling:")
+	fmt.Println("  track BUS-1 every -5 km:", scheduler.Track(mustGet(fleet, "BUS-1"), -5))
+	for _, order := range scheduler.Review() {
+		fmt.Printf("  review opened %s for %s: %s, %s\n", order.ID, order.VehicleID, order.Priority, order.Reason)
+	}
+	fmt.Printf("  due for maintenance: [%s]\n", ids(fleet.DueForMaintenance(scheduler)))
+	if _, err := scheduler.Report("BUS-1", "brake pads worn", PriorityCritical); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	_, err = scheduler.Report("VAN-1", "flat tyre", PriorityHigh)
+	fmt.Println("  report for VAN-1:", err)
+	mustGet(fleet, "MC-1").Telemetry().Drive(100, 60)
+	fmt.Printf("  MC-1 rides 100 km more, review opened %d orders; queue:\n", len(scheduler.Review()))
+	for _, order := range scheduler.Queue() {
+		fmt.Printf("    %s %-6s %-8s %s\n", order.ID, order.VehicleID, order.Priority, order.Reason)
+	}
+	fmt.Printf("  MaintenanceDue events: CAR-1 %d, MC-1 %d, TRK-1 %d\n",
+		alerts["CAR-1 MaintenanceDue"], alerts["MC-1 MaintenanceDue"], alerts["TRK-1 MaintenanceDue"])
+	for range 3 { // the workshop has three slots today
+		order, ok := scheduler.Next()
+		if !ok {
+			break
+		}
+		record, err := scheduler.Complete(order.ID, "done in bay 1")
+		fmt.Printf("  completed %s on %s at %.0f km (err: %v)\n", record.OrderID, order.VehicleID, record.OdometerKm, err)
+	}
+	_, err = scheduler.Complete("WO-001", "again")
+	fmt.Println("  complete WO-001 again:", err)
+	for _, id := range []string{"CAR-1", "MC-1", "CAR-2", "CAR-3"} {
+		due, err := scheduler.Due(id)
+		history, _ := scheduler.History(id)
+		fmt.Printf("  %s due: %-5v history: %d record(s) (err: %v)\n", id, due, len(history), err)
+	}
+	fmt.Printf("  still queued: %d, next review opened %d orders\n", len(scheduler.Queue()), len(scheduler.Review()))
+	fmt.Printf("  due for maintenance: [%s]\n", ids(fleet.DueForMaintenance(scheduler)))
+
+	fmt.Println("\n8. Rentals:")
+	rentals := NewRentalService(fleet, map[VehicleClass]PricingStrategy{
+		ClassCar:        WeeklyPricing{PerDay: 45, PerWeek: 250},
+		ClassMotorcycle: MileagePricing{PerDay: 30, PerKm: 0.25, FreeKmPerDay: 150},
+	}, LatePolicy{Grace: time.Hour, Multiplier: 1.5}, clock)
+	ana := Customer{ID: "C-1", Name: "Ana", LicensedFor: []VehicleClass{ClassCar}}
+	ben := Customer{ID: "C-2", Name: "Ben", LicensedFor: []VehicleClass{ClassCar, ClassMotorcycle}}
+	fmt.Printf("  cars available: [%s]\n", ids(rentals.Available(ClassCar)))
+	anaCar, err := rentals.Rent(ana, "CAR-2", 9)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	_, err = rentals.Rent(ben, "CAR-2", 2)
+	fmt.Println("  Ben rents CAR-2:", err)
+	_, err = rentals.Rent(ana, "MC-1", 2)
+	fmt.Println("  Ana rents MC-1:", err)
+	_, err = rentals.Rent(ben, "BUS-1", 1)
+	fmt.Println("  Ben rents BUS-1:", err)
+	benBike, err := rentals.Rent(ben, "MC-1", 2)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Printf("  %s: Ana has CAR-2 until %s; %s: Ben has MC-1 until %s\n",
+		anaCar.ID(), anaCar.Due().Format("Jan 02 15:04"), benBike.ID(), benBike.Due().Format("Jan 02 15:04"))
+	fmt.Printf("  cars available: [%s]\n", ids(rentals.Available(ClassCar)))
+
+	clock.Advance(2*24*time.Hour + 30*time.Minute) // Ben is 30 minutes late: inside the grace period
+	invoice, err := rentals.Return(benBike.ID(), 420)
+	fmt.Printf("  %s returned: %+v (err: %v)\n", benBike.ID(), invoice, err)
+	clock.Advance(8*24*time.Hour + 2*time.Hour) // Ana is a day and a few hours late
+	invoice, err = rentals.Return(anaCar.ID(), 1150)
+	fmt.Printf("  %s returned: %+v (err: %v)\n", anaCar.ID(), invoice, err)
+	_, err = rentals.Return(anaCar.ID(), 10)
+	fmt.Println("  return it again:", err)
+	fmt.Printf("  cars available: [%s], CAR-2 now at %.0f km\n", ids(rentals.Available(ClassCar)), mustGet(fleet, "CAR-2").Mileage())
+
+	fmt.Println("\n9. Efficiency models:")
+	// the model's checks are TestEfficiencyModels in example_test.go
+	for _, id := range []string{"CAR-1", "CAR-2", "EV-1"} {
+		telemetry := mustGet(fleet, id).Telemetry()
+		fmt.Printf("  %-5s range on what is left: city %4.0f km, highway %4.0f km, sport %4.0f km\n",
+			id, telemetry.Range(ProfileCity), telemetry.Range(ProfileHighway), telemetry.Range(ProfileSport))
+	}
+	civic := mustGet(fleet, "CAR-2")
+	before := civic.CalculateFuelEfficiency(ProfileHighway)
+	civic.(*Car).UseEfficiencyModel(CombustionModel{RatedKmPerLiter: 17, ReferenceLiters: 1.6}) // eco remap
+	fmt.Printf("  CAR-2 (2.0 l, %.0f km) highway: %.2f km/l, after an eco remap %.2f km/l\n",
+		civic.Mileage(), before, civic.CalculateFuelEfficiency(ProfileHighway))
+
+	fmt.Println("\n=== Vehicles demonstrated ===")
+}
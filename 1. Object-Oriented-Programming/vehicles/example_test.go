@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// layoutReport lists offset, size and padding per field; go test -v prints it
+func layoutReport(t reflect.Type) (report string, padding uintptr) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: size %d, align %d\n", t.Name(), t.Size(), t.Align())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		end := t.Size()
+		if i+1 < t.NumField() {
+			end = t.Field(i + 1).Offset
+		}
+		hole := end - field.Offset - field.Type.Size()
+		padding += hole
+		fmt.Fprintf(&b, "  %-14s %-18s offset %3d size %2d padding %d\n", field.Name, field.Type, field.Offset, field.Type.Size(), hole)
+	}
+	return b.String(), padding
+}
+
+// packedSize is the size with the fields ordered largest alignment first,
+// the order that leaves no holes between them
+func packedSize(t reflect.Type) (uintptr, []string) {
+	fields := make([]reflect.StructField, t.NumField())
+	for i := range fields {
+		fields[i] = t.Field(i)
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Type.Align() > fields[j].Type.Align() })
+	var size uintptr
+	order := make([]string, len(fields))
+	for i, field := range fields {
+		align := uintptr(field.Type.Align())
+		size = (size+align-1)/align*align + field.Type.Size()
+		order[i] = field.Name
+	}
+	align := uintptr(t.Align())
+	return (size + align - 1) / align * align, order
+}
+
+// The fleet keeps one of each of these per vehicle or order, so a field added
+// in the wrong place costs memory on every one of them. Reordering fields is
+// free; this fails when a reorder would make a struct smaller.
+func TestFleetStructsHaveNoAvoidablePadding(t *testing.T) {
+	for _, v := range []interface{}{Vehicle{}, Telemetry{}, WorkOrder{}, ServiceRecord{}, servicePlan{}, Fleet{}} {
+		typ := reflect.TypeOf(v)
+		report, padding := layoutReport(typ)
+		packed, order := packedSize(typ)
+		t.Logf("%s  wasted: %d of %d bytes", report, padding, typ.Size())
+		if packed < typ.Size() {
+			t.Errorf("%s is %d bytes but %d in this order: %s", typ.Name(), typ.Size(), packed, strings.Join(order, ", "))
+		}
+	}
+}
+
+func TestPackedSize(t *testing.T) {
+	type natural struct {
+		inService bool
+		mileage   float64
+		wheels    uint8
+		year      int32
+		brand     string
+	}
+	if size, order := packedSize(reflect.TypeOf(natural{})); size != 32 || order[len(order)-1] != "wheels" {
+		t.Fatalf("got %d bytes in order %v, want 32 with the 1-byte fields last", size, order)
+	}
+	if got := reflect.TypeOf(natural{}).Size(); got != 40 {
+		t.Fatalf("natural order is %d bytes, want 40", got)
+	}
+}
+
+func maintenanceFleet(t *testing.T) (*Fleet, *MaintenanceScheduler) {
+	t.Helper()
+	fleet := NewFleet("Test")
+	if err := fleet.Add(NewCar("CAR-1", "Toyota", "Corolla"), NewCar("CAR-2", "Honda", "Civic"), NewCar("CAR-3", "Ford", "Focus")); err != nil {
+		t.Fatal(err)
+	}
+	scheduler := NewMaintenanceScheduler(&ManualClock{now: time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)})
+	for _, id := range []string{"CAR-1", "CAR-2"} { // CAR-3 is not tracked
+		if err := scheduler.Track(mustGet(fleet, id), 1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fleet, scheduler
+}
+
+// MaintenanceDue fires once when a review finds the interval reached, not
+// while the service is merely coming up, and again only after a service
+func TestMaintenanceDueOncePerInterval(t *testing.T) {
+	fleet, scheduler := maintenanceFleet(t)
+	var events []string
+	mustGet(fleet, "CAR-1").Telemetry().Subscribe(func(event TelemetryEvent) {
+		if event.Kind == MaintenanceDue {
+			events = append(events, fmt.Sprintf("%s at %.0f km", event.VehicleID, event.OdometerKm))
+		}
+	})
+	for _, step := range []struct {
+		km   float64
+		want int
+	}{
+		{950, 0}, // soon: a low-priority order, no event
+		{100, 1}, // due
+		{0, 1},   // reviewing again does not repeat it
+		{300, 1}, // overdue: escalated, still one event
+	} {
+		if step.km > 0 {
+			if err := fleet.RecordTrip("CAR-1", step.km, 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+		scheduler.Review()
+		if len(events) != step.want {
+			t.Fatalf("after %.0f km: events %v, want %d", mustGet(fleet, "CAR-1").Mileage(), events, step.want)
+		}
+	}
+
+	order, _ := scheduler.Next()
+	if _, err := scheduler.Complete(order.ID, "serviced"); err != nil {
+		t.Fatal(err)
+	}
+	fleet.RecordTrip("CAR-1", 1000, 10)
+	scheduler.Review()
+	if want := "[CAR-1 at 1050 km CAR-1 at 2350 km]"; fmt.Sprint(events) != want {
+		t.Fatalf("events %v, want %s", events, want)
+	}
+}
+
+func TestFleetDueForMaintenance(t *testing.T) {
+	fleet, scheduler := maintenanceFleet(t)
+	if due := fleet.DueForMaintenance(scheduler); len(due) != 0 {
+		t.Fatalf("new fleet: due [%s]", ids(due))
+	}
+	for _, id := range []string{"CAR-1", "CAR-3"} {
+		if err := fleet.RecordTrip(id, 1200, 12); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if due := ids(fleet.DueForMaintenance(scheduler)); due != "CAR-1" {
+		t.Fatalf("after 1200 km: due [%s], want [CAR-1] (CAR-3 is not tracked)", due)
+	}
+	if _, err := scheduler.Report("CAR-2", "warning light", PriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	if due := ids(fleet.DueForMaintenance(scheduler)); due != "CAR-1, CAR-2" {
+		t.Fatalf("after a report: due [%s], want [CAR-1, CAR-2]", due)
+	}
+}
+
+func TestEfficiencyModels(t *testing.T) {
+	car := CombustionModel{RatedKmPerLiter: 15.5, ReferenceLiters: 1.6}
+	ev := ElectricModel{KmPerKWh: 6.5}
+	for _, tc := range []struct {
+		name      string
+		model     EfficiencyModel
+		engine    float64
+		mileageKm float64
+		profile   DrivingProfile
+		want      float64
+	}{
+		{"car new, highway", car, 1.6, 0, ProfileHighway, 17.05},
+		{"car new, city", car, 1.6, 0, ProfileCity, 12.4},
+		{"car new, sport", car, 1.6, 0, ProfileSport, 10.85},
+		{"car 6.4 l engine, highway", car, 6.4, 0, ProfileHighway, 8.525},
+		{"car at 200,000 km, highway", car, 1.6, 200_000, ProfileHighway, 15.345},
+		{"car wear capped at 15%", car, 1.6, 1_000_000, ProfileCity, 10.54},
+		{"car unknown profile rated 1.0", car, 1.6, 0, "offroad", 15.5},
+		{"ev new, city", ev, 0, 0, ProfileCity, 66.5275},
+		{"ev engine size ignored", ev, 3.0, 0, ProfileCity, 66.5275},
+		{"ev at 100,000 km, sport", ev, 0, 100_000, ProfileSport, 42.5198},
+	} {
+		if got := tc.model.KmPerLiter(tc.engine, tc.mileageKm, tc.profile); math.Abs(got-tc.want) > 1e-3 {
+			t.Errorf("%s: got %.3f km/l, want %.3f", tc.name, got, tc.want)
+		}
+	}
+}
+
+// The same 100 km costs what the model says for the engine and the profile
+// the speed implies; a battery is drained in kWh
+func TestDriveBurnsThroughTheModel(t *testing.T) {
+	for _, tc := range []struct {
+		spec     VehicleSpec
+		speedKmh float64
+		wantUsed float64 // liters, or kWh for the EV
+	}{
+		{VehicleSpec{Kind: "car", ID: "CAR-1", Brand: "b", Model: "m"}, 100, 100 / 17.05},
+		{VehicleSpec{Kind: "car", ID: "CAR-1", Brand: "b", Model: "m"}, 40, 100 / 12.4},
+		{VehicleSpec{Kind: "car", ID: "CAR-1", Brand: "b", Model: "m"}, 150, 100 / 10.85},
+		{VehicleSpec{Kind: "car", ID: "CAR-2", Brand: "b", Model: "m", Options: map[string]float64{"engine_liters": 6.4}}, 100, 100 / 8.525},
+		{VehicleSpec{Kind: "electric_car", ID: "EV-1", Brand: "b", Model: "m", Options: map[string]float64{"battery_kwh": 60, "km_per_kwh": 6.5}}, 40, 100 / (6.5 * 1.15)},
+	} {
+		v, err := BuildFromSpec(tc.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		telemetry := v.Telemetry()
+		full, profile := telemetry.FuelLevel(), ProfileAt(tc.speedKmh)
+		if rangeKm, want := telemetry.Range(profile), 100*full/tc.wantUsed; math.Abs(rangeKm-want) > 1e-6 {
+			t.Errorf("%s at %.0f km/h: range %.1f km, want %.1f", tc.spec.ID, tc.speedKmh, rangeKm, want)
+		}
+		if _, err := telemetry.Drive(100, tc.speedKmh); err != nil {
+			t.Fatal(err)
+		}
+		if used := full - telemetry.FuelLevel(); math.Abs(used-tc.wantUsed) > 1e-6 {
+			t.Errorf("%s at %.0f km/h (%s): used %.3f, want %.3f", tc.spec.ID, tc.speedKmh, profile, used, tc.wantUsed)
+		}
+	}
+}
+
+func TestRemapChangesHowFarATankGoes(t *testing.T) {
+	car := NewCar("CAR-1", "Honda", "Civic")
+	before := car.Telemetry().Range(ProfileHighway)
+	car.UseEfficiencyModel(CombustionModel{RatedKmPerLiter: 17, ReferenceLiters: 1.6})
+	if after := car.Telemetry().Range(ProfileHighway); after <= before {
+		t.Fatalf("range %.0f km after an eco remap, %.0f before", after, before)
+	}
+}
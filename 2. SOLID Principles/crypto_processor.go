@@ -0,0 +1,60 @@
+// OCP extension: a new payment method added without editing example.go.
+//
+// This file only uses what example.go exports and plugs in through the
+// processor registry from init, the way a database/sql driver does. In a
+// module it would be its own package, imported for its side effect:
+//
+//	import _ "example.com/payments/crypto"
+//
+// Without a go.mod the core is package main, which nothing can import, so the
+// tutorial keeps the extension as a second file of that package:
+//
+//	go run example.go                     # core only
+//	go run example.go crypto_processor.go # core + crypto
+//	go test example.go crypto_processor.go example_test.go crypto_processor_test.go
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// CryptoProcessor settles in bitcoin at a fixed quote per fiat currency
+type CryptoProcessor struct {
+	btcPrice map[string]float64 // fiat price of one BTC
+}
+
+func NewCryptoProcessor() *CryptoProcessor {
+	return &CryptoProcessor{btcPrice: map[string]float64{"USD": 64000, "EUR": 59000}}
+}
+
+// ProcessPayment reports no fee itself: the 1% is attached to the registration,
+// like every other processor's, and filled in by the registry
+func (c *CryptoProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	if err := ctx.Err(); err != nil {
+		return Receipt{}, err
+	}
+	amount := payment.Amount()
+	price, ok := c.btcPrice[amount.Currency()]
+	if !ok {
+		return Receipt{}, fmt.Errorf("%w: no BTC quote for %s", ErrInvalidCurrency, amount.Currency())
+	}
+	sats := int64(amount.Amount() / price * 1e8)
+	id := payment.View(false).ID
+	tx := sha256.Sum256([]byte(id))
+	fmt.Printf("Processing crypto payment: %s (%d sats)\n", id, sats)
+	return Receipt{
+		PaymentID: id,
+		Processor: "crypto",
+		Reference: "btc-" + hex.EncodeToString(tx[:8]),
+		Amount:    amount,
+		Fees:      FeeBreakdown{Strategy: "none", Fee: NewMoney(0, amount.Currency()), Net: amount},
+	}, nil
+}
+
+func init() {
+	RegisterProcessorWithFees("crypto", func() PaymentProcessor { return NewCryptoProcessor() }, PercentageFee{Percent: 1})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCryptoRegistered(t *testing.T) {
+	processor, err := NewProcessor("crypto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment := NewPayment("PAY-CRYPTO-1", 32, "USD")
+	receipt, err := NewPaymentService(processor, &RecordingNotifier{}).Charge(context.Background(), payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.Status() != StatusCaptured || receipt.PaymentID != "PAY-CRYPTO-1" || receipt.Processor != "crypto" {
+		t.Fatalf("got %s, receipt %+v", payment.Status(), receipt)
+	}
+	if receipt.Fees.Fee != NewMoney(0.32, "USD") || receipt.Fees.Strategy != "1%" {
+		t.Fatalf("fees: got %+v, want the registered 1%%", receipt.Fees)
+	}
+
+	if _, err := processor.ProcessPayment(context.Background(), NewPayment("PAY-CRYPTO-2", 32, "JPY")); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("JPY: got %v, want ErrInvalidCurrency", err)
+	}
+	if _, err := processor.ProcessPayment(canceled(), NewPayment("PAY-CRYPTO-3", 32, "USD")); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled: got %v, want context.Canceled", err)
+	}
+}
+
+func git(t *testing.T, args ...string) []byte {
+	t.Helper()
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		t.Skipf("git %s: %v (needs a git checkout)", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+// The extension is only OCP if adding it needed no change to the core. Every
+// commit that touched crypto_processor.go, the later fixes included, is
+// tallied line by line: whatever they added to or removed from another Go
+// file must cancel out, so the series leaves the core as it found it
+func TestCoreUnchangedByExtension(t *testing.T) {
+	commits := strings.Fields(string(git(t, "log", "--format=%H", "--", "crypto_processor.go")))
+	if len(commits) == 0 {
+		t.Skip("crypto_processor.go is not committed yet")
+	}
+	net := map[string]int{}
+	for _, commit := range commits {
+		diff := git(t, "diff", "-U0", "--relative", commit+"^", commit, "--", ".", ":!crypto_processor.go", ":!crypto_processor_test.go")
+		path := ""
+		for _, line := range strings.Split(string(diff), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+				if name := strings.TrimSpace(line[4:]); name != "/dev/null" {
+					path = strings.TrimPrefix(strings.TrimPrefix(name, "a/"), "b/")
+				}
+			case !strings.HasSuffix(path, ".go"):
+			case strings.HasPrefix(line, "+"):
+				net[path+": "+line[1:]]++
+			case strings.HasPrefix(line, "-"):
+				net[path+": "+line[1:]]--
+			}
+		}
+	}
+	for line, n := range net {
+		if n != 0 {
+			t.Errorf("the extension's commits left a change in the core (%+d): %s", n, line)
+		}
+	}
+	core, err := os.ReadFile("example.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(core, []byte("CryptoProcessor")) || bytes.Contains(core, []byte(`"crypto"`)) {
+		t.Error("example.go refers to the crypto extension; the core must not know about it")
+	}
+}
@@ -1,162 +1,3400 @@
 package main
 
-import "fmt"
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	htmltemplate "html/template"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	texttemplate "text/template"
+	"time"
+)
+
+// Money is a value object: an integer count of the currency's minor units,
+// so amounts never pick up float drift as they are added up
+type Money struct {
+	minor    int64
+	currency string
+}
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// minorUnitDigits lists currencies whose minor unit is not 1/100
+var minorUnitDigits = map[string]int{"JPY": 0, "KRW": 0, "BHD": 3, "KWD": 3, "JOD": 3}
+
+func currencyDigits(currency string) int {
+	if digits, ok := minorUnitDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// NewMoney rounds half away from zero to the currency's minor unit
+func NewMoney(amount float64, currency string) Money {
+	scale := math.Pow10(currencyDigits(currency))
+	return Money{minor: int64(math.Round(amount * scale)), currency: currency}
+}
+
+func (m Money) Minor() int64     { return m.minor }
+func (m Money) Currency() string { return m.currency }
+func (m Money) IsPositive() bool { return m.minor > 0 }
+
+func (m Money) Amount() float64 {
+	return float64(m.minor) / math.Pow10(currencyDigits(m.currency))
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%.*f %s", currencyDigits(m.currency), m.Amount(), m.currency)
+}
+
+// AppendTo appends String's text to dst using integer arithmetic only
+func (m Money) AppendTo(dst []byte) []byte {
+	minor := m.minor
+	if minor < 0 {
+		dst = append(dst, '-')
+		minor = -minor
+	}
+	digits := currencyDigits(m.currency)
+	scale := int64(math.Pow10(digits))
+	dst = strconv.AppendInt(dst, minor/scale, 10)
+	if digits > 0 {
+		dst = append(dst, '.')
+		frac := minor % scale
+		for pad := scale / 10; pad > 1 && frac < pad; pad /= 10 {
+			dst = append(dst, '0')
+		}
+		dst = strconv.AppendInt(dst, frac, 10)
+	}
+	dst = append(dst, ' ')
+	return append(dst, m.currency...)
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.currency != other.currency {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return Money{minor: m.minor + other.minor, currency: m.currency}, nil
+}
 
 // 1. SRP: Payment struct only handles payment data
 type Payment struct {
-	id       string
-	amount   float64
-	currency string
+	id      string
+	amount  Money
+	account string          // optional; orders async processing, see Submit
+	country string          // optional ISO code of the payer, used by fraud screening
+	card    CardToken       // optional; the raw card never reaches Payment, see Tokenizer
+	state   PaymentState    // lifecycle, see State pattern below
+	history []PaymentStatus // every status the payment has been in
+}
+
+// Constructor function for Payment
+func NewPayment(id string, amount float64, currency string) *Payment {
+	return &Payment{
+		id:      id,
+		amount:  NewMoney(amount, currency),
+		state:   pendingState{},
+		history: []PaymentStatus{StatusPending},
+	}
+}
+
+func (p *Payment) Amount() Money { return p.amount }
+
+// State pattern: each status decides which transitions are legal
+type PaymentStatus string
+
+const (
+	StatusPending    PaymentStatus = "Pending"
+	StatusAuthorized PaymentStatus = "Authorized"
+	StatusCaptured   PaymentStatus = "Captured"
+	StatusRefunded   PaymentStatus = "Refunded"
+	StatusFailed     PaymentStatus = "Failed"
+)
+
+var ErrInvalidTransition = errors.New("invalid payment transition")
+
+type PaymentState interface {
+	Status() PaymentStatus
+	Authorize(p *Payment) error
+	Capture(p *Payment) error
+	Refund(p *Payment) error
+	Fail(p *Payment) error
+}
+
+// noTransitions rejects everything; states embed it and override what they allow
+type noTransitions struct{}
+
+func reject(p *Payment, action string) error {
+	return fmt.Errorf("%w: cannot %s %s payment %s", ErrInvalidTransition, action, p.state.Status(), p.id)
+}
+
+func (noTransitions) Authorize(p *Payment) error { return reject(p, "authorize") }
+func (noTransitions) Capture(p *Payment) error   { return reject(p, "capture") }
+func (noTransitions) Refund(p *Payment) error    { return reject(p, "refund") }
+func (noTransitions) Fail(p *Payment) error      { return reject(p, "fail") }
+
+type pendingState struct{ noTransitions }
+
+func (pendingState) Status() PaymentStatus      { return StatusPending }
+func (pendingState) Authorize(p *Payment) error { return p.moveTo(authorizedState{}) }
+func (pendingState) Fail(p *Payment) error      { return p.moveTo(failedState{}) }
+
+type authorizedState struct{ noTransitions }
+
+func (authorizedState) Status() PaymentStatus    { return StatusAuthorized }
+func (authorizedState) Capture(p *Payment) error { return p.moveTo(capturedState{}) }
+func (authorizedState) Fail(p *Payment) error    { return p.moveTo(failedState{}) }
+
+type capturedState struct{ noTransitions }
+
+func (capturedState) Status() PaymentStatus   { return StatusCaptured }
+func (capturedState) Refund(p *Payment) error { return p.moveTo(refundedState{}) }
+
+type refundedState struct{ noTransitions }
+
+func (refundedState) Status() PaymentStatus { return StatusRefunded }
+
+type failedState struct{ noTransitions }
+
+func (failedState) Status() PaymentStatus { return StatusFailed }
+
+func (p *Payment) moveTo(next PaymentState) error {
+	p.state = next
+	p.history = append(p.history, next.Status())
+	return nil
+}
+
+// ForAccount tags the payment with the paying account
+func (p *Payment) ForAccount(account string) *Payment {
+	p.account = account
+	return p
+}
+
+// label names the payment in messages, even when the id is missing
+func (p *Payment) label() string {
+	if p.id == "" {
+		return "payment without id"
+	}
+	return p.id
+}
+
+// FromCountry records where the payer is
+func (p *Payment) FromCountry(country string) *Payment {
+	p.country = country
+	return p
+}
+
+// WithCard attaches a token from a Tokenizer, never the card itself
+func (p *Payment) WithCard(token CardToken) *Payment {
+	p.card = token
+	return p
+}
+
+func (p *Payment) Card() CardToken { return p.card }
+
+// String is safe to log: it holds no card data, only the token
+func (p *Payment) String() string {
+	s := fmt.Sprintf("%s %s %s", p.label(), p.amount, p.Status())
+	if p.card != "" {
+		s += " card " + string(p.card)
+	}
+	return s
+}
+
+func (p *Payment) Account() string          { return p.account }
+func (p *Payment) Status() PaymentStatus    { return p.state.Status() }
+func (p *Payment) History() []PaymentStatus { return append([]PaymentStatus(nil), p.history...) }
+func (p *Payment) Authorize() error         { return p.state.Authorize(p) }
+func (p *Payment) Capture() error           { return p.state.Capture(p) }
+func (p *Payment) Refund() error            { return p.state.Refund(p) }
+func (p *Payment) Fail() error              { return p.state.Fail(p) }
+
+// Card data: the PAN lives in unexported fields and every way of printing a
+// CardData masks it, so it cannot leak through fmt, logs or JSON by accident
+var ErrInvalidCard = errors.New("invalid card")
+
+type CardData struct {
+	pan         string // digits only
+	expiryMonth int
+	expiryYear  int
+}
+
+// NewCardData accepts spaces and dashes in pan and checks it with the Luhn algorithm
+func NewCardData(pan string, expiryMonth, expiryYear int) (CardData, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, pan)
+	if len(digits) < 13 || len(digits) > 19 || !luhnValid(digits) {
+		return CardData{}, fmt.Errorf("%w: number %s", ErrInvalidCard, maskPAN(digits))
+	}
+	if expiryMonth < 1 || expiryMonth > 12 {
+		return CardData{}, fmt.Errorf("%w: expiry month %d", ErrInvalidCard, expiryMonth)
+	}
+	return CardData{pan: digits, expiryMonth: expiryMonth, expiryYear: expiryYear}, nil
+}
+
+func luhnValid(digits string) bool {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if (len(digits)-i)%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// maskPAN keeps only the last four digits
+func maskPAN(digits string) string {
+	if len(digits) <= 4 {
+		return strings.Repeat("*", len(digits))
+	}
+	return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+}
+
+func (c CardData) Last4() string { return c.pan[len(c.pan)-4:] }
+
+func (c CardData) String() string {
+	return fmt.Sprintf("%s exp %02d/%02d", maskPAN(c.pan), c.expiryMonth, c.expiryYear%100)
+}
+
+// GoString covers %#v, which would otherwise print the fields
+func (c CardData) GoString() string { return "CardData(" + c.String() + ")" }
+
+func (c CardData) MarshalJSON() ([]byte, error) { return json.Marshal(c.String()) }
+
+// panPattern finds 13-19 digit runs, optionally grouped by spaces or dashes
+var panPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// MaskPANs scrubs anything that looks like a card number out of free text.
+// Loggers run every message and string field through it as a last line of defence.
+func MaskPANs(text string) string {
+	return panPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return maskPAN(strings.NewReplacer(" ", "", "-", "").Replace(match))
+	})
+}
+
+// CardToken is an opaque reference to a card held by a Tokenizer
+type CardToken string
+
+// Tokenizer swaps card data for a token, so the rest of the system only
+// ever handles tokens. Only the vault behind it can turn a token back.
+type Tokenizer interface {
+	Tokenize(ctx context.Context, card CardData) (CardToken, error)
+}
+
+var ErrUnknownToken = errors.New("unknown card token")
+
+// CardVault is an in-memory Tokenizer. Tokens are derived from a secret and a
+// counter, not from the card, so they reveal nothing about it.
+type CardVault struct {
+	mu     sync.Mutex
+	secret []byte
+	next   uint64
+	cards  map[CardToken]CardData
+}
+
+func NewCardVault(secret []byte) *CardVault {
+	return &CardVault{secret: secret, cards: make(map[CardToken]CardData)}
+}
+
+func (v *CardVault) Tokenize(ctx context.Context, card CardData) (CardToken, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if card.pan == "" {
+		return "", fmt.Errorf("%w: empty card", ErrInvalidCard)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.next++
+	mac := hmac.New(sha256.New, v.secret)
+	fmt.Fprintf(mac, "%d", v.next)
+	token := CardToken("tok_" + hex.EncodeToString(mac.Sum(nil))[:16])
+	v.cards[token] = card
+	return token, nil
+}
+
+// Detokenize is for the processor that actually charges the card; it is not
+// part of Tokenizer, so code that only tokenizes cannot read cards back
+func (v *CardVault) Detokenize(ctx context.Context, token CardToken) (CardData, error) {
+	if err := ctx.Err(); err != nil {
+		return CardData{}, err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	card, ok := v.cards[token]
+	if !ok {
+		return CardData{}, fmt.Errorf("%w: %s", ErrUnknownToken, token)
+	}
+	return card, nil
+}
+
+// 2. OCP: PaymentProcessor interface allows for extension
+type PaymentProcessor interface {
+	ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error)
+}
+
+// Receipt is the processor's proof of a successful charge
+type Receipt struct {
+	PaymentID string
+	Processor string
+	Reference string
+	Amount    Money
+	Fees      FeeBreakdown // what the processor keeps and what the merchant gets
+}
+
+// String is the line Charge prints for a receipt with a fee
+func (r Receipt) String() string {
+	return fmt.Sprintf("Payment %s: %s gross, %s fee (%s), %s net",
+		r.PaymentID, r.Amount, r.Fees.Fee, r.Fees.Strategy, r.Fees.Net)
+}
+
+// AppendReceipt appends String's text to dst without fmt: nothing is boxed and
+// no string is built, so rendering into a reused buffer does not allocate
+func (r Receipt) AppendReceipt(dst []byte) []byte {
+	dst = append(dst, "Payment "...)
+	dst = append(dst, r.PaymentID...)
+	dst = append(dst, ": "...)
+	dst = r.Amount.AppendTo(dst)
+	dst = append(dst, " gross, "...)
+	dst = r.Fees.Fee.AppendTo(dst)
+	dst = append(dst, " fee ("...)
+	dst = append(dst, r.Fees.Strategy...)
+	dst = append(dst, "), "...)
+	dst = r.Fees.Net.AppendTo(dst)
+	return append(dst, " net"...)
+}
+
+// AppendText makes Receipt an encoding.TextAppender, for log/slog and encoders
+func (r Receipt) AppendText(dst []byte) ([]byte, error) {
+	return r.AppendReceipt(dst), nil
+}
+
+// Error categories every processor maps its failures onto, so callers can
+// branch with errors.Is without knowing which processor they talk to
+var (
+	ErrPaymentDeclined = errors.New("payment declined by processor")
+	ErrNetwork         = errors.New("processor unreachable")
+	ErrInvalidCurrency = errors.New("currency not supported by processor")
+)
+
+func newReceipt(processor, reference string, payment *Payment) Receipt {
+	return Receipt{
+		PaymentID: payment.id, Processor: processor, Reference: reference, Amount: payment.amount,
+		Fees: FeeBreakdown{Strategy: "none", Fee: Money{currency: payment.amount.currency}, Net: payment.amount},
+	}
+}
+
+// Fees: each processor charges differently, so how a fee is computed is a
+// strategy chosen per processor registration rather than code in the service
+type FeeStrategy interface {
+	Fee(amount Money) Money
+	String() string
+}
+
+// FeeBreakdown splits a charged amount into the processor's fee and the net
+type FeeBreakdown struct {
+	Strategy string
+	Fee      Money
+	Net      Money
+}
+
+// FlatFee charges the same amount, in the payment's currency, every time
+type FlatFee struct {
+	Amount float64
+}
+
+func (f FlatFee) Fee(amount Money) Money { return NewMoney(f.Amount, amount.currency) }
+func (f FlatFee) String() string         { return fmt.Sprintf("flat %.2f", f.Amount) }
+
+type PercentageFee struct {
+	Percent float64
+}
+
+func (f PercentageFee) Fee(amount Money) Money {
+	return Money{minor: int64(math.Round(float64(amount.minor) * f.Percent / 100)), currency: amount.currency}
+}
+
+func (f PercentageFee) String() string { return fmt.Sprintf("%g%%", f.Percent) }
+
+// FeeTier applies Strategy to amounts up to UpTo; a zero UpTo has no limit
+type FeeTier struct {
+	UpTo     float64
+	Strategy FeeStrategy
+}
+
+// TieredFee picks the first tier the whole amount fits in
+type TieredFee struct {
+	tiers []FeeTier
+}
+
+func NewTieredFee(tiers ...FeeTier) *TieredFee {
+	return &TieredFee{tiers: tiers}
+}
+
+func (f *TieredFee) tier(amount Money) (FeeTier, bool) {
+	for _, tier := range f.tiers {
+		if tier.UpTo == 0 || amount.Amount() <= tier.UpTo {
+			return tier, true
+		}
+	}
+	return FeeTier{}, false
+}
+
+func (f *TieredFee) Fee(amount Money) Money {
+	if tier, ok := f.tier(amount); ok {
+		return tier.Strategy.Fee(amount)
+	}
+	return Money{currency: amount.currency}
+}
+
+func (f *TieredFee) String() string {
+	parts := make([]string, len(f.tiers))
+	for i, tier := range f.tiers {
+		limit := "above"
+		if tier.UpTo > 0 {
+			limit = fmt.Sprintf("<= %g", tier.UpTo)
+		}
+		parts[i] = fmt.Sprintf("%s: %s", limit, tier.Strategy)
+	}
+	return "tiered (" + strings.Join(parts, ", ") + ")"
+}
+
+// ApplyFee never lets the fee exceed the amount, so the net is never negative
+func ApplyFee(strategy FeeStrategy, amount Money) FeeBreakdown {
+	fee := strategy.Fee(amount)
+	if fee.minor > amount.minor {
+		fee.minor = amount.minor
+	}
+	return FeeBreakdown{
+		Strategy: strategy.String(),
+		Fee:      fee,
+		Net:      Money{minor: amount.minor - fee.minor, currency: amount.currency},
+	}
+}
+
+// ProcessorFunc lets a plain function act as a PaymentProcessor, like http.HandlerFunc
+type ProcessorFunc func(ctx context.Context, payment *Payment) (Receipt, error)
+
+func (f ProcessorFunc) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	return f(ctx, payment)
+}
+
+// Credit card processor implementation
+type CreditCardProcessor struct{}
+
+func (c *CreditCardProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	if err := ctx.Err(); err != nil {
+		return Receipt{}, err
+	}
+	fmt.Printf("Processing credit card payment: %s\n", payment.id)
+	return newReceipt("credit_card", "CC-"+payment.id, payment), nil
+}
+
+// SimulatedProcessor charges nothing and prints nothing; it just takes time,
+// for demos and load tests
+type SimulatedProcessor struct {
+	latency time.Duration
+}
+
+func (p *SimulatedProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	if err := sleepContext(ctx, p.latency); err != nil {
+		return Receipt{}, err
+	}
+	return newReceipt("simulated", "SIM-"+payment.id, payment), nil
+}
+
+// sleepContext waits for d, or returns early with ctx's error
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// PayPal processor implementation
+type PayPalProcessor struct{}
+
+var paypalCurrencies = map[string]bool{"USD": true, "EUR": true, "GBP": true}
+
+func (p *PayPalProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	if err := ctx.Err(); err != nil {
+		return Receipt{}, err
+	}
+	if !paypalCurrencies[payment.amount.Currency()] {
+		return Receipt{}, fmt.Errorf("%w: PayPal does not take %s", ErrInvalidCurrency, payment.amount.Currency())
+	}
+	fmt.Printf("Processing PayPal payment: %s\n", payment.id)
+	return newReceipt("paypal", "PP-"+payment.id, payment), nil
+}
+
+// Adapter: a legacy gateway with an incompatible API (cents + reference, coded errors)
+type OldBankGateway struct {
+	declineOverCents int  // 0 means no limit
+	offline          bool // simulates the bank's host being down
+	outages          int  // the next n charges fail as if offline
+}
+
+// OldBankError carries the legacy response code
+type OldBankError struct {
+	Code int // 51 = over limit, 91 = issuer unavailable
+	Ref  string
+}
+
+func (e *OldBankError) Error() string {
+	return fmt.Sprintf("old bank: response %d for %s", e.Code, e.Ref)
+}
+
+func (g *OldBankGateway) Charge(cents int, ref string) error {
+	if g.offline {
+		return &OldBankError{Code: 91, Ref: ref}
+	}
+	if g.outages > 0 {
+		g.outages--
+		return &OldBankError{Code: 91, Ref: ref}
+	}
+	if g.declineOverCents > 0 && cents > g.declineOverCents {
+		return &OldBankError{Code: 51, Ref: ref}
+	}
+	fmt.Printf("Old bank charged %d cents, ref %s\n", cents, ref)
+	return nil
+}
+
+// OldBankGatewayAdapter makes OldBankGateway satisfy PaymentProcessor
+// without changing either side
+type OldBankGatewayAdapter struct {
+	gateway *OldBankGateway
+}
+
+func NewOldBankGatewayAdapter(gateway *OldBankGateway) *OldBankGatewayAdapter {
+	return &OldBankGatewayAdapter{gateway: gateway}
+}
+
+// ProcessPayment also translates the legacy response codes into our error categories
+func (a *OldBankGatewayAdapter) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	if payment.amount.Currency() != "USD" {
+		return Receipt{}, fmt.Errorf("%w: old bank only supports USD, got %s", ErrInvalidCurrency, payment.amount.Currency())
+	}
+	if err := ctx.Err(); err != nil { // the legacy API cannot be interrupted once called
+		return Receipt{}, err
+	}
+	cents := int(payment.amount.Minor()) // USD only, so minor units are cents
+	if err := a.gateway.Charge(cents, payment.id); err != nil {
+		var bankErr *OldBankError
+		if errors.As(err, &bankErr) && bankErr.Code == 91 {
+			return Receipt{}, fmt.Errorf("%w: %w", ErrNetwork, err)
+		}
+		return Receipt{}, fmt.Errorf("%w: %w", ErrPaymentDeclined, err)
+	}
+	return newReceipt("old_bank", fmt.Sprintf("OB-%d-%s", cents, payment.id), payment), nil
+}
+
+// Mock gateway: a tiny HTTP payment API to test against. Failure modes are
+// chosen by the last two minor-unit digits, like card-network test numbers: .02 is
+// declined (402), .05 is unavailable (503), .07 is too slow (sleeps past any
+// sane timeout), and anything but USD/EUR is rejected with 422.
+type gatewayRequest struct {
+	PaymentID       string `json:"payment_id,omitempty"`
+	AmountMinor     int64  `json:"amount_minor,omitempty"`
+	Currency        string `json:"currency,omitempty"`
+	AuthorizationID string `json:"authorization_id,omitempty"`
+	CaptureID       string `json:"capture_id,omitempty"`
+}
+
+type gatewayResponse struct {
+	ID    string `json:"id,omitempty"`
+	Code  string `json:"code,omitempty"` // set on errors
+	Error string `json:"error,omitempty"`
+}
+
+type MockGateway struct {
+	mu         sync.Mutex
+	nextID     int
+	authorized map[string]gatewayRequest // authorization id -> request
+	captured   map[string]bool           // capture id -> refunded
+	slow       time.Duration
+}
+
+func NewMockGateway() *MockGateway {
+	return &MockGateway{authorized: map[string]gatewayRequest{}, captured: map[string]bool{}, slow: 300 * time.Millisecond}
+}
+
+func (g *MockGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req gatewayRequest
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&req) != nil {
+		writeGateway(w, http.StatusBadRequest, gatewayResponse{Code: "bad_request", Error: "POST a JSON body"})
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nextID++
+	switch r.URL.Path {
+	case "/authorize":
+		switch {
+		case req.PaymentID == "" || req.AmountMinor <= 0:
+			writeGateway(w, http.StatusBadRequest, gatewayResponse{Code: "bad_request", Error: "payment_id and a positive amount_minor are required"})
+		case req.Currency != "USD" && req.Currency != "EUR":
+			writeGateway(w, http.StatusUnprocessableEntity, gatewayResponse{Code: "unsupported_currency", Error: req.Currency})
+		case req.AmountMinor%100 == 2:
+			writeGateway(w, http.StatusPaymentRequired, gatewayResponse{Code: "card_declined", Error: "insufficient funds"})
+		case req.AmountMinor%100 == 5:
+			writeGateway(w, http.StatusServiceUnavailable, gatewayResponse{Code: "unavailable", Error: "try again later"})
+		case req.AmountMinor%100 == 7:
+			g.mu.Unlock()
+			time.Sleep(g.slow)
+			g.mu.Lock()
+			writeGateway(w, http.StatusGatewayTimeout, gatewayResponse{Code: "timeout"})
+		default:
+			id := fmt.Sprintf("auth_%d", g.nextID)
+			g.authorized[id] = req
+			writeGateway(w, http.StatusOK, gatewayResponse{ID: id})
+		}
+	case "/capture":
+		if _, ok := g.authorized[req.AuthorizationID]; !ok {
+			writeGateway(w, http.StatusNotFound, gatewayResponse{Code: "unknown_authorization", Error: req.AuthorizationID})
+			return
+		}
+		delete(g.authorized, req.AuthorizationID)
+		id := fmt.Sprintf("cap_%d", g.nextID)
+		g.captured[id] = false
+		writeGateway(w, http.StatusOK, gatewayResponse{ID: id})
+	case "/refund":
+		refunded, ok := g.captured[req.CaptureID]
+		if !ok || refunded {
+			writeGateway(w, http.StatusConflict, gatewayResponse{Code: "not_refundable", Error: req.CaptureID})
+			return
+		}
+		g.captured[req.CaptureID] = true
+		writeGateway(w, http.StatusOK, gatewayResponse{ID: fmt.Sprintf("ref_%d", g.nextID)})
+	default:
+		writeGateway(w, http.StatusNotFound, gatewayResponse{Code: "not_found", Error: r.URL.Path})
+	}
+}
+
+func writeGateway(w http.ResponseWriter, status int, resp gatewayResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// HTTPGatewayProcessor is the client side: authorize then capture over HTTP,
+// mapping status codes onto our error categories. It refunds too, remembering
+// the capture id of every payment it charged.
+type HTTPGatewayProcessor struct {
+	baseURL string
+	client  *http.Client
+
+	mu       sync.Mutex
+	captures map[string]string // payment id -> capture id
+}
+
+func NewHTTPGatewayProcessor(baseURL string, timeout time.Duration) *HTTPGatewayProcessor {
+	return &HTTPGatewayProcessor{baseURL: baseURL, client: &http.Client{Timeout: timeout}, captures: map[string]string{}}
+}
+
+func (p *HTTPGatewayProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	auth, err := p.call(ctx, "/authorize", gatewayRequest{
+		PaymentID:   payment.id,
+		AmountMinor: payment.amount.Minor(),
+		Currency:    payment.amount.Currency(),
+	})
+	if err != nil {
+		return Receipt{}, err
+	}
+	capture, err := p.call(ctx, "/capture", gatewayRequest{AuthorizationID: auth.ID})
+	if err != nil {
+		return Receipt{}, err
+	}
+	p.mu.Lock()
+	p.captures[payment.id] = capture.ID
+	p.mu.Unlock()
+	return newReceipt("http_gateway", capture.ID, payment), nil
+}
+
+func (p *HTTPGatewayProcessor) ProcessRefund(ctx context.Context, payment *Payment) error {
+	p.mu.Lock()
+	captureID, ok := p.captures[payment.id]
+	p.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s was not charged through this gateway", ErrInvalidPayment, payment.id)
+	}
+	_, err := p.call(ctx, "/refund", gatewayRequest{CaptureID: captureID})
+	return err
+}
+
+func (p *HTTPGatewayProcessor) call(ctx context.Context, path string, req gatewayRequest) (gatewayResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return gatewayResponse{}, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return gatewayResponse{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpResp, err := p.client.Do(httpReq)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return gatewayResponse{}, fmt.Errorf("gateway %s: %w", path, ctxErr)
+	}
+	if err != nil {
+		return gatewayResponse{}, fmt.Errorf("%w: %w", ErrNetwork, err) // includes client timeouts
+	}
+	defer httpResp.Body.Close()
+	var resp gatewayResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return gatewayResponse{}, fmt.Errorf("%w: %s: unreadable response: %w", ErrNetwork, path, err)
+	}
+	detail := fmt.Sprintf("gateway %s: %d %s %s", path, httpResp.StatusCode, resp.Code, resp.Error)
+	switch {
+	case httpResp.StatusCode == http.StatusOK:
+		return resp, nil
+	case httpResp.StatusCode == http.StatusPaymentRequired:
+		return resp, fmt.Errorf("%w: %s", ErrPaymentDeclined, detail)
+	case resp.Code == "unsupported_currency":
+		return resp, fmt.Errorf("%w: %s", ErrInvalidCurrency, detail)
+	case httpResp.StatusCode >= 500:
+		return resp, fmt.Errorf("%w: %s", ErrNetwork, detail)
+	}
+	return resp, fmt.Errorf("%w: %s", ErrInvalidPayment, detail)
+}
+
+// Registry: processors self-register by name, services resolve them at runtime,
+// so adding a payment method never touches service code (OCP)
+type ProcessorFactory func() PaymentProcessor
+
+var ErrUnknownProcessor = errors.New("unknown payment processor")
+
+type registration struct {
+	factory ProcessorFactory
+	fees    FeeStrategy // nil means the processor charges no fee
+}
+
+var (
+	processorsMu sync.RWMutex
+	processors   = map[string]registration{}
+)
+
+// RegisterProcessor panics on duplicates, like database/sql.Register:
+// two packages claiming one name is a programming error
+func RegisterProcessor(name string, factory ProcessorFactory) {
+	RegisterProcessorWithFees(name, factory, nil)
+}
+
+// RegisterProcessorWithFees attaches how this processor charges; every
+// processor NewProcessor builds for name then reports fees on its receipts
+func RegisterProcessorWithFees(name string, factory ProcessorFactory, fees FeeStrategy) {
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	if factory == nil {
+		panic("payment: RegisterProcessor factory is nil")
+	}
+	if _, dup := processors[name]; dup {
+		panic("payment: RegisterProcessor called twice for " + name)
+	}
+	processors[name] = registration{factory: factory, fees: fees}
+}
+
+func lookupProcessor(name string) (registration, error) {
+	processorsMu.RLock()
+	defer processorsMu.RUnlock()
+	reg, ok := processors[name]
+	if !ok {
+		return registration{}, fmt.Errorf("%w: %q", ErrUnknownProcessor, name)
+	}
+	return reg, nil
+}
+
+func NewProcessor(name string) (PaymentProcessor, error) {
+	reg, err := lookupProcessor(name)
+	if err != nil {
+		return nil, err
+	}
+	if reg.fees == nil {
+		return reg.factory(), nil
+	}
+	return NewFeeProcessor(reg.factory(), reg.fees), nil
+}
+
+func ProcessorNames() []string {
+	processorsMu.RLock()
+	defer processorsMu.RUnlock()
+	names := make([]string, 0, len(processors))
+	for name := range processors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterProcessorWithFees("credit_card", func() PaymentProcessor { return &CreditCardProcessor{} },
+		NewTieredFee(FeeTier{UpTo: 100, Strategy: PercentageFee{Percent: 2.9}}, FeeTier{Strategy: PercentageFee{Percent: 2.4}}))
+	RegisterProcessorWithFees("paypal", func() PaymentProcessor { return &PayPalProcessor{} }, PercentageFee{Percent: 3.49})
+	RegisterProcessorWithFees("old_bank", func() PaymentProcessor { return NewOldBankGatewayAdapter(&OldBankGateway{}) }, FlatFee{Amount: 0.25})
+}
+
+// Routing: a ProcessorRouter is itself a PaymentProcessor, so the service
+// stays unaware that each payment may go to a different processor
+var ErrNoRoute = errors.New("no processor can take this payment")
+
+// Route describes when a processor may be used; zero fields mean "no restriction".
+// What a route costs is not configured here: it is the FeeStrategy registered
+// under Name, the same one that prices the receipt.
+type Route struct {
+	Name       string
+	Processor  PaymentProcessor // nil resolves Name from the registry
+	Currencies []string
+	MinAmount  float64
+	MaxAmount  float64
+	fees       FeeStrategy // nil when Name has no registered fees
+	healthy    bool
+	downSince  time.Time // set when the router took the route out, zero if an operator did
 }
 
-// Constructor function for Payment
-func NewPayment(id string, amount float64, currency string) *Payment {
-	return &Payment{
-		id:       id,
-		amount:   amount,
-		currency: currency,
+// Fee is what this route would keep from payment; routes without fees are free
+func (r Route) Fee(payment *Payment) Money {
+	if r.fees == nil {
+		return Money{currency: payment.amount.currency}
+	}
+	return ApplyFee(r.fees, payment.amount).Fee
+}
+
+func (r Route) Accepts(payment *Payment) bool {
+	amount := payment.amount.Amount()
+	if !r.healthy || amount < r.MinAmount || (r.MaxAmount > 0 && amount > r.MaxAmount) {
+		return false
+	}
+	if len(r.Currencies) == 0 {
+		return true
+	}
+	for _, currency := range r.Currencies {
+		if currency == payment.amount.Currency() {
+			return true
+		}
+	}
+	return false
+}
+
+type RoutingStrategy interface {
+	Choose(payment *Payment, routes []Route) (Route, error)
+}
+
+// FirstMatchStrategy takes the first acceptable route in priority order
+type FirstMatchStrategy struct{}
+
+func (FirstMatchStrategy) Choose(payment *Payment, routes []Route) (Route, error) {
+	for _, route := range routes {
+		if route.Accepts(payment) {
+			return route, nil
+		}
+	}
+	return Route{}, fmt.Errorf("%w: %s (%s)", ErrNoRoute, payment.id, payment.amount)
+}
+
+// LowestFeeStrategy takes the acceptable route whose fee on this amount is smallest,
+// so tiered and flat fees compare correctly against percentages
+type LowestFeeStrategy struct{}
+
+func (LowestFeeStrategy) Choose(payment *Payment, routes []Route) (Route, error) {
+	best := -1
+	for i, route := range routes {
+		if route.Accepts(payment) && (best < 0 || route.Fee(payment).minor < routes[best].Fee(payment).minor) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Route{}, fmt.Errorf("%w: %s (%s)", ErrNoRoute, payment.id, payment.amount)
+	}
+	return routes[best], nil
+}
+
+type ProcessorRouter struct {
+	mu         sync.Mutex
+	strategy   RoutingStrategy
+	routes     []Route
+	clock      Clock
+	probeAfter time.Duration
+}
+
+const defaultProbeAfter = 30 * time.Second
+
+// NewProcessorRouter resolves registry names up front so a typo fails at startup.
+// A route with its own Processor still takes its fees from the registration under
+// Name, if there is one, so the router prices every route the same way.
+func NewProcessorRouter(strategy RoutingStrategy, routes ...Route) (*ProcessorRouter, error) {
+	router := &ProcessorRouter{strategy: strategy, clock: SystemClock{}, probeAfter: defaultProbeAfter}
+	for _, route := range routes {
+		reg, err := lookupProcessor(route.Name)
+		if err != nil && route.Processor == nil {
+			return nil, err
+		}
+		if route.Processor == nil {
+			route.Processor = reg.factory()
+		}
+		route.fees = reg.fees
+		route.healthy = true
+		router.routes = append(router.routes, route)
+	}
+	return router, nil
+}
+
+// WithRecovery sets how long a route the router took out waits before one
+// payment is sent to it as a probe; success puts it back into rotation
+func (r *ProcessorRouter) WithRecovery(probeAfter time.Duration, clock Clock) *ProcessorRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.probeAfter, r.clock = probeAfter, clock
+	return r
+}
+
+// SetHealthy takes a route out of (or back into) rotation. A route an operator
+// takes out stays out; only routes the router marked down are probed.
+func (r *ProcessorRouter) SetHealthy(name string, healthy bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setHealthy(name, healthy, time.Time{})
+}
+
+func (r *ProcessorRouter) setHealthy(name string, healthy bool, downSince time.Time) {
+	for i := range r.routes {
+		if r.routes[i].Name == name {
+			r.routes[i].healthy, r.routes[i].downSince = healthy, downSince
+		}
+	}
+}
+
+// snapshot copies the routes for one payment. A route that has been down for
+// probeAfter is offered to this payment as a probe, and its timer restarts so
+// concurrent payments do not all pile onto a processor that may still be down.
+func (r *ProcessorRouter) snapshot() (routes []Route, probing map[string]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	probing = map[string]bool{}
+	for i := range r.routes {
+		route := &r.routes[i]
+		if !route.healthy && !route.downSince.IsZero() && now.Sub(route.downSince) >= r.probeAfter {
+			route.downSince = now
+			probing[route.Name] = true
+		}
+		routes = append(routes, *route)
+		if probing[route.Name] {
+			routes[len(routes)-1].healthy = true
+		}
+	}
+	return routes, probing
+}
+
+// HealthReporter is implemented by processors that track their own health,
+// such as CircuitBreakerProcessor; the router asks them instead of guessing
+type HealthReporter interface {
+	Healthy() bool
+}
+
+// ProcessPayment marks a route unhealthy when it is unreachable, so a retry goes
+// elsewhere, and puts it back once a probe gets through. The receipt carries the
+// fee of the route that was actually used.
+func (r *ProcessorRouter) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	routes, probing := r.snapshot()
+	for i := range routes {
+		if reporter, ok := routes[i].Processor.(HealthReporter); ok {
+			routes[i].healthy = routes[i].healthy && reporter.Healthy()
+		}
+	}
+	route, err := r.strategy.Choose(payment, routes)
+	if err != nil {
+		return Receipt{}, err
+	}
+	receipt, err := route.Processor.ProcessPayment(ctx, payment)
+	if _, selfReporting := route.Processor.(HealthReporter); !selfReporting {
+		r.mu.Lock()
+		switch {
+		case errors.Is(err, ErrNetwork):
+			r.setHealthy(route.Name, false, r.clock.Now())
+		case err == nil && probing[route.Name]:
+			r.setHealthy(route.Name, true, time.Time{})
+		}
+		r.mu.Unlock()
+	}
+	if err == nil && route.fees != nil {
+		receipt.Fees = ApplyFee(route.fees, receipt.Amount)
+	}
+	return receipt, err
+}
+
+// 3. LSP: RefundProcessor interface. Any refunder must accept every payment
+// its paired processor captured and must leave the lifecycle to the service.
+type RefundProcessor interface {
+	ProcessRefund(ctx context.Context, payment *Payment) error
+}
+
+var ErrRefundsUnsupported = errors.New("refunds not configured")
+
+type CreditCardRefundProcessor struct{}
+
+func (c *CreditCardRefundProcessor) ProcessRefund(ctx context.Context, payment *Payment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fmt.Printf("Processing credit card refund: %s\n", payment.id)
+	return nil
+}
+
+type PayPalRefundProcessor struct{}
+
+// Same currencies as PayPalProcessor: no stronger precondition than the charge side
+func (p *PayPalRefundProcessor) ProcessRefund(ctx context.Context, payment *Payment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !paypalCurrencies[payment.amount.Currency()] {
+		return fmt.Errorf("%w: PayPal does not refund %s", ErrInvalidCurrency, payment.amount.Currency())
+	}
+	fmt.Printf("Processing PayPal refund: %s\n", payment.id)
+	return nil
+}
+
+// CappedRefundProcessor violates LSP: it refuses refunds its processor happily charged
+type CappedRefundProcessor struct {
+	max float64
+}
+
+func (c *CappedRefundProcessor) ProcessRefund(ctx context.Context, payment *Payment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if payment.amount.Amount() > c.max {
+		return fmt.Errorf("%w: refunds over %.2f need a manual review", ErrPaymentDeclined, c.max)
+	}
+	return nil
+}
+
+// 4. ISP: Separate interfaces for different responsibilities
+type Notifier interface {
+	SendNotification(ctx context.Context, message string)
+}
+
+type EmailNotifier struct{}
+
+func (e *EmailNotifier) SendNotification(ctx context.Context, message string) {
+	fmt.Printf("Sending email: %s\n", message)
+}
+
+// RecordingNotifier keeps messages instead of sending them
+type RecordingNotifier struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *RecordingNotifier) SendNotification(ctx context.Context, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message)
+}
+
+func (r *RecordingNotifier) Messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.messages...)
+}
+
+type SMSNotifier struct{}
+
+func (s *SMSNotifier) SendNotification(ctx context.Context, message string) {
+	fmt.Printf("Sending SMS: %s\n", message)
+}
+
+// Templated notifications: rendering text is a separate responsibility (SRP),
+// so notifiers only deliver and each channel picks its own template
+type PaymentView struct {
+	ID       string  `json:"id"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Success  bool    `json:"success"`
+}
+
+func (p *Payment) View(success bool) PaymentView {
+	return PaymentView{ID: p.id, Amount: p.amount.Amount(), Currency: p.amount.Currency(), Success: success}
+}
+
+type StatementView struct {
+	Owner    string
+	Currency string
+	Payments []PaymentView
+}
+
+func (s StatementView) Total() float64 {
+	total := 0.0
+	for _, p := range s.Payments {
+		if p.Success {
+			total += p.Amount
+		}
+	}
+	return total
+}
+
+func FormatMoney(amount float64, currency string) string {
+	return NewMoney(amount, currency).String()
+}
+
+var templateFuncs = map[string]interface{}{"money": FormatMoney}
+
+// Partials ("header", "line") are shared by receipts, statements and notifications
+const textTemplates = `
+{{define "header"}}ACME Pay{{end}}
+{{define "line"}}{{.ID}}  {{money .Amount .Currency}}  {{if .Success}}paid{{else}}failed{{end}}{{end}}
+{{define "receipt"}}{{template "header"}} receipt
+{{template "line" .}}{{end}}
+{{define "statement"}}{{template "header"}} statement for {{.Owner}}
+{{range .Payments}}{{template "line" .}}
+{{end}}Total paid: {{money .Total .Currency}}{{end}}
+{{define "sms"}}{{if .Success}}Paid{{else}}FAILED{{end}} {{money .Amount .Currency}} ref {{.ID}}{{end}}
+{{define "email"}}Subject: Payment {{.ID}}
+{{template "receipt" .}}{{end}}
+`
+
+// HTML channels get auto-escaping from html/template
+const htmlTemplates = `
+{{define "header"}}<h1>ACME Pay</h1>{{end}}
+{{define "email"}}{{template "header"}}<p>Payment <b>{{.ID}}</b>: {{money .Amount .Currency}} ({{if .Success}}paid{{else}}failed{{end}})</p>{{end}}
+`
+
+type Renderer interface {
+	Render(name string, data interface{}) (string, error)
+}
+
+type TextTemplateRenderer struct {
+	templates *texttemplate.Template
+}
+
+func NewTextTemplateRenderer() (*TextTemplateRenderer, error) {
+	templates, err := texttemplate.New("text").Funcs(templateFuncs).Parse(textTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("parse text templates: %w", err)
+	}
+	return &TextTemplateRenderer{templates: templates}, nil
+}
+
+func (r *TextTemplateRenderer) Render(name string, data interface{}) (string, error) {
+	var out strings.Builder
+	if err := r.templates.ExecuteTemplate(&out, name, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+type HTMLTemplateRenderer struct {
+	templates *htmltemplate.Template
+}
+
+func NewHTMLTemplateRenderer() (*HTMLTemplateRenderer, error) {
+	templates, err := htmltemplate.New("html").Funcs(templateFuncs).Parse(htmlTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("parse html templates: %w", err)
+	}
+	return &HTMLTemplateRenderer{templates: templates}, nil
+}
+
+func (r *HTMLTemplateRenderer) Render(name string, data interface{}) (string, error) {
+	var out strings.Builder
+	if err := r.templates.ExecuteTemplate(&out, name, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// PaymentNotifier is an optional capability: services use it when the notifier has it
+type PaymentNotifier interface {
+	NotifyPayment(ctx context.Context, view PaymentView) error
+}
+
+// TemplatedNotifier configures one channel with its renderer and template
+type TemplatedNotifier struct {
+	Notifier
+	renderer Renderer
+	template string
+}
+
+func NewTemplatedNotifier(channel Notifier, renderer Renderer, template string) *TemplatedNotifier {
+	return &TemplatedNotifier{Notifier: channel, renderer: renderer, template: template}
+}
+
+func (t *TemplatedNotifier) NotifyPayment(ctx context.Context, view PaymentView) error {
+	body, err := t.renderer.Render(t.template, view)
+	if err != nil {
+		return err
+	}
+	t.SendNotification(ctx, body)
+	return nil
+}
+
+// HTTPWebhookNotifier POSTs signed JSON to a partner's URL. It implements both
+// Notifier and PaymentNotifier; Notifier has no error result, so the last
+// delivery error is kept for Err, like JSONFileRepository does.
+var ErrWebhookFailed = errors.New("webhook delivery failed")
+
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+type WebhookPayload struct {
+	Event   string       `json:"event"` // "payment" or "message"
+	Payment *PaymentView `json:"payment,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+type HTTPWebhookNotifier struct {
+	url         string
+	secret      []byte
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+
+	mu  sync.Mutex
+	err error
+}
+
+func NewHTTPWebhookNotifier(url, secret string, timeout time.Duration, maxAttempts int) *HTTPWebhookNotifier {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &HTTPWebhookNotifier{
+		url:         url,
+		secret:      []byte(secret),
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: maxAttempts,
+		backoff:     10 * time.Millisecond,
+	}
+}
+
+// SignWebhook is shared by sender and receiver: hex HMAC-SHA256 of the raw body
+func SignWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyWebhook(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, body)), []byte(signature))
+}
+
+func (n *HTTPWebhookNotifier) Err() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.err
+}
+
+func (n *HTTPWebhookNotifier) SendNotification(ctx context.Context, message string) {
+	n.Notify(ctx, message)
+}
+
+// Notify is SendNotification with the delivery error returned
+func (n *HTTPWebhookNotifier) Notify(ctx context.Context, message string) error {
+	return n.deliver(ctx, WebhookPayload{Event: "message", Message: message})
+}
+
+func (n *HTTPWebhookNotifier) NotifyPayment(ctx context.Context, view PaymentView) error {
+	return n.deliver(ctx, WebhookPayload{Event: "payment", Payment: &view})
+}
+
+// deliver retries transport errors and 5xx responses; a 4xx means the request
+// itself is wrong, so sending it again cannot help
+func (n *HTTPWebhookNotifier) deliver(ctx context.Context, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return n.setErr(err)
+	}
+	signature := SignWebhook(n.secret, body)
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, body, signature)
+		if err == nil {
+			return n.setErr(nil)
+		}
+		if !retry || attempt == n.maxAttempts || ctx.Err() != nil {
+			return n.setErr(fmt.Errorf("%w after %d attempt(s): %w", ErrWebhookFailed, attempt, err))
+		}
+		if err := sleepContext(ctx, n.backoff*time.Duration(attempt)); err != nil {
+			return n.setErr(fmt.Errorf("%w after %d attempt(s): %w", ErrWebhookFailed, attempt, err))
+		}
+	}
+}
+
+func (n *HTTPWebhookNotifier) post(ctx context.Context, body []byte, signature string) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, signature)
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500, fmt.Errorf("status %s", resp.Status)
+	}
+	return false, nil
+}
+
+func (n *HTTPWebhookNotifier) setErr(err error) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.err = err
+	return err
+}
+
+// ErrorNotifier is an optional capability for channels that can report failure
+type ErrorNotifier interface {
+	Notify(ctx context.Context, message string) error
+}
+
+var ErrAllChannelsFailed = errors.New("every notification channel failed")
+
+// ChannelError names the channel that failed
+type ChannelError struct {
+	Channel string
+	Err     error
+}
+
+func (e *ChannelError) Error() string { return e.Channel + ": " + e.Err.Error() }
+func (e *ChannelError) Unwrap() error { return e.Err }
+
+// CompositeNotifier fans one message out to every channel concurrently. By
+// default any failure is an error; with RequireAtLeastOne a single delivery is enough.
+type CompositeNotifier struct {
+	channels   []Notifier
+	requireOne bool
+
+	mu     sync.Mutex
+	failed []*ChannelError // from the latest send
+}
+
+func NewCompositeNotifier(channels ...Notifier) *CompositeNotifier {
+	return &CompositeNotifier{channels: channels}
+}
+
+func (c *CompositeNotifier) RequireAtLeastOne() *CompositeNotifier {
+	c.requireOne = true
+	return c
+}
+
+func (c *CompositeNotifier) SendNotification(ctx context.Context, message string) {
+	c.Notify(ctx, message)
+}
+
+func (c *CompositeNotifier) Notify(ctx context.Context, message string) error {
+	errs := make([]error, len(c.channels))
+	var wg sync.WaitGroup
+	for i, channel := range c.channels {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if en, ok := channel.(ErrorNotifier); ok {
+				errs[i] = en.Notify(ctx, message)
+			} else {
+				channel.SendNotification(ctx, message) // cannot fail as far as we can tell
+			}
+		}()
+	}
+	wg.Wait()
+
+	var failed []*ChannelError
+	var joined []error
+	for i, err := range errs {
+		if err != nil {
+			channelErr := &ChannelError{Channel: fmt.Sprintf("%T", c.channels[i]), Err: err}
+			failed = append(failed, channelErr)
+			joined = append(joined, channelErr)
+		}
+	}
+	c.mu.Lock()
+	c.failed = failed
+	c.mu.Unlock()
+
+	switch {
+	case len(failed) == 0:
+		return nil
+	case len(failed) == len(c.channels):
+		return fmt.Errorf("%w: %w", ErrAllChannelsFailed, errors.Join(joined...))
+	case c.requireOne:
+		return nil
+	}
+	return errors.Join(joined...)
+}
+
+// Failures reports the channels that failed on the latest send, even when
+// RequireAtLeastOne made it a success
+func (c *CompositeNotifier) Failures() []*ChannelError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*ChannelError(nil), c.failed...)
+}
+
+// Logger interface - following ISP. Leveled and structured: a message plus
+// key-value pairs, e.g. logger.Info("payment completed", "payment", id)
+type Logger interface {
+	Debug(message string, keyvals ...interface{})
+	Info(message string, keyvals ...interface{})
+	Warn(message string, keyvals ...interface{})
+	Error(message string, keyvals ...interface{})
+}
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	return [...]string{"DEBUG", "INFO", "WARN", "ERROR"}[l]
+}
+
+// LogField is one key-value pair; a key without a value gets "!MISSING"
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+func logFields(keyvals []interface{}) []LogField {
+	fields := make([]LogField, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		field := LogField{Key: fmt.Sprint(keyvals[i]), Value: "!MISSING"}
+		if i+1 < len(keyvals) {
+			field.Value = keyvals[i+1]
+		}
+		switch value := field.Value.(type) {
+		case error:
+			field.Value = MaskPANs(value.Error()) // errors marshal to {} in JSON otherwise
+		case string:
+			field.Value = MaskPANs(value)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// leveledLogger turns the four methods into one log func, so each backend
+// only decides how to write an entry
+type leveledLogger struct {
+	min Level
+	log func(level Level, message string, fields []LogField)
+}
+
+func (l leveledLogger) write(level Level, message string, keyvals []interface{}) {
+	if level >= l.min {
+		l.log(level, MaskPANs(message), logFields(keyvals))
+	}
+}
+
+func (l leveledLogger) Debug(message string, keyvals ...interface{}) {
+	l.write(LevelDebug, message, keyvals)
+}
+
+func (l leveledLogger) Info(message string, keyvals ...interface{}) {
+	l.write(LevelInfo, message, keyvals)
+}
+
+func (l leveledLogger) Warn(message string, keyvals ...interface{}) {
+	l.write(LevelWarn, message, keyvals)
+}
+
+func (l leveledLogger) Error(message string, keyvals ...interface{}) {
+	l.write(LevelError, message, keyvals)
+}
+
+// ConsoleLogger writes "LEVEL message key=value ..." lines for humans
+type ConsoleLogger struct {
+	leveledLogger
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func NewConsoleLogger(out io.Writer, min Level) *ConsoleLogger {
+	c := &ConsoleLogger{out: out}
+	c.leveledLogger = leveledLogger{min: min, log: c.log}
+	return c
+}
+
+func (c *ConsoleLogger) log(level Level, message string, fields []LogField) {
+	var line strings.Builder
+	fmt.Fprintf(&line, "%-5s %s", level, message)
+	for _, field := range fields {
+		fmt.Fprintf(&line, " %s=%v", field.Key, field.Value)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintln(c.out, line.String())
+}
+
+// JSONLinesLogger writes one JSON object per entry for log pipelines
+type JSONLinesLogger struct {
+	leveledLogger
+	mu    sync.Mutex
+	out   io.Writer
+	clock Clock
+}
+
+func NewJSONLinesLogger(out io.Writer, min Level, clock Clock) *JSONLinesLogger {
+	j := &JSONLinesLogger{out: out, clock: clock}
+	j.leveledLogger = leveledLogger{min: min, log: j.log}
+	return j
+}
+
+func (j *JSONLinesLogger) log(level Level, message string, fields []LogField) {
+	// built by hand to keep time, level and msg first and fields in call order
+	var line bytes.Buffer
+	fmt.Fprintf(&line, `{"time":%q,"level":%q,"msg":%s`, j.clock.Now().Format(time.RFC3339), strings.ToLower(level.String()), mustJSON(message))
+	for _, field := range fields {
+		fmt.Fprintf(&line, ",%s:%s", mustJSON(field.Key), mustJSON(field.Value))
+	}
+	line.WriteString("}\n")
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.out.Write(line.Bytes())
+}
+
+func mustJSON(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return data
+}
+
+// MemoryLogger keeps entries for assertions
+type LogEntry struct {
+	Level   Level
+	Message string
+	Fields  []LogField
+}
+
+// Field returns the value logged under key, or nil
+func (e LogEntry) Field(key string) interface{} {
+	for _, field := range e.Fields {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+type MemoryLogger struct {
+	leveledLogger
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+func NewMemoryLogger(min Level) *MemoryLogger {
+	m := &MemoryLogger{}
+	m.leveledLogger = leveledLogger{min: min, log: m.log}
+	return m
+}
+
+func (m *MemoryLogger) log(level Level, message string, fields []LogField) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, LogEntry{Level: level, Message: message, Fields: fields})
+}
+
+func (m *MemoryLogger) Entries() []LogEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]LogEntry(nil), m.entries...)
+}
+
+// Repository interface - following DIP
+type PaymentRepository interface {
+	SavePayment(ctx context.Context, payment *Payment)
+	FindPaymentByID(ctx context.Context, id string) *Payment
+}
+
+// InMemoryPaymentRepository keeps payments in a map; safe for concurrent use
+type InMemoryPaymentRepository struct {
+	mu       sync.RWMutex
+	payments map[string]*Payment
+}
+
+func NewInMemoryPaymentRepository() *InMemoryPaymentRepository {
+	return &InMemoryPaymentRepository{payments: make(map[string]*Payment)}
+}
+
+func (r *InMemoryPaymentRepository) SavePayment(ctx context.Context, payment *Payment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payments[payment.id] = payment
+}
+
+func (r *InMemoryPaymentRepository) FindPaymentByID(ctx context.Context, id string) *Payment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.payments[id]
+}
+
+// paymentRecord is the stored shape of a Payment, every field of it; the
+// state is rebuilt from history
+type paymentRecord struct {
+	ID      string          `json:"id"`
+	Amount  moneyRecord     `json:"amount"`
+	Account string          `json:"account,omitempty"`
+	Country string          `json:"country,omitempty"`
+	Card    CardToken       `json:"card,omitempty"`
+	History []PaymentStatus `json:"history"`
+}
+
+// moneyRecord stores Money as it is held, in minor units, so 1500 JPY and
+// 12.345 BHD survive a round trip exactly
+type moneyRecord struct {
+	Minor    int64  `json:"minor"`
+	Currency string `json:"currency"`
+}
+
+func newPaymentRecord(payment *Payment) paymentRecord {
+	return paymentRecord{
+		ID: payment.id, Amount: moneyRecord{Minor: payment.amount.minor, Currency: payment.amount.currency},
+		Account: payment.account, Country: payment.country, Card: payment.card, History: payment.History(),
+	}
+}
+
+var statesByStatus = map[PaymentStatus]PaymentState{
+	StatusPending:    pendingState{},
+	StatusAuthorized: authorizedState{},
+	StatusCaptured:   capturedState{},
+	StatusRefunded:   refundedState{},
+	StatusFailed:     failedState{},
+}
+
+func (rec paymentRecord) payment() (*Payment, error) {
+	if len(rec.History) == 0 {
+		return nil, fmt.Errorf("payment %s: empty history", rec.ID)
+	}
+	if rec.Amount.Currency == "" {
+		return nil, fmt.Errorf("payment %s: amount has no currency", rec.ID)
+	}
+	state, ok := statesByStatus[rec.History[len(rec.History)-1]]
+	if !ok {
+		return nil, fmt.Errorf("payment %s: unknown status %q", rec.ID, rec.History[len(rec.History)-1])
+	}
+	return &Payment{
+		id: rec.ID, amount: Money{minor: rec.Amount.Minor, currency: rec.Amount.Currency}, account: rec.Account, country: rec.Country,
+		card: rec.Card, state: state, history: rec.History,
+	}, nil
+}
+
+// JSONFileRepository stores all payments in one JSON file, rewritten on each save.
+// The interface has no error results, so failures are kept and reported by Err.
+type JSONFileRepository struct {
+	mu   sync.Mutex
+	path string
+	err  error
+}
+
+func NewJSONFileRepository(path string) *JSONFileRepository {
+	return &JSONFileRepository{path: path}
+}
+
+// Err returns the first error hit by SavePayment or FindPaymentByID
+func (r *JSONFileRepository) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *JSONFileRepository) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *JSONFileRepository) load() (map[string]paymentRecord, error) {
+	records := make(map[string]paymentRecord)
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("%s: %w", r.path, err)
+	}
+	return records, nil
+}
+
+func (r *JSONFileRepository) SavePayment(ctx context.Context, payment *Payment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := ctx.Err(); err != nil { // the write is the expensive part; skip it
+		r.fail(err)
+		return
+	}
+	records, err := r.load()
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	records[payment.id] = newPaymentRecord(payment)
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	// write then rename so a crash never leaves a half-written file
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		r.fail(err)
+		return
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		r.fail(err)
+	}
+}
+
+func (r *JSONFileRepository) FindPaymentByID(ctx context.Context, id string) *Payment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records, err := r.load()
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	record, ok := records[id]
+	if !ok {
+		return nil
+	}
+	payment, err := record.payment()
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	return payment
+}
+
+// 5. DIP: PaymentService depends on abstractions
+type PaymentService struct {
+	processor        PaymentProcessor
+	refunder         RefundProcessor
+	notifier         Notifier
+	logger           Logger
+	repository       PaymentRepository
+	validation       Validator // run first by every entry point; nil means DefaultValidation
+	screener         FraudScreener
+	batchConcurrency int         // workers used by ExecuteBatch; <= 1 means sequential
+	pool             *workerPool // started by WithWorkers, used by Submit
+}
+
+// Constructor function for PaymentService
+func NewPaymentService(processor PaymentProcessor, notifier Notifier) *PaymentService {
+	return &PaymentService{
+		processor: processor,
+		notifier:  notifier,
+	}
+}
+
+// ExecutePayment method
+func (s *PaymentService) ExecutePayment(ctx context.Context, payment *Payment) bool {
+	_, err := s.Charge(ctx, payment)
+	return err == nil
+}
+
+// Charge is ExecutePayment for callers that need the receipt, e.g. to see
+// the fee the processor kept and the net amount that will be paid out
+func (s *PaymentService) Charge(ctx context.Context, payment *Payment) (Receipt, error) {
+	err := s.admit(ctx, payment)
+	var receipt Receipt
+	if err == nil {
+		receipt, err = s.process(ctx, payment)
+	}
+	if err != nil {
+		fmt.Printf("Payment %s %s\n", payment.id, explain(err))
+	} else if receipt.Fees.Fee.IsPositive() {
+		fmt.Println(receipt)
+	}
+	s.notifyPayment(ctx, payment, err == nil)
+	return receipt, err
+}
+
+// WithValidation replaces DefaultValidation, e.g. to add a VelocityCheck
+func (s *PaymentService) WithValidation(validators ...Validator) *PaymentService {
+	s.validation = ValidationPipeline(validators)
+	return s
+}
+
+// admit is the gate every entry point passes before process: Charge, the
+// enhanced and outbox services, ExecuteBatch and Submit all validate and
+// screen the same way
+func (s *PaymentService) admit(ctx context.Context, payment *Payment) error {
+	if err := s.validate(payment); err != nil {
+		return err
+	}
+	return s.screen(ctx, payment)
+}
+
+// validate fails an invalid payment before any processor sees it
+func (s *PaymentService) validate(payment *Payment) error {
+	var err error
+	if s.validation != nil {
+		err = s.validation.Validate(payment)
+	} else {
+		err = DefaultValidation.Validate(payment) // called directly: boxing the slice would allocate per payment
+	}
+	if err != nil {
+		payment.Fail()
+		return err
+	}
+	return nil
+}
+
+// WithFraudScreener asks screener about every payment before processing it
+func (s *PaymentService) WithFraudScreener(screener FraudScreener) *PaymentService {
+	s.screener = screener
+	return s
+}
+
+// screen fails declined payments and leaves ones under review Pending,
+// so they can be executed again once a person has approved them
+func (s *PaymentService) screen(ctx context.Context, payment *Payment) error {
+	if s.screener == nil {
+		return nil
+	}
+	result := s.screener.Screen(ctx, payment)
+	switch result.Decision {
+	case FraudDecline:
+		payment.Fail()
+		return fmt.Errorf("%w: %s: %s", ErrFraudDeclined, payment.id, strings.Join(result.Reasons, ", "))
+	case FraudReview:
+		return fmt.Errorf("%w: %s: %s", ErrHeldForReview, payment.id, strings.Join(result.Reasons, ", "))
+	}
+	return nil
+}
+
+// ExecutePaymentVia resolves the processor by name for this one payment
+func (s *PaymentService) ExecutePaymentVia(ctx context.Context, method string, payment *Payment) (bool, error) {
+	processor, err := NewProcessor(method)
+	if err != nil {
+		return false, err
+	}
+	scoped := *s
+	scoped.processor = processor
+	return scoped.ExecutePayment(ctx, payment), nil
+}
+
+// explain branches on the error category, not on which processor failed
+func explain(err error) string {
+	switch {
+	case errors.Is(err, ErrPaymentDeclined):
+		return "declined, ask the customer for another card: " + err.Error()
+	case errors.Is(err, ErrNetwork):
+		return "not charged, processor unreachable (still authorized, safe to retry): " + err.Error()
+	case errors.Is(err, ErrInvalidCurrency):
+		return "needs another payment method: " + err.Error()
+	case errors.Is(err, ErrHeldForReview):
+		return "is waiting for a person to review it: " + err.Error()
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "abandoned by the caller: " + err.Error()
+	}
+	return "failed: " + err.Error()
+}
+
+// process drives the lifecycle: Pending -> Authorized -> Captured, or Failed.
+// A network error or an ended ctx leaves the payment Authorized: nothing was
+// charged, so it may be retried. Once the processor has charged, the capture
+// is recorded even if ctx ends, because the money has already moved.
+func (s *PaymentService) process(ctx context.Context, payment *Payment) (Receipt, error) {
+	if err := ctx.Err(); err != nil {
+		return Receipt{}, fmt.Errorf("%s: %w", payment.id, err)
+	}
+	if payment.Status() != StatusAuthorized {
+		if err := payment.Authorize(); err != nil {
+			return Receipt{}, err
+		}
+	}
+	receipt, err := s.processor.ProcessPayment(ctx, payment)
+	if err != nil {
+		if !errors.Is(err, ErrNetwork) && ctx.Err() == nil {
+			payment.Fail()
+		}
+		return Receipt{}, fmt.Errorf("%s: %w", payment.id, err)
+	}
+	return receipt, payment.Capture()
+}
+
+// WithRefunds pairs the service's processor with the refunder for the same method
+func (s *PaymentService) WithRefunds(refunder RefundProcessor) *PaymentService {
+	s.refunder = refunder
+	return s
+}
+
+// ExecuteRefund refunds a captured payment: Captured -> Refunded
+func (s *PaymentService) ExecuteRefund(ctx context.Context, payment *Payment) error {
+	if s.refunder == nil {
+		return fmt.Errorf("%w: %s", ErrRefundsUnsupported, payment.id)
+	}
+	if payment.Status() != StatusCaptured {
+		return reject(payment, "refund")
+	}
+	if err := s.refunder.ProcessRefund(ctx, payment); err != nil {
+		s.notifier.SendNotification(ctx, "Refund failed: "+payment.id)
+		return fmt.Errorf("%s: %w", payment.id, err)
+	}
+	if err := payment.Refund(); err != nil {
+		return err
+	}
+	s.notifier.SendNotification(ctx, "Refund issued: "+payment.id)
+	return nil
+}
+
+// notifyPayment prefers a templated channel and falls back to a plain message
+func (s *PaymentService) notifyPayment(ctx context.Context, payment *Payment, success bool) {
+	if templated, ok := s.notifier.(PaymentNotifier); ok {
+		if err := templated.NotifyPayment(ctx, payment.View(success)); err == nil {
+			return
+		}
+	}
+	if success {
+		s.notifier.SendNotification(ctx, "Payment successful: "+payment.id)
+	} else {
+		s.notifier.SendNotification(ctx, "Payment failed: "+payment.id)
+	}
+}
+
+// Domain events: the service announces what happened; side effects such as
+// notifying and logging subscribe to it instead of being called inline
+type PaymentEventKind string
+
+const (
+	PaymentSucceeded PaymentEventKind = "PaymentSucceeded"
+	PaymentFailed    PaymentEventKind = "PaymentFailed"
+)
+
+type PaymentEvent struct {
+	Kind         PaymentEventKind
+	Payment      *Payment
+	Receipt      Receipt       // set on PaymentSucceeded
+	Err          error         // set on PaymentFailed
+	Subscription *Subscription // set by BillingEngine
+}
+
+type EventHandler func(ctx context.Context, event PaymentEvent)
+
+type subscription struct {
+	id      int
+	handler EventHandler
+}
+
+// EventBus delivers synchronously, in subscription order
+type EventBus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[PaymentEventKind][]subscription
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[PaymentEventKind][]subscription)}
+}
+
+// Subscribe returns a function that removes the handler again
+func (b *EventBus) Subscribe(kind PaymentEventKind, handler EventHandler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.handlers[kind] = append(b.handlers[kind], subscription{id: id, handler: handler})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		subs := b.handlers[kind]
+		for i, sub := range subs {
+			if sub.id == id {
+				b.handlers[kind] = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (b *EventBus) Publish(ctx context.Context, event PaymentEvent) {
+	b.mu.RLock()
+	subs := append([]subscription(nil), b.handlers[event.Kind]...)
+	b.mu.RUnlock()
+	for _, sub := range subs { // outside the lock, so handlers may (un)subscribe
+		sub.handler(ctx, event)
+	}
+}
+
+// SubscribeNotifier and SubscribeLogger adapt the existing side effects to events
+func SubscribeNotifier(bus *EventBus, notifier Notifier) {
+	send := func(ctx context.Context, event PaymentEvent) {
+		(&PaymentService{notifier: notifier}).notifyPayment(ctx, event.Payment, event.Kind == PaymentSucceeded)
+	}
+	bus.Subscribe(PaymentSucceeded, send)
+	bus.Subscribe(PaymentFailed, send)
+}
+
+func SubscribeLogger(bus *EventBus, logger Logger) {
+	bus.Subscribe(PaymentSucceeded, func(ctx context.Context, event PaymentEvent) {
+		logger.Info("payment completed", "payment", event.Payment.id, "amount", event.Payment.amount.String(),
+			"reference", event.Receipt.Reference)
+	})
+	bus.Subscribe(PaymentFailed, func(ctx context.Context, event PaymentEvent) {
+		if event.Payment.Status() == StatusAuthorized { // not charged and retryable, so not yet an error
+			logger.Warn("payment not charged", "payment", event.Payment.id, "error", event.Err)
+			return
+		}
+		logger.Error("payment failed", "payment", event.Payment.id, "status", event.Payment.Status(), "error", event.Err)
+	})
+}
+
+// Enhanced PaymentService with a repository and an event bus; the repository
+// is the embedded service's, so ExecuteBatch and Submit save to it too
+type EnhancedPaymentService struct {
+	PaymentService
+	events *EventBus
+}
+
+// NewEnhancedPaymentService wires notifier and logger as the first subscribers
+func NewEnhancedPaymentService(
+	processor PaymentProcessor,
+	notifier Notifier,
+	logger Logger,
+	repository PaymentRepository,
+) *EnhancedPaymentService {
+	events := NewEventBus()
+	SubscribeLogger(events, logger)
+	SubscribeNotifier(events, notifier)
+	return &EnhancedPaymentService{
+		PaymentService: PaymentService{processor: processor, notifier: notifier, repository: repository},
+		events:         events,
+	}
+}
+
+// Events lets other parts of the program react to payments
+func (s *EnhancedPaymentService) Events() *EventBus { return s.events }
+
+func (s *EnhancedPaymentService) ExecutePayment(ctx context.Context, payment *Payment) bool {
+	err := s.admit(ctx, payment)
+	var receipt Receipt
+	if err == nil {
+		receipt, err = s.process(ctx, payment)
+	}
+	if err != nil {
+		s.events.Publish(ctx, PaymentEvent{Kind: PaymentFailed, Payment: payment, Err: err})
+		return false
+	}
+	s.repository.SavePayment(context.WithoutCancel(ctx), payment) // charged: record it even if ctx has ended
+	s.events.Publish(ctx, PaymentEvent{Kind: PaymentSucceeded, Payment: payment, Receipt: receipt})
+	return true
+}
+
+// Decorators: each wraps a PaymentProcessor and is one, so they stack in any order
+
+// FeeProcessor fills in the fee breakdown of every receipt from its strategy
+type FeeProcessor struct {
+	next PaymentProcessor
+	fees FeeStrategy
+}
+
+func NewFeeProcessor(next PaymentProcessor, fees FeeStrategy) *FeeProcessor {
+	return &FeeProcessor{next: next, fees: fees}
+}
+
+func (p *FeeProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	receipt, err := p.next.ProcessPayment(ctx, payment)
+	if err != nil {
+		return receipt, err
+	}
+	receipt.Fees = ApplyFee(p.fees, receipt.Amount)
+	return receipt, nil
+}
+
+type LoggingProcessor struct {
+	next   PaymentProcessor
+	logger Logger
+}
+
+func NewLoggingProcessor(next PaymentProcessor, logger Logger) *LoggingProcessor {
+	return &LoggingProcessor{next: next, logger: logger}
+}
+
+func (p *LoggingProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	p.logger.Info("processing payment", "payment", payment.id, "amount", payment.amount.String())
+	receipt, err := p.next.ProcessPayment(ctx, payment)
+	if err != nil {
+		p.logger.Error("processor failed", "payment", payment.id, "error", err)
+	}
+	return receipt, err
+}
+
+type TimingProcessor struct {
+	next   PaymentProcessor
+	record func(id string, elapsed time.Duration)
+}
+
+func NewTimingProcessor(next PaymentProcessor, record func(id string, elapsed time.Duration)) *TimingProcessor {
+	return &TimingProcessor{next: next, record: record}
+}
+
+func (p *TimingProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	start := time.Now()
+	defer func() { p.record(payment.id, time.Since(start)) }()
+	return p.next.ProcessPayment(ctx, payment)
+}
+
+// ValidatingProcessor rejects bad payments before the wrapped processor sees them
+type ValidatingProcessor struct {
+	next   PaymentProcessor
+	reject func(err error)
+}
+
+func NewValidatingProcessor(next PaymentProcessor, reject func(err error)) *ValidatingProcessor {
+	return &ValidatingProcessor{next: next, reject: reject}
+}
+
+func (p *ValidatingProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	if err := validatePayment(payment); err != nil {
+		if p.reject != nil {
+			p.reject(err)
+		}
+		return Receipt{}, err
+	}
+	return p.next.ProcessPayment(ctx, payment)
+}
+
+// RetryPolicy: delay before attempt n+1 is BaseDelay*2^(n-1), capped at MaxDelay,
+// then shortened by up to Jitter (0..1) of itself so clients don't retry in lockstep
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64
+}
+
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if p.MaxDelay > 0 && (d > p.MaxDelay || d <= 0) {
+		d = p.MaxDelay
+	}
+	return d - time.Duration(p.Jitter*rand.Float64()*float64(d))
+}
+
+// RetryError is the final error after retrying; errors.Is still sees the cause
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// RetryingProcessor retries transient (ErrNetwork) failures; declines and
+// invalid currencies fail the same way every time, so they are returned at once
+type RetryingProcessor struct {
+	next   PaymentProcessor
+	policy RetryPolicy
+}
+
+func NewRetryingProcessor(next PaymentProcessor, policy RetryPolicy) *RetryingProcessor {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return &RetryingProcessor{next: next, policy: policy}
+}
+
+// ProcessPayment stops early when the next retry could not start before ctx's deadline
+func (p *RetryingProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	for attempt := 1; ; attempt++ {
+		receipt, err := p.next.ProcessPayment(ctx, payment)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ErrNetwork) || attempt == p.policy.MaxAttempts {
+			return Receipt{}, &RetryError{Attempts: attempt, Err: err}
+		}
+		wait := p.policy.delay(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return Receipt{}, &RetryError{Attempts: attempt, Err: fmt.Errorf("%w (%w before next retry)", err, context.DeadlineExceeded)}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Receipt{}, &RetryError{Attempts: attempt, Err: fmt.Errorf("%w (%w)", err, ctx.Err())}
+		case <-timer.C:
+		}
+	}
+}
+
+// Clock lets time-based behaviour run against a fake clock in demos and tests
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// ManualClock is safe for concurrent use, so a test may advance it while
+// the code under test reads it
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Circuit breaker: after too many network failures the processor is skipped
+// for a cool-down, then a single trial call decides whether it is back
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// ErrCircuitOpen wraps ErrNetwork: nothing was charged and a retry elsewhere is safe
+var ErrCircuitOpen = fmt.Errorf("%w: circuit open", ErrNetwork)
+
+type BreakerSettings struct {
+	Window      int     // calls considered when computing the failure rate
+	FailureRate float64 // open when failures/Window reaches this
+	CoolDown    time.Duration
+}
+
+type CircuitBreakerProcessor struct {
+	next     PaymentProcessor
+	settings BreakerSettings
+	clock    Clock
+
+	mu       sync.Mutex
+	state    BreakerState
+	recent   []bool // true = failed, newest last, at most Window long
+	openedAt time.Time
+	trial    bool // a half-open trial call is in flight
+}
+
+func NewCircuitBreakerProcessor(next PaymentProcessor, settings BreakerSettings, clock Clock) *CircuitBreakerProcessor {
+	if settings.Window < 1 {
+		settings.Window = 1
+	}
+	return &CircuitBreakerProcessor{next: next, settings: settings, clock: clock, state: BreakerClosed}
+}
+
+// State reports half-open once the cool-down has passed, even before the trial call
+func (b *CircuitBreakerProcessor) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState()
+}
+
+func (b *CircuitBreakerProcessor) currentState() BreakerState {
+	if b.state == BreakerOpen && b.clock.Now().Sub(b.openedAt) >= b.settings.CoolDown {
+		b.state = BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Healthy lets ProcessorRouter skip this processor while the circuit is open
+func (b *CircuitBreakerProcessor) Healthy() bool {
+	return b.State() != BreakerOpen
+}
+
+func (b *CircuitBreakerProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	b.mu.Lock()
+	switch b.currentState() {
+	case BreakerOpen:
+		b.mu.Unlock()
+		return Receipt{}, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.trial {
+			b.mu.Unlock()
+			return Receipt{}, ErrCircuitOpen
+		}
+		b.trial = true
+	}
+	b.mu.Unlock()
+
+	receipt, err := b.next.ProcessPayment(ctx, payment)
+	b.record(errors.Is(err, ErrNetwork)) // declines say nothing about the processor's health
+	return receipt, err
+}
+
+func (b *CircuitBreakerProcessor) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen {
+		b.trial = false
+		if failed {
+			b.state, b.openedAt = BreakerOpen, b.clock.Now()
+		} else {
+			b.state, b.recent = BreakerClosed, nil
+		}
+		return
+	}
+	b.recent = append(b.recent, failed)
+	if len(b.recent) > b.settings.Window {
+		b.recent = b.recent[1:]
+	}
+	failures := 0
+	for _, f := range b.recent {
+		if f {
+			failures++
+		}
+	}
+	if len(b.recent) == b.settings.Window && float64(failures)/float64(b.settings.Window) >= b.settings.FailureRate {
+		b.state, b.openedAt, b.recent = BreakerOpen, b.clock.Now(), nil
+	}
+}
+
+// Batch API: validate, deduplicate, process and persist many payments in one call
+var (
+	ErrInvalidPayment   = errors.New("invalid payment")
+	ErrDuplicatePayment = errors.New("duplicate payment id in batch")
+)
+
+// PaymentOutcome is the per-item result of a batch, in input order
+type PaymentOutcome struct {
+	Payment *Payment
+	Receipt Receipt // zero unless Err is nil
+	Err     error   // nil on success
+}
+
+// CurrencyTotal sums one currency's amounts; batches never add across currencies
+type CurrencyTotal struct {
+	Succeeded Money
+	Failed    Money
+}
+
+type BatchResult struct {
+	Outcomes  []PaymentOutcome
+	Succeeded int
+	Failed    int
+	Totals    map[string]CurrencyTotal // by currency
+}
+
+func (r BatchResult) total(currency string) CurrencyTotal {
+	if total, ok := r.Totals[currency]; ok {
+		return total
+	}
+	return CurrencyTotal{Succeeded: NewMoney(0, currency), Failed: NewMoney(0, currency)}
+}
+
+// WithBatchConcurrency lets ExecuteBatch process up to n payments at once
+func (s *PaymentService) WithBatchConcurrency(n int) *PaymentService {
+	s.batchConcurrency = n
+	return s
+}
+
+// Validation pipeline: every Validator checks one rule, and the pipeline runs
+// them all so the caller sees every violation at once
+type Validator interface {
+	Validate(payment *Payment) error
+}
+
+type ValidatorFunc func(payment *Payment) error
+
+func (f ValidatorFunc) Validate(payment *Payment) error { return f(payment) }
+
+var NonEmptyID = ValidatorFunc(func(payment *Payment) error {
+	if payment.id == "" {
+		return fmt.Errorf("%w: empty id", ErrInvalidPayment)
+	}
+	return nil
+})
+
+var PositiveAmount = ValidatorFunc(func(payment *Payment) error {
+	if !payment.amount.IsPositive() {
+		return fmt.Errorf("%w: %s amount must be positive", ErrInvalidPayment, payment.label())
+	}
+	return nil
+})
+
+// SupportedCurrencies rejects currencies outside the set
+func SupportedCurrencies(codes ...string) Validator {
+	supported := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		supported[code] = true
+	}
+	return ValidatorFunc(func(payment *Payment) error {
+		if !supported[payment.amount.Currency()] {
+			return fmt.Errorf("%w: %s has unsupported currency %q", ErrInvalidPayment, payment.label(), payment.amount.Currency())
+		}
+		return nil
+	})
+}
+
+// VelocityCheck allows at most Max payments per account within Window;
+// payments without an account are not limited
+type VelocityCheck struct {
+	max    int
+	window time.Duration
+	clock  Clock
+
+	mu   sync.Mutex
+	seen map[string][]time.Time
+}
+
+func NewVelocityCheck(max int, window time.Duration, clock Clock) *VelocityCheck {
+	return &VelocityCheck{max: max, window: window, clock: clock, seen: map[string][]time.Time{}}
+}
+
+func (v *VelocityCheck) Validate(payment *Payment) error {
+	if payment.account == "" {
+		return nil
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.clock.Now()
+	recent := v.seen[payment.account][:0]
+	for _, at := range v.seen[payment.account] {
+		if now.Sub(at) < v.window {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= v.max {
+		v.seen[payment.account] = recent
+		return fmt.Errorf("%w: %s exceeds %d payments per %v for %s", ErrInvalidPayment, payment.id, v.max, v.window, payment.account)
+	}
+	v.seen[payment.account] = append(recent, now)
+	return nil
+}
+
+// ValidationErrors is the multi-error a pipeline returns; errors.Is and
+// errors.As look through it to each violation
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error { return e }
+
+type ValidationPipeline []Validator
+
+func (p ValidationPipeline) Validate(payment *Payment) error {
+	if payment == nil {
+		return fmt.Errorf("%w: nil payment", ErrInvalidPayment)
+	}
+	var violations ValidationErrors
+	for _, validator := range p {
+		if err := validator.Validate(payment); err != nil {
+			violations = append(violations, err)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// DefaultValidation is what services use unless configured otherwise
+var DefaultValidation = ValidationPipeline{NonEmptyID, PositiveAmount, SupportedCurrencies("USD", "EUR", "GBP", "JPY")}
+
+func validatePayment(payment *Payment) error {
+	return DefaultValidation.Validate(payment)
+}
+
+// Fraud screening: a plugin asked before any money moves. Unlike validation,
+// it can also say "a human should look at this first".
+type FraudDecision int
+
+const (
+	FraudApprove FraudDecision = iota
+	FraudReview
+	FraudDecline
+)
+
+func (d FraudDecision) String() string {
+	return [...]string{"approve", "review", "decline"}[d]
+}
+
+var (
+	ErrFraudDeclined = errors.New("declined by fraud screening")
+	ErrHeldForReview = errors.New("held for manual fraud review")
+)
+
+type FraudResult struct {
+	Decision FraudDecision
+	Reasons  []string
+}
+
+type FraudScreener interface {
+	Screen(ctx context.Context, payment *Payment) FraudResult
+}
+
+// RulesScreener applies every rule and keeps the most severe decision
+type RulesScreener struct {
+	reviewOver       float64 // in the payment's currency; 0 disables
+	declineOver      float64
+	blockedCountries map[string]bool
+	velocity         *VelocityCheck // per payer; over the limit means review
+}
+
+func NewRulesScreener(reviewOver, declineOver float64, blockedCountries []string, velocity *VelocityCheck) *RulesScreener {
+	blocked := make(map[string]bool, len(blockedCountries))
+	for _, country := range blockedCountries {
+		blocked[country] = true
+	}
+	return &RulesScreener{reviewOver: reviewOver, declineOver: declineOver, blockedCountries: blocked, velocity: velocity}
+}
+
+func (r *RulesScreener) Screen(ctx context.Context, payment *Payment) FraudResult {
+	var result FraudResult
+	flag := func(decision FraudDecision, reason string) {
+		result.Reasons = append(result.Reasons, reason)
+		if decision > result.Decision {
+			result.Decision = decision
+		}
+	}
+	amount := payment.amount.Amount()
+	switch {
+	case r.declineOver > 0 && amount > r.declineOver:
+		flag(FraudDecline, fmt.Sprintf("amount %s over decline limit", payment.amount))
+	case r.reviewOver > 0 && amount > r.reviewOver:
+		flag(FraudReview, fmt.Sprintf("amount %s over review limit", payment.amount))
+	}
+	if r.blockedCountries[payment.country] {
+		flag(FraudDecline, "payer country "+payment.country+" is blocked")
+	}
+	if r.velocity != nil {
+		if err := r.velocity.Validate(payment); err != nil {
+			flag(FraudReview, "payer velocity: "+err.Error())
+		}
+	}
+	return result
+}
+
+// ExecuteBatch deduplicates and admits up front, processes the remaining
+// payments (concurrently when configured), persists successes, and sends one
+// summary notification instead of one per payment.
+func (s *PaymentService) ExecuteBatch(ctx context.Context, payments []*Payment) BatchResult {
+	outcomes := make([]PaymentOutcome, len(payments))
+	scratch := batchScratchPool.Get().(*batchScratch)
+	defer scratch.release()
+	seen := scratch.seen
+	pending := scratch.pending[:0] // indexes that passed validation and dedup
+	for i, payment := range payments {
+		outcomes[i].Payment = payment
+		if payment == nil {
+			outcomes[i].Err = fmt.Errorf("%w: nil payment", ErrInvalidPayment)
+			continue
+		}
+		if seen[payment.id] { // before admit, so a duplicate is not screened twice
+			outcomes[i].Err = fmt.Errorf("%w: %s", ErrDuplicatePayment, payment.id)
+			continue
+		}
+		seen[payment.id] = true // even if admit rejects it: its duplicates are still duplicates
+		if err := s.admit(ctx, payment); err != nil {
+			outcomes[i].Err = err
+			continue
+		}
+		pending = append(pending, i)
+	}
+	scratch.pending = pending // keep the grown buffer for the next batch
+
+	process := func(i int) {
+		if err := ctx.Err(); err != nil {
+			outcomes[i].Err = err
+			return
+		}
+		outcomes[i].Receipt, outcomes[i].Err = s.process(ctx, outcomes[i].Payment)
+	}
+
+	if s.batchConcurrency <= 1 {
+		for _, i := range pending {
+			process(i)
+		}
+	} else {
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < s.batchConcurrency; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					process(i) // each worker writes only its own outcomes[i]
+				}
+			}()
+		}
+		for _, i := range pending {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	result := BatchResult{Outcomes: outcomes, Totals: make(map[string]CurrencyTotal)}
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			result.Failed++
+			if outcome.Payment != nil && outcome.Payment.amount.IsPositive() {
+				total := result.total(outcome.Payment.amount.Currency())
+				total.Failed, _ = total.Failed.Add(outcome.Payment.amount) // same currency by construction
+				result.Totals[outcome.Payment.amount.Currency()] = total
+			}
+			continue
+		}
+		result.Succeeded++
+		total := result.total(outcome.Payment.amount.Currency())
+		total.Succeeded, _ = total.Succeeded.Add(outcome.Payment.amount)
+		result.Totals[outcome.Payment.amount.Currency()] = total
+		if s.repository != nil {
+			s.repository.SavePayment(context.WithoutCancel(ctx), outcome.Payment)
+		}
 	}
+	s.notifier.SendNotification(ctx, fmt.Sprintf("Batch finished: %d succeeded, %d failed", result.Succeeded, result.Failed))
+	return result
 }
 
-// 2. OCP: PaymentProcessor interface allows for extension
-type PaymentProcessor interface {
-	ProcessPayment(payment *Payment) bool
+// batchScratch is ExecuteBatch's working memory; none of it is returned, so
+// it goes back to a pool for the next batch instead of to the GC
+type batchScratch struct {
+	seen    map[string]bool
+	pending []int
 }
 
-// Credit card processor implementation
-type CreditCardProcessor struct{}
+var batchScratchPool = sync.Pool{New: func() any { return &batchScratch{seen: make(map[string]bool)} }}
 
-func (c *CreditCardProcessor) ProcessPayment(payment *Payment) bool {
-	fmt.Printf("Processing credit card payment: %s\n", payment.id)
-	return true
+func (b *batchScratch) release() {
+	clear(b.seen)
+	b.pending = b.pending[:0]
+	batchScratchPool.Put(b)
 }
 
-// PayPal processor implementation
-type PayPalProcessor struct{}
+// Settlement: a high-volume run through ExecuteBatch that keeps only the
+// totals. It feeds the batch processor in chunks whose payments come from a
+// sync.Pool and go back once the chunk is tallied. That is only safe because
+// its service has no repository: one would keep the pointers. Receipts are
+// returned by value, so there is nothing to pool.
+type ChargeRequest struct {
+	ID       string
+	Amount   float64
+	Currency string
+}
 
-func (p *PayPalProcessor) ProcessPayment(payment *Payment) bool {
-	fmt.Printf("Processing PayPal payment: %s\n", payment.id)
-	return true
+type SettlementResult struct {
+	Settled int
+	Failed  int
+	Net     map[string]Money // by currency, after fees
 }
 
-// 3. LSP: RefundProcessor interface
-type RefundProcessor interface {
-	ProcessRefund(payment *Payment) bool
+var paymentPool = sync.Pool{New: func() any { return new(Payment) }}
+
+// acquirePayment is NewPayment from the pool; the history buffer is reused
+func acquirePayment(id string, amount float64, currency string) *Payment {
+	p := paymentPool.Get().(*Payment)
+	*p = Payment{id: id, amount: NewMoney(amount, currency), state: pendingState{}, history: append(p.history[:0], StatusPending)}
+	return p
 }
 
-type CreditCardRefundProcessor struct{}
+// releasePayment zeroes p first so nothing leaks into the next run
+func releasePayment(p *Payment) {
+	*p = Payment{history: p.history[:0]}
+	paymentPool.Put(p)
+}
 
-func (c *CreditCardRefundProcessor) ProcessRefund(payment *Payment) bool {
-	fmt.Printf("Processing credit card refund: %s\n", payment.id)
-	return true
+type SettlementRun struct {
+	service *PaymentService
+	pooled  bool
 }
 
-// 4. ISP: Separate interfaces for different responsibilities
-type Notifier interface {
-	SendNotification(message string)
+// settlementChunk payments are in flight at a time, so a pooled run reuses
+// about that many Payments however long it is
+const settlementChunk = 256
+
+// discardNotifier drops the per-chunk batch summaries
+type discardNotifier struct{}
+
+func (discardNotifier) SendNotification(ctx context.Context, message string) {}
+
+// NewSettlementRun with pooled false allocates a Payment per request, for comparison
+func NewSettlementRun(processor PaymentProcessor, pooled bool) *SettlementRun {
+	return &SettlementRun{service: NewPaymentService(processor, discardNotifier{}), pooled: pooled}
 }
 
-type EmailNotifier struct{}
+func (r *SettlementRun) Run(ctx context.Context, requests []ChargeRequest) SettlementResult {
+	result := SettlementResult{Net: make(map[string]Money)}
+	chunk := make([]*Payment, 0, settlementChunk)
+	for len(requests) > 0 {
+		n := min(len(requests), settlementChunk)
+		chunk = chunk[:0]
+		for _, request := range requests[:n] {
+			if r.pooled {
+				chunk = append(chunk, acquirePayment(request.ID, request.Amount, request.Currency))
+			} else {
+				chunk = append(chunk, NewPayment(request.ID, request.Amount, request.Currency))
+			}
+		}
+		requests = requests[n:]
 
-func (e *EmailNotifier) SendNotification(message string) {
-	fmt.Printf("Sending email: %s\n", message)
+		batch := r.service.ExecuteBatch(ctx, chunk)
+		result.Settled += batch.Succeeded
+		result.Failed += batch.Failed
+		for _, outcome := range batch.Outcomes {
+			if outcome.Err != nil {
+				continue
+			}
+			net, ok := result.Net[outcome.Receipt.Fees.Net.currency]
+			if !ok {
+				net = Money{currency: outcome.Receipt.Fees.Net.currency}
+			}
+			result.Net[net.currency], _ = net.Add(outcome.Receipt.Fees.Net)
+		}
+		if r.pooled {
+			for _, payment := range chunk {
+				releasePayment(payment) // nothing may hold payment past this point
+			}
+		}
+	}
+	return result
 }
 
-type SMSNotifier struct{}
+// Transactional outbox: the payment and the message announcing it are saved
+// in one unit of work, and a dispatcher delivers messages afterwards. A crash
+// or a notifier outage between "saved" and "sent" delays a notification but
+// can no longer lose it (delivery is at-least-once).
+type OutboxMessage struct {
+	ID        int
+	PaymentID string
+	Kind      PaymentEventKind
+	Body      string
+	Attempts  int
+	LastError string
+}
 
-func (s *SMSNotifier) SendNotification(message string) {
-	fmt.Printf("Sending SMS: %s\n", message)
+// OutboxStore keeps payments and pending messages under one lock, the
+// in-memory stand-in for a database transaction across two tables
+type OutboxStore struct {
+	mu       sync.Mutex
+	payments map[string]*Payment
+	pending  []OutboxMessage
+	nextID   int
 }
 
-// Logger interface - following ISP
-type Logger interface {
-	LogInfo(message string)
-	LogError(message string)
+func NewOutboxStore() *OutboxStore {
+	return &OutboxStore{payments: make(map[string]*Payment)}
 }
 
-type FileLogger struct{}
+func (o *OutboxStore) SavePayment(ctx context.Context, payment *Payment) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.payments[payment.id] = payment
+}
 
-func (f *FileLogger) LogInfo(message string) {
-	fmt.Printf("INFO: %s\n", message)
+func (o *OutboxStore) FindPaymentByID(ctx context.Context, id string) *Payment {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.payments[id]
 }
 
-func (f *FileLogger) LogError(message string) {
-	fmt.Printf("ERROR: %s\n", message)
+// SaveWithMessage is the unit of work: both writes happen or neither does
+func (o *OutboxStore) SaveWithMessage(payment *Payment, kind PaymentEventKind, body string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.payments[payment.id] = payment
+	o.nextID++
+	o.pending = append(o.pending, OutboxMessage{ID: o.nextID, PaymentID: payment.id, Kind: kind, Body: body})
 }
 
-// Repository interface - following DIP
-type PaymentRepository interface {
-	SavePayment(payment *Payment)
-	FindPaymentByID(id string) *Payment
+func (o *OutboxStore) Pending() []OutboxMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]OutboxMessage(nil), o.pending...)
 }
 
-// 5. DIP: PaymentService depends on abstractions
-type PaymentService struct {
-	processor  PaymentProcessor
-	notifier   Notifier
-	logger     Logger
-	repository PaymentRepository
+func (o *OutboxStore) markDelivered(id int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, msg := range o.pending {
+		if msg.ID == id {
+			o.pending = append(o.pending[:i:i], o.pending[i+1:]...)
+			return
+		}
+	}
 }
 
-// Constructor function for PaymentService
-func NewPaymentService(processor PaymentProcessor, notifier Notifier) *PaymentService {
-	return &PaymentService{
-		processor: processor,
-		notifier:  notifier,
+func (o *OutboxStore) markFailed(id int, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := range o.pending {
+		if o.pending[i].ID == id {
+			o.pending[i].Attempts++
+			o.pending[i].LastError = err.Error()
+		}
 	}
 }
 
-// ExecutePayment method
-func (s *PaymentService) ExecutePayment(payment *Payment) bool {
-	success := s.processor.ProcessPayment(payment)
+// OutboxPaymentService never notifies inline; it only records what to send
+type OutboxPaymentService struct {
+	PaymentService
+	store *OutboxStore
+}
 
-	if success {
-		s.notifier.SendNotification("Payment successful: " + payment.id)
-	} else {
-		s.notifier.SendNotification("Payment failed: " + payment.id)
+func NewOutboxPaymentService(processor PaymentProcessor, store *OutboxStore) *OutboxPaymentService {
+	return &OutboxPaymentService{PaymentService: PaymentService{processor: processor, repository: store}, store: store}
+}
+
+func (s *OutboxPaymentService) ExecutePayment(ctx context.Context, payment *Payment) bool {
+	err := s.admit(ctx, payment)
+	if err == nil {
+		_, err = s.process(ctx, payment)
 	}
+	if err != nil {
+		s.store.SaveWithMessage(payment, PaymentFailed, "Payment failed: "+payment.id)
+		return false
+	}
+	s.store.SaveWithMessage(payment, PaymentSucceeded, "Payment successful: "+payment.id)
+	return true
+}
 
-	return success
+// OutboxSender delivers one message; an error leaves it pending for the next pass
+type OutboxSender func(ctx context.Context, msg OutboxMessage) error
+
+// NotifierSender adapts a Notifier, which cannot report failure, to OutboxSender
+func NotifierSender(notifier Notifier) OutboxSender {
+	return func(ctx context.Context, msg OutboxMessage) error {
+		notifier.SendNotification(ctx, msg.Body)
+		return nil
+	}
 }
 
-// Enhanced PaymentService with logging and repository
-type EnhancedPaymentService struct {
-	PaymentService
-	logger     Logger
-	repository PaymentRepository
+type OutboxDispatcher struct {
+	store    *OutboxStore
+	send     OutboxSender
+	interval time.Duration
+	done     chan struct{}
 }
 
-func NewEnhancedPaymentService(
-	processor PaymentProcessor,
-	notifier Notifier,
-	logger Logger,
-	repository PaymentRepository,
-) *EnhancedPaymentService {
-	return &EnhancedPaymentService{
-		PaymentService: PaymentService{processor: processor, notifier: notifier},
-		logger:         logger,
-		repository:     repository,
+func NewOutboxDispatcher(store *OutboxStore, send OutboxSender, interval time.Duration) *OutboxDispatcher {
+	return &OutboxDispatcher{store: store, send: send, interval: interval}
+}
+
+// DispatchOnce sends every pending message in order and reports how many went out
+func (d *OutboxDispatcher) DispatchOnce(ctx context.Context) int {
+	delivered := 0
+	for _, msg := range d.store.Pending() {
+		if err := d.send(ctx, msg); err != nil {
+			d.store.markFailed(msg.ID, err)
+			continue
+		}
+		d.store.markDelivered(msg.ID)
+		delivered++
 	}
+	return delivered
+}
+
+// Start polls until ctx ends, then makes one last pass; Wait blocks until it has
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	d.done = make(chan struct{})
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				d.DispatchOnce(context.WithoutCancel(ctx)) // the final flush must not be canceled too
+				return
+			case <-ticker.C:
+				d.DispatchOnce(ctx)
+			}
+		}
+	}()
+}
+
+func (d *OutboxDispatcher) Wait() { <-d.done }
+
+// Metrics: services are instrumented from the outside with a decorator, just
+// like processors are; any Metrics backend can be plugged in
+type Metrics interface {
+	Inc(name string)
+	Observe(name string, value float64)
 }
 
-func (s *EnhancedPaymentService) ExecutePayment(payment *Payment) bool {
-	s.logger.LogInfo("Processing payment: " + payment.id)
+// PaymentExecutor is what every payment service offers its callers
+type PaymentExecutor interface {
+	ExecutePayment(ctx context.Context, payment *Payment) bool
+}
+
+type InstrumentedPaymentService struct {
+	next    PaymentExecutor
+	metrics Metrics
+}
 
-	success := s.processor.ProcessPayment(payment)
+func NewInstrumentedPaymentService(next PaymentExecutor, metrics Metrics) *InstrumentedPaymentService {
+	return &InstrumentedPaymentService{next: next, metrics: metrics}
+}
 
+func (s *InstrumentedPaymentService) ExecutePayment(ctx context.Context, payment *Payment) bool {
+	start := time.Now()
+	success := s.next.ExecutePayment(ctx, payment)
+	s.metrics.Observe("payment_duration_ms", float64(time.Since(start).Microseconds())/1000)
 	if success {
-		s.repository.SavePayment(payment)
-		s.notifier.SendNotification("Payment successful: " + payment.id)
-		s.logger.LogInfo("Payment completed: " + payment.id)
+		s.metrics.Inc("payments_succeeded_total")
 	} else {
-		s.logger.LogError("Payment failed: " + payment.id)
-		s.notifier.SendNotification("Payment failed: " + payment.id)
+		s.metrics.Inc("payments_failed_total")
 	}
-
 	return success
 }
 
+// LatencyBuckets are the histogram upper bounds in milliseconds
+var LatencyBuckets = []float64{1, 5, 25, 100, 500}
+
+type histogram struct {
+	counts []int // one per bucket, plus +Inf at the end
+	sum    float64
+	count  int
+}
+
+// InMemoryMetrics is a metrics sink for demos and tests
+type InMemoryMetrics struct {
+	mu         sync.Mutex
+	counters   map[string]int
+	histograms map[string]*histogram
+}
+
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{counters: map[string]int{}, histograms: map[string]*histogram{}}
+}
+
+func (m *InMemoryMetrics) Inc(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name]++
+}
+
+func (m *InMemoryMetrics) Observe(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.histograms[name]
+	if !ok {
+		h = &histogram{counts: make([]int, len(LatencyBuckets)+1)}
+		m.histograms[name] = h
+	}
+	bucket := sort.SearchFloat64s(LatencyBuckets, value) // first bound >= value
+	h.counts[bucket]++
+	h.sum += value
+	h.count++
+}
+
+func (m *InMemoryMetrics) Counter(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+// Print writes counters, then histograms as cumulative "le" buckets, sorted by name
+func (m *InMemoryMetrics) Print(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.counters))
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s %d\n", name, m.counters[name])
+	}
+	names = names[:0]
+	for name := range m.histograms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h := m.histograms[name]
+		cumulative := 0
+		for i, bound := range LatencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{le=%g} %d\n", name, bound, cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{le=+Inf} %d\n", name, h.count)
+		fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+	}
+}
+
+// Async API: Submit queues a payment on a bounded worker pool. Payments of one
+// account always land on the same worker, so they are processed in the order
+// they were submitted; different accounts run concurrently.
+var ErrServiceClosed = errors.New("payment service is shut down")
+
+type submission struct {
+	ctx     context.Context
+	payment *Payment
+	result  chan PaymentOutcome
+}
+
+type workerPool struct {
+	mu     sync.RWMutex // Submit holds it for reading while enqueuing; Shutdown for writing
+	closed bool
+	queues []chan submission
+	wg     sync.WaitGroup
+}
+
+// WithWorkers starts n workers, each with a queue of queueSize; Submit blocks
+// while the chosen worker's queue is full
+func (s *PaymentService) WithWorkers(n, queueSize int) *PaymentService {
+	if n < 1 {
+		n = 1
+	}
+	pool := &workerPool{queues: make([]chan submission, n)}
+	for i := range pool.queues {
+		queue := make(chan submission, queueSize)
+		pool.queues[i] = queue
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			for sub := range queue {
+				sub.result <- s.processAsync(sub.ctx, sub.payment)
+				close(sub.result)
+			}
+		}()
+	}
+	s.pool = pool
+	return s
+}
+
+func (s *PaymentService) processAsync(ctx context.Context, payment *Payment) PaymentOutcome {
+	err := s.admit(ctx, payment)
+	var receipt Receipt
+	if err == nil {
+		receipt, err = s.process(ctx, payment)
+	}
+	if err == nil && s.repository != nil {
+		s.repository.SavePayment(context.WithoutCancel(ctx), payment)
+	}
+	s.notifyPayment(ctx, payment, err == nil)
+	return PaymentOutcome{Payment: payment, Receipt: receipt, Err: err}
+}
+
+// Submit returns at once; the outcome arrives on the channel, which is then closed
+func (s *PaymentService) Submit(ctx context.Context, payment *Payment) <-chan PaymentOutcome {
+	result := make(chan PaymentOutcome, 1)
+	if s.pool == nil {
+		result <- s.processAsync(ctx, payment)
+		close(result)
+		return result
+	}
+	s.pool.mu.RLock()
+	defer s.pool.mu.RUnlock()
+	if s.pool.closed {
+		result <- PaymentOutcome{Payment: payment, Err: fmt.Errorf("%w: %s", ErrServiceClosed, payment.id)}
+		close(result)
+		return result
+	}
+	key := payment.account
+	if key == "" {
+		key = payment.id
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	select {
+	case s.pool.queues[h.Sum32()%uint32(len(s.pool.queues))] <- submission{ctx: ctx, payment: payment, result: result}:
+	case <-ctx.Done(): // gave up waiting for room in the queue
+		result <- PaymentOutcome{Payment: payment, Err: fmt.Errorf("%s: %w", payment.id, ctx.Err())}
+		close(result)
+	}
+	return result
+}
+
+// Shutdown stops accepting payments and waits for queued ones to finish,
+// or for ctx to end, whichever comes first
+func (s *PaymentService) Shutdown(ctx context.Context) error {
+	if s.pool == nil {
+		return nil
+	}
+	s.pool.mu.Lock()
+	if !s.pool.closed {
+		s.pool.closed = true
+		for _, queue := range s.pool.queues {
+			close(queue)
+		}
+	}
+	s.pool.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.pool.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("shutdown: %w", ctx.Err())
+	}
+}
+
+// Container is a minimal DI container: constructors are registered against
+// the interface they provide and resolved lazily, once, on first use. It is
+// meant for wiring at startup and is not safe for concurrent use.
+type Container struct {
+	providers map[reflect.Type]func(*Container) (any, error)
+	instances map[reflect.Type]any
+	resolving []reflect.Type // the current resolution path, for cycle detection
+}
+
+var (
+	ErrNotRegistered   = errors.New("dependency not registered")
+	ErrDependencyCycle = errors.New("dependency cycle")
+)
+
+func NewContainer() *Container {
+	return &Container{
+		providers: make(map[reflect.Type]func(*Container) (any, error)),
+		instances: make(map[reflect.Type]any),
+	}
+}
+
+// Provide registers the constructor for T, usually an interface type. The
+// constructor resolves its own dependencies from the container it is given.
+// Like RegisterProcessor, providing the same type twice panics.
+func Provide[T any](c *Container, constructor func(c *Container) (T, error)) {
+	key := reflect.TypeFor[T]()
+	if _, dup := c.providers[key]; dup {
+		panic("container: Provide called twice for " + key.String())
+	}
+	c.providers[key] = func(c *Container) (any, error) { return constructor(c) }
+}
+
+// Resolve returns the single instance of T, building it and its dependencies first
+func Resolve[T any](c *Container) (T, error) {
+	var zero T
+	key := reflect.TypeFor[T]()
+	if instance, ok := c.instances[key]; ok {
+		return instance.(T), nil
+	}
+	for i, pending := range c.resolving {
+		if pending == key {
+			return zero, fmt.Errorf("%w: %s", ErrDependencyCycle, c.path(c.resolving[i:], key))
+		}
+	}
+	provider, ok := c.providers[key]
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrNotRegistered, c.path(c.resolving, key))
+	}
+	c.resolving = append(c.resolving, key)
+	instance, err := provider(c)
+	c.resolving = c.resolving[:len(c.resolving)-1]
+	if err != nil {
+		return zero, err
+	}
+	c.instances[key] = instance
+	return instance.(T), nil
+}
+
+func (c *Container) path(types []reflect.Type, last reflect.Type) string {
+	names := make([]string, 0, len(types)+1)
+	for _, t := range types {
+		names = append(names, t.String())
+	}
+	return strings.Join(append(names, last.String()), " -> ")
+}
+
+// Subscription billing: a BillingEngine turns subscriptions into recurring
+// payments through an ordinary PaymentService, driven by an injected Clock
+type BillingCycle int
+
+const (
+	Weekly BillingCycle = iota
+	Monthly
+	Yearly
+)
+
+func (c BillingCycle) String() string {
+	return [...]string{"weekly", "monthly", "yearly"}[c]
+}
+
+// PeriodStart is the start of period n of a subscription that began at
+// anchor. Months are clamped to their last day, so a subscription started on
+// Jan 31 renews on Feb 29 and Mar 31 rather than drifting to Mar 2.
+func (c BillingCycle) PeriodStart(anchor time.Time, n int) time.Time {
+	switch c {
+	case Weekly:
+		return anchor.AddDate(0, 0, 7*n)
+	case Yearly:
+		return addMonths(anchor, 12*n)
+	}
+	return addMonths(anchor, n)
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// SubscriptionStatus is the dunning state: a failed renewal makes the
+// subscription PastDue while it is retried, and Canceled once retries run out
+type SubscriptionStatus string
+
+const (
+	SubscriptionActive   SubscriptionStatus = "Active"
+	SubscriptionPastDue  SubscriptionStatus = "PastDue"
+	SubscriptionCanceled SubscriptionStatus = "Canceled"
+)
+
+const (
+	RenewalSucceeded PaymentEventKind = "RenewalSucceeded"
+	RenewalFailed    PaymentEventKind = "RenewalFailed"    // will be retried
+	RenewalAbandoned PaymentEventKind = "RenewalAbandoned" // retries exhausted, subscription canceled
+)
+
+type Subscription struct {
+	id          string
+	account     string
+	price       Money
+	cycle       BillingCycle
+	anchor      time.Time // when the subscription started; periods count from here
+	periodStart time.Time // start of the period being billed
+	nextAttempt time.Time
+	status      SubscriptionStatus
+	failures    int // failed attempts for the current period
+	periods     int // periods paid so far
+}
+
+// NewSubscription bills its first period at start
+func NewSubscription(id, account string, price float64, currency string, cycle BillingCycle, start time.Time) *Subscription {
+	return &Subscription{
+		id: id, account: account, price: NewMoney(price, currency), cycle: cycle,
+		anchor: start, periodStart: start, nextAttempt: start, status: SubscriptionActive,
+	}
+}
+
+func (s *Subscription) ID() string                 { return s.id }
+func (s *Subscription) Status() SubscriptionStatus { return s.status }
+func (s *Subscription) PaidPeriods() int           { return s.periods }
+
+// payment is a fresh Payment per attempt, so a declined one stays Failed in history
+func (s *Subscription) payment() *Payment {
+	id := fmt.Sprintf("%s-%s", s.id, s.periodStart.Format("2006-01-02"))
+	if s.failures > 0 {
+		id = fmt.Sprintf("%s-retry%d", id, s.failures)
+	}
+	return &Payment{id: id, amount: s.price, account: s.account, state: pendingState{}, history: []PaymentStatus{StatusPending}}
+}
+
+type BillingEngine struct {
+	mu            sync.Mutex
+	service       *PaymentService
+	clock         Clock
+	events        *EventBus
+	retrySchedule []time.Duration // delay before each retry; its length is the retry budget
+	subscriptions []*Subscription
+}
+
+func NewBillingEngine(service *PaymentService, clock Clock, events *EventBus, retrySchedule ...time.Duration) *BillingEngine {
+	return &BillingEngine{service: service, clock: clock, events: events, retrySchedule: retrySchedule}
+}
+
+func (e *BillingEngine) Add(subscription *Subscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.subscriptions = append(e.subscriptions, subscription)
+}
+
+// RunDue charges every subscription whose next attempt is due, catching up
+// on missed periods one at a time; call it from a scheduler or a ticker
+func (e *BillingEngine) RunDue(ctx context.Context) []PaymentOutcome {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var outcomes []PaymentOutcome
+	for _, sub := range e.subscriptions {
+		for sub.status != SubscriptionCanceled && !sub.nextAttempt.After(e.clock.Now()) {
+			if ctx.Err() != nil {
+				return outcomes
+			}
+			outcome := e.bill(ctx, sub)
+			outcomes = append(outcomes, outcome)
+			if outcome.Err != nil {
+				break
+			}
+		}
+	}
+	return outcomes
+}
+
+func (e *BillingEngine) bill(ctx context.Context, sub *Subscription) PaymentOutcome {
+	payment := sub.payment()
+	receipt, err := e.service.Charge(ctx, payment)
+	event := PaymentEvent{Payment: payment, Receipt: receipt, Err: err, Subscription: sub}
+	switch {
+	case err == nil:
+		sub.periods++
+		sub.failures = 0
+		sub.status = SubscriptionActive
+		sub.periodStart = sub.cycle.PeriodStart(sub.anchor, sub.periods)
+		sub.nextAttempt = sub.periodStart
+		event.Kind = RenewalSucceeded
+	case sub.failures < len(e.retrySchedule):
+		sub.nextAttempt = e.clock.Now().Add(e.retrySchedule[sub.failures])
+		sub.failures++
+		sub.status = SubscriptionPastDue
+		event.Kind = RenewalFailed
+	default:
+		sub.status = SubscriptionCanceled
+		event.Kind = RenewalAbandoned
+	}
+	if e.events != nil {
+		e.events.Publish(ctx, event)
+	}
+	return PaymentOutcome{Payment: payment, Receipt: receipt, Err: err}
+}
+
 func main() {
+	ctx := context.Background()
+
 	// Create payment
 	payment := NewPayment("PAY-001", 100.0, "USD")
 
@@ -168,7 +3406,7 @@ func main() {
 	paymentService := NewPaymentService(creditCardProcessor, emailNotifier)
 
 	// Execute payment
-	result := paymentService.ExecutePayment(payment)
+	result := paymentService.ExecutePayment(ctx, payment)
 	fmt.Printf("Payment result: %v\n", result)
 
 	// Demonstrate OCP - switch to PayPal
@@ -176,9 +3414,572 @@ func main() {
 	smsNotifier := &SMSNotifier{}
 
 	paypalService := NewPaymentService(paypalProcessor, smsNotifier)
-	paypalService.ExecutePayment(payment)
+	paypalService.ExecutePayment(ctx, NewPayment("PAY-002", 60.0, "USD"))
+
+	// State - a captured payment cannot be charged again
+	paypalService.ExecutePayment(ctx, payment)
+
+	// Adapter - the legacy gateway plugs into the same service
+	legacyService := NewPaymentService(NewOldBankGatewayAdapter(&OldBankGateway{declineOverCents: 50000}), emailNotifier)
+	legacyService.ExecutePayment(ctx, NewPayment("PAY-301", 19.99, "USD"))
+	declined := NewPayment("PAY-302", 999.00, "USD")
+	legacyService.ExecutePayment(ctx, declined)
+	if err := legacyService.WithRefunds(&CreditCardRefundProcessor{}).ExecuteRefund(ctx, declined); errors.Is(err, ErrInvalidTransition) {
+		fmt.Printf("%v (lifecycle %v)\n", err, declined.History())
+	}
+	legacyService.ExecutePayment(ctx, NewPayment("PAY-303", 10.00, "EUR"))
+
+	// Error categories - a network error keeps the payment Authorized so it can be retried
+	flaky := &OldBankGateway{offline: true}
+	flakyService := NewPaymentService(NewOldBankGatewayAdapter(flaky), emailNotifier)
+	retryable := NewPayment("PAY-304", 25.00, "USD")
+	flakyService.ExecutePayment(ctx, retryable)
+	fmt.Printf("PAY-304 after outage: %s\n", retryable.Status())
+	flaky.offline = false
+	flakyService.ExecutePayment(ctx, retryable)
+	if _, err := paypalProcessor.ProcessPayment(ctx, NewPayment("PAY-305", 5.00, "JPY")); errors.Is(err, ErrInvalidCurrency) {
+		fmt.Println("PayPal:", err)
+	}
+
+	// Registry - pick the processor by name at runtime
+	fmt.Printf("Registered processors: %v\n", ProcessorNames())
+	for i, method := range []string{"paypal", "old_bank", "bitcoin"} {
+		if _, err := paymentService.ExecutePaymentVia(ctx, method, NewPayment(fmt.Sprintf("PAY-50%d", i+1), 30, "USD")); err != nil {
+			fmt.Println("error:", err)
+		}
+	}
+
+	// Fee strategies - attached to each registration, reported on the receipt
+	registeredCard, _ := NewProcessor("credit_card")
+	feeService := NewPaymentService(registeredCard, emailNotifier)
+	for i, amount := range []float64{40, 250} {
+		if receipt, err := feeService.Charge(ctx, NewPayment(fmt.Sprintf("PAY-50%d", i+4), amount, "USD")); err == nil {
+			fmt.Printf("  payout for %s: %s\n", receipt.PaymentID, receipt.Fees.Net)
+		}
+	}
+	fmt.Println("  0.10 USD with a 0.25 flat fee:", ApplyFee(FlatFee{Amount: 0.25}, NewMoney(0.10, "USD")))
+
+	// Validation - every violation is reported at once, and each is still an ErrInvalidPayment
+	var violations ValidationErrors
+	if err := validatePayment(NewPayment("", -5, "XXX")); errors.As(err, &violations) {
+		fmt.Printf("%d violations (invalid: %v): %v\n", len(violations), errors.Is(err, ErrInvalidPayment), err)
+	}
+	velocityClock := &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	velocityService := NewPaymentService(creditCardProcessor, emailNotifier).
+		WithValidation(append(DefaultValidation, NewVelocityCheck(2, time.Minute, velocityClock))...)
+	for i := 1; i <= 3; i++ {
+		velocityService.ExecutePayment(ctx, NewPayment(fmt.Sprintf("PAY-81%d", i), 5, "USD").ForAccount("mallory"))
+	}
+	velocityClock.Advance(time.Minute)
+	velocityService.ExecutePayment(ctx, NewPayment("PAY-814", 5, "USD").ForAccount("mallory"))
+
+	// Fraud screening - the service acts on approve / review / decline
+	fraudClock := &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	screened := NewPaymentService(creditCardProcessor, emailNotifier).
+		WithFraudScreener(NewRulesScreener(1000, 5000, []string{"KP"}, NewVelocityCheck(2, time.Hour, fraudClock)))
+	for _, p := range []*Payment{
+		NewPayment("PAY-821", 50, "USD").ForAccount("trent").FromCountry("DE"),
+		NewPayment("PAY-822", 2500, "USD").ForAccount("trent").FromCountry("DE"),
+		NewPayment("PAY-823", 75, "USD").ForAccount("trent").FromCountry("DE"),
+		NewPayment("PAY-824", 20, "USD").ForAccount("oscar").FromCountry("KP"),
+		NewPayment("PAY-825", 9000, "USD").ForAccount("peggy").FromCountry("FR"),
+	} {
+		screened.ExecutePayment(ctx, p)
+		fmt.Printf("  %s -> %s\n", p.id, p.Status())
+	}
+
+	// Routing - one service, the strategy picks a processor per payment
+	routeBank := &OldBankGateway{outages: 1}
+	routes := []Route{
+		{Name: "old_bank", Processor: NewOldBankGatewayAdapter(routeBank), Currencies: []string{"USD"}, MaxAmount: 500},
+		{Name: "paypal", Currencies: []string{"USD", "EUR", "GBP"}},
+		{Name: "credit_card"},
+	}
+	routeClock := &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	for _, strategy := range []RoutingStrategy{FirstMatchStrategy{}, LowestFeeStrategy{}} {
+		router, err := NewProcessorRouter(strategy, routes...)
+		if err != nil {
+			fmt.Println("error:", err)
+			continue
+		}
+		router.WithRecovery(time.Minute, routeClock)
+		fmt.Printf("Routing with %T:\n", strategy)
+		routedService := NewPaymentService(router, emailNotifier)
+		first := NewPayment("PAY-801", 20, "USD")
+		if !routedService.ExecutePayment(ctx, first) && first.Status() == StatusAuthorized {
+			routedService.ExecutePayment(ctx, first) // old_bank is now out of rotation
+		}
+		routedService.ExecutePayment(ctx, NewPayment("PAY-802", 900, "USD"))
+		routedService.ExecutePayment(ctx, NewPayment("PAY-803", 20, "JPY"))
+		routeClock.Advance(time.Minute)
+		if receipt, err := router.ProcessPayment(ctx, NewPayment("PAY-804", 20, "USD")); err == nil {
+			fmt.Printf("  a minute later PAY-804 goes to %s, fee %s\n", receipt.Processor, receipt.Fees.Fee)
+		}
+	}
+	if _, err := NewProcessorRouter(FirstMatchStrategy{}, Route{Name: "bitcoin"}); err != nil {
+		fmt.Println("error:", err)
+	}
+
+	// Circuit breaker - driven by a manual clock so the cool-down is instant
+	breakerClock := &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	flappingBank := &OldBankGateway{outages: 3}
+	breaker := NewCircuitBreakerProcessor(NewOldBankGatewayAdapter(flappingBank),
+		BreakerSettings{Window: 4, FailureRate: 0.5, CoolDown: 30 * time.Second}, breakerClock)
+	for i := 1; i <= 5; i++ {
+		_, err := breaker.ProcessPayment(ctx, NewPayment(fmt.Sprintf("PAY-85%d", i), 10, "USD"))
+		fmt.Printf("  call %d: breaker %s, err: %v\n", i, breaker.State(), err)
+	}
+	breakerRouter, err := NewProcessorRouter(FirstMatchStrategy{},
+		Route{Name: "old_bank", Processor: breaker, Currencies: []string{"USD"}},
+		Route{Name: "credit_card"})
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	breakerService := NewPaymentService(breakerRouter, emailNotifier)
+	breakerService.ExecutePayment(ctx, NewPayment("PAY-856", 10, "USD")) // old_bank skipped while open
+	breakerClock.Advance(31 * time.Second)
+	fmt.Printf("  after cool-down: breaker %s\n", breaker.State())
+	breakerService.ExecutePayment(ctx, NewPayment("PAY-857", 10, "USD")) // trial call succeeds
+	fmt.Printf("  after trial: breaker %s\n", breaker.State())
+
+	// HTTP gateway - real JSON over a local server, with a client timeout
+	gateway := httptest.NewServer(NewMockGateway())
+	httpProcessor := NewHTTPGatewayProcessor(gateway.URL, 100*time.Millisecond)
+	httpService := NewPaymentService(httpProcessor, emailNotifier).WithRefunds(httpProcessor)
+	for _, p := range []*Payment{
+		NewPayment("PAY-861", 20.00, "USD"),
+		NewPayment("PAY-862", 20.02, "USD"),
+		NewPayment("PAY-863", 20.05, "USD"),
+		NewPayment("PAY-864", 20.07, "USD"),
+		NewPayment("PAY-865", 20.00, "GBP"),
+	} {
+		httpService.ExecutePayment(ctx, p)
+	}
+	gateway.CloseClientConnections()
+	gateway.Close()
+
+	// Decorators - same PaymentService code, processor wrapped in a chain
+	logger := NewConsoleLogger(os.Stdout, LevelInfo)
+	decorated := NewValidatingProcessor(
+		NewLoggingProcessor(
+			NewTimingProcessor(creditCardProcessor, func(id string, elapsed time.Duration) {
+				fmt.Printf("Timing: %s took %v\n", id, elapsed.Round(time.Microsecond))
+			}),
+			logger),
+		func(err error) { logger.Error("payment rejected", "error", err) })
+	decoratedService := NewPaymentService(decorated, emailNotifier)
+	decoratedService.ExecutePayment(ctx, NewPayment("PAY-401", 12.0, "USD"))
+	// the service validates first; the decorator guards callers that use the processor directly
+	decorated.ProcessPayment(ctx, NewPayment("PAY-402", 0, "USD"))
+
+	// Context - a stage cancels the request; the slow stage after it stops at
+	// once, nothing is captured, saved or announced as a success
+	pipelineCtx, cancelPipeline := context.WithCancel(ctx)
+	var stages []string
+	slowStage := NewTimingProcessor(&SimulatedProcessor{latency: time.Second}, func(id string, _ time.Duration) {
+		stages = append(stages, "slow stage returned")
+	})
+	pipeline := NewLoggingProcessor(ProcessorFunc(func(ctx context.Context, payment *Payment) (Receipt, error) {
+		stages = append(stages, "caller cancels")
+		cancelPipeline()
+		return slowStage.ProcessPayment(ctx, payment)
+	}), logger)
+	pipelineRepo, pipelineNotes := NewInMemoryPaymentRepository(), &RecordingNotifier{}
+	canceled := NewPayment("PAY-971", 33, "USD")
+	started := time.Now()
+	ok := NewEnhancedPaymentService(pipeline, pipelineNotes, logger, pipelineRepo).ExecutePayment(pipelineCtx, canceled)
+	fmt.Printf("Canceled: ok=%v status=%s saved=%v notes=%q stages=%v fast=%v\n", ok, canceled.Status(),
+		pipelineRepo.FindPaymentByID(ctx, "PAY-971") != nil, pipelineNotes.Messages(), stages, time.Since(started) < time.Second)
+	canceledBatch := NewPaymentService(creditCardProcessor, &RecordingNotifier{}).
+		ExecuteBatch(pipelineCtx, []*Payment{NewPayment("PAY-972", 5, "USD"), NewPayment("PAY-973", 6, "USD")})
+	fmt.Printf("Batch on a canceled context: %d succeeded, %d failed (%v)\n",
+		canceledBatch.Succeeded, canceledBatch.Failed, errors.Is(canceledBatch.Outcomes[0].Err, context.Canceled))
+
+	// Retry - transient outages are retried with backoff, declines are not
+	retryPolicy := RetryPolicy{MaxAttempts: 4, BaseDelay: 2 * time.Millisecond, MaxDelay: 10 * time.Millisecond, Jitter: 0.5}
+	outageBank := &OldBankGateway{declineOverCents: 50000, outages: 2}
+	retrying := NewRetryingProcessor(NewOldBankGatewayAdapter(outageBank), retryPolicy)
+	NewPaymentService(retrying, emailNotifier).ExecutePayment(ctx, NewPayment("PAY-701", 40, "USD"))
+	var retryErr *RetryError
+	if _, err := retrying.ProcessPayment(ctx, NewPayment("PAY-702", 900, "USD")); errors.As(err, &retryErr) {
+		fmt.Printf("PAY-702 gave up after %d attempt(s), declined: %v\n", retryErr.Attempts, errors.Is(err, ErrPaymentDeclined))
+	}
+	outageBank.offline = true
+	if _, err := retrying.ProcessPayment(ctx, NewPayment("PAY-703", 10, "USD")); errors.As(err, &retryErr) {
+		fmt.Printf("PAY-703 gave up after %d attempt(s): %v\n", retryErr.Attempts, errors.Is(err, ErrNetwork))
+	}
+	deadlineCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	slow := NewRetryingProcessor(NewOldBankGatewayAdapter(outageBank), RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second})
+	if _, err := slow.ProcessPayment(deadlineCtx, NewPayment("PAY-704", 10, "USD")); errors.Is(err, context.DeadlineExceeded) {
+		fmt.Println("PAY-704", err)
+	}
+	cancel()
+
+	// Repositories - both implementations pass the same contract (see example_test.go);
+	// the file one backs the enhanced service
+	dir, err := os.MkdirTemp("", "payments")
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	fileRepo := NewJSONFileRepository(filepath.Join(dir, "payments.json"))
+	// DI container - each abstraction is bound once; the service only names what it needs
+	container := NewContainer()
+	Provide(container, func(*Container) (PaymentProcessor, error) { return creditCardProcessor, nil })
+	Provide(container, func(*Container) (Notifier, error) { return emailNotifier, nil })
+	Provide(container, func(*Container) (Logger, error) { return logger, nil })
+	Provide(container, func(*Container) (PaymentRepository, error) { return fileRepo, nil })
+	Provide(container, func(c *Container) (*EnhancedPaymentService, error) {
+		processor, err := Resolve[PaymentProcessor](c)
+		if err != nil {
+			return nil, err
+		}
+		notifier, err := Resolve[Notifier](c)
+		if err != nil {
+			return nil, err
+		}
+		logger, err := Resolve[Logger](c)
+		if err != nil {
+			return nil, err
+		}
+		repository, err := Resolve[PaymentRepository](c)
+		if err != nil {
+			return nil, err
+		}
+		return NewEnhancedPaymentService(processor, notifier, logger, repository), nil
+	})
+	enhanced, err := Resolve[*EnhancedPaymentService](container)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	if again, _ := Resolve[*EnhancedPaymentService](container); again != enhanced {
+		fmt.Println("container built the service twice")
+	}
+	incomplete := NewContainer()
+	Provide(incomplete, func(c *Container) (Notifier, error) {
+		if _, err := Resolve[Logger](c); err != nil {
+			return nil, err
+		}
+		return emailNotifier, nil
+	})
+	_, err = Resolve[Notifier](incomplete)
+	fmt.Println("Container, missing binding:", err)
+	cyclic := NewContainer()
+	Provide(cyclic, func(c *Container) (PaymentProcessor, error) {
+		_, err := Resolve[Notifier](c) // a processor that reports through a notifier...
+		return creditCardProcessor, err
+	})
+	Provide(cyclic, func(c *Container) (Notifier, error) {
+		_, err := Resolve[PaymentProcessor](c) // ...that itself needs the processor
+		return emailNotifier, err
+	})
+	_, err = Resolve[PaymentProcessor](cyclic)
+	fmt.Printf("Container, cycle (is ErrDependencyCycle: %v): %v\n", errors.Is(err, ErrDependencyCycle), err)
+	revenue := 0.0
+	stopTally := enhanced.Events().Subscribe(PaymentSucceeded, func(ctx context.Context, event PaymentEvent) {
+		revenue += event.Payment.amount.Amount()
+	})
+	pay601 := NewPayment("PAY-601", 75, "USD")
+	enhanced.ExecutePayment(ctx, pay601)
+	enhanced.ExecutePayment(ctx, NewPayment("PAY-602", 25, "USD"))
+	stopTally()
+	enhanced.ExecutePayment(ctx, NewPayment("PAY-603", 40, "USD"))
+	enhanced.ExecutePayment(ctx, pay601) // already captured: published as PaymentFailed
+	fmt.Printf("Revenue seen by the tally subscriber: %.2f\n", revenue)
+
+	// Logger backends - the same service logs JSON lines or into memory for assertions
+	jsonLogger := NewJSONLinesLogger(os.Stdout, LevelInfo, &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)})
+	NewEnhancedPaymentService(NewOldBankGatewayAdapter(&OldBankGateway{offline: true}), &RecordingNotifier{}, jsonLogger, NewInMemoryPaymentRepository()).
+		ExecutePayment(ctx, NewPayment("PAY-604", 60, "USD"))
+	memoryLogger := NewMemoryLogger(LevelDebug)
+	NewEnhancedPaymentService(creditCardProcessor, &RecordingNotifier{}, memoryLogger, NewInMemoryPaymentRepository()).
+		ExecutePayment(ctx, NewPayment("PAY-605", 15, "USD"))
+	for _, entry := range memoryLogger.Entries() {
+		fmt.Printf("Memory log: %s %q reference=%v\n", entry.Level, entry.Message, entry.Field("reference"))
+	}
+
+	// Tokenization - the card goes into the vault at the edge; Payment, logs
+	// and JSON only ever see a token or a masked number
+	vault := NewCardVault([]byte("vault-demo-secret"))
+	var tokenizer Tokenizer = vault
+	card, err := NewCardData("4242 4242 4242 4242", 12, 2027)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	token, err := tokenizer.Tokenize(ctx, card)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	tokenized := NewPayment("PAY-871", 64, "USD").WithCard(token)
+	cardJSON, _ := json.Marshal(map[string]any{"card": card})
+	fmt.Printf("Card: %v | %#v | %s\n", card, card, cardJSON)
+	fmt.Println("Payment:", tokenized)
+	cardLogger := NewMemoryLogger(LevelDebug)
+	cardLogger.Info("customer typed 4000-0566-5566-5556 into the notes field", "payment", tokenized.id,
+		"note", "card 4242424242424242 used", "error", fmt.Errorf("%w: 5555 5555 5555 4444", ErrPaymentDeclined))
+	for _, entry := range cardLogger.Entries() {
+		fmt.Printf("Masked log: %s note=%v error=%v\n", entry.Message, entry.Field("note"), entry.Field("error"))
+	}
+	if stored, err := vault.Detokenize(ctx, tokenized.Card()); err == nil {
+		fmt.Println("Vault resolves the token to card ending", stored.Last4())
+	}
+	_, err = NewCardData("4242 4242 4242 4241", 12, 2027)
+	fmt.Println("Typo in card number:", err)
+	if stored := fileRepo.FindPaymentByID(ctx, "PAY-601"); stored != nil {
+		fmt.Printf("Loaded from file: %s %v\n", stored.id, stored.Status())
+	}
+	if err := fileRepo.Err(); err != nil {
+		fmt.Println("repository error:", err)
+	}
+
+	// Demonstrate LSP - refunds go through the service, whichever refunder is plugged in
+	paymentService.WithRefunds(&CreditCardRefundProcessor{})
+	if err := paymentService.ExecuteRefund(ctx, payment); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Printf("PAY-001 lifecycle: %v\n", payment.History())
+	if err := paymentService.ExecuteRefund(ctx, payment); errors.Is(err, ErrInvalidTransition) {
+		fmt.Println(err)
+	}
+	// Every processor/refunder pair is checked in example_test.go; the capped
+	// refunder is the one that fails, refusing a refund its processor charged
+	cappedService := NewPaymentService(creditCardProcessor, emailNotifier).WithRefunds(&CappedRefundProcessor{max: 100})
+	if large := NewPayment("PAY-071", 250, "USD"); cappedService.ExecutePayment(ctx, large) {
+		fmt.Println("LSP capped refunds:", cappedService.ExecuteRefund(ctx, large))
+	}
+
+	// Money - minor units per currency, and no float drift when adding
+	fmt.Println("Money:", NewMoney(19.999, "USD"), "|", NewMoney(1999.6, "JPY"), "|", NewMoney(2.71828, "BHD"))
+	a, b := 0.1, 0.2
+	sum, _ := NewMoney(a, "USD").Add(NewMoney(b, "USD"))
+	fmt.Printf("  0.1 + 0.2 as float64: %v, as Money: %s\n", a+b, sum)
+	if _, err := sum.Add(NewMoney(1, "EUR")); errors.Is(err, ErrCurrencyMismatch) {
+		fmt.Println(" ", err)
+	}
+	if receipt, err := creditCardProcessor.ProcessPayment(ctx, NewPayment("PAY-111", 1999.6, "JPY")); err == nil {
+		fmt.Printf("  receipt %s for %s\n", receipt.Reference, receipt.Amount)
+	}
+
+	// Batch API: one call for many payments, per-item outcomes
+	batch := []*Payment{
+		NewPayment("PAY-101", 25.0, "USD"),
+		NewPayment("PAY-102", -5.0, "USD"),
+		NewPayment("PAY-101", 25.0, "USD"),
+		NewPayment("PAY-103", 70.0, "EUR"),
+		NewPayment("PAY-104", 12.5, "USD"),
+		NewPayment("PAY-105", 30.0, "EUR"),
+	}
+	batchResult := NewPaymentService(creditCardProcessor, emailNotifier).
+		WithBatchConcurrency(2).
+		ExecuteBatch(ctx, batch)
+	for _, outcome := range batchResult.Outcomes {
+		fmt.Printf("  %s -> err: %v\n", outcome.Payment.id, outcome.Err)
+	}
+	if errors.Is(batchResult.Outcomes[2].Err, ErrDuplicatePayment) {
+		fmt.Println("  duplicate detected with errors.Is")
+	}
+	currencies := make([]string, 0, len(batchResult.Totals))
+	for currency := range batchResult.Totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		total := batchResult.Totals[currency]
+		fmt.Printf("  %s: paid %s, failed %s\n", currency, total.Succeeded, total.Failed)
+	}
+
+	// Settlement - 100k charges through ExecuteBatch in chunks, payments from a pool
+	requests := make([]ChargeRequest, 100_000)
+	for i := range requests {
+		requests[i] = ChargeRequest{ID: fmt.Sprintf("SET-%d", i), Amount: float64(i%500 + 1), Currency: "USD"}
+	}
+	for _, pooled := range []bool{false, true} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		settlement := NewSettlementRun(&SimulatedProcessor{}, pooled).Run(ctx, requests)
+		runtime.ReadMemStats(&after)
+		fmt.Printf("  pooled=%v: %d settled, net %s, %.1f mallocs per payment\n", pooled, settlement.Settled,
+			settlement.Net["USD"], float64(after.Mallocs-before.Mallocs)/float64(len(requests)))
+	}
+
+	// Templates: each channel renders its own body from shared partials
+	textRenderer, err := NewTextTemplateRenderer()
+	if err != nil {
+		fmt.Println("template error:", err)
+		return
+	}
+	htmlRenderer, err := NewHTMLTemplateRenderer()
+	if err != nil {
+		fmt.Println("template error:", err)
+		return
+	}
+	NewPaymentService(creditCardProcessor, NewTemplatedNotifier(smsNotifier, textRenderer, "sms")).
+		ExecutePayment(ctx, NewPayment("PAY-201", 42.5, "USD"))
+	NewPaymentService(creditCardProcessor, NewTemplatedNotifier(emailNotifier, htmlRenderer, "email")).
+		ExecutePayment(ctx, NewPayment("PAY-<202>", 10, "EUR"))
+	statement, err := textRenderer.Render("statement", StatementView{
+		Owner:    "Alice",
+		Currency: "USD",
+		Payments: []PaymentView{payment.View(true), NewPayment("PAY-203", 15, "USD").View(false)},
+	})
+	if err != nil {
+		fmt.Println("template error:", err)
+		return
+	}
+	fmt.Println(statement)
+
+	// Metrics - decorate any service; the sink is printed at the end of main
+	metrics := NewInMemoryMetrics()
+	instrumented := []PaymentExecutor{
+		NewInstrumentedPaymentService(NewPaymentService(&SimulatedProcessor{latency: 2 * time.Millisecond}, &RecordingNotifier{}), metrics),
+		NewInstrumentedPaymentService(NewOutboxPaymentService(NewOldBankGatewayAdapter(&OldBankGateway{declineOverCents: 5000}), NewOutboxStore()), metrics),
+	}
+	for i, amount := range []float64{10, 75, 30, 20} {
+		instrumented[i%2].ExecutePayment(ctx, NewPayment(fmt.Sprintf("PAY-93%d", i+1), amount, "USD"))
+	}
+
+	// Composite - email and SMS together, plus a webhook to a partner that is down
+	emailLog, smsLog := &RecordingNotifier{}, &RecordingNotifier{}
+	deadPartner := NewHTTPWebhookNotifier("http://127.0.0.1:1/hooks", "whsec-demo", 100*time.Millisecond, 1)
+	strict := NewCompositeNotifier(emailLog, smsLog, deadPartner)
+	fmt.Println("Composite (strict):", strict.Notify(ctx, "Payment successful: PAY-921"))
+	lenient := NewCompositeNotifier(emailLog, smsLog, deadPartner).RequireAtLeastOne()
+	NewPaymentService(&SimulatedProcessor{}, lenient).ExecutePayment(ctx, NewPayment("PAY-922", 8, "USD"))
+	fmt.Printf("Composite (at least one): email %q, sms %q, %d failed channel(s)\n",
+		emailLog.Messages(), smsLog.Messages(), len(lenient.Failures()))
+	onlyDead := NewCompositeNotifier(deadPartner).RequireAtLeastOne()
+	fmt.Println("Composite (all down):", errors.Is(onlyDead.Notify(ctx, "ping"), ErrAllChannelsFailed))
+
+	// Async - three accounts share two workers; each account keeps its own order
+	var orderMu sync.Mutex
+	processedOrder := map[string][]string{}
+	asyncProcessor := NewTimingProcessor(&SimulatedProcessor{latency: time.Millisecond}, func(id string, _ time.Duration) {
+		orderMu.Lock()
+		defer orderMu.Unlock()
+		account := id[:strings.Index(id, "-")]
+		processedOrder[account] = append(processedOrder[account], id)
+	})
+	asyncNotifier := &RecordingNotifier{}
+	asyncService := NewPaymentService(asyncProcessor, asyncNotifier).WithWorkers(2, 4)
+	var results []<-chan PaymentOutcome
+	for i := 1; i <= 4; i++ {
+		for _, account := range []string{"alice", "bob", "carol"} {
+			id := fmt.Sprintf("%s-%d", account, i)
+			results = append(results, asyncService.Submit(ctx, NewPayment(id, float64(10*i), "USD").ForAccount(account)))
+		}
+	}
+	succeeded := 0
+	for _, result := range results {
+		if outcome := <-result; outcome.Err == nil {
+			succeeded++
+		}
+	}
+	if err := asyncService.Shutdown(context.Background()); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Printf("Async: %d/%d succeeded, %d notifications\n", succeeded, len(results), len(asyncNotifier.Messages()))
+	for _, account := range []string{"alice", "bob", "carol"} {
+		fmt.Printf("  %-5s processed in order: %v\n", account, processedOrder[account])
+	}
+	late := <-asyncService.Submit(ctx, NewPayment("alice-5", 5, "USD").ForAccount("alice"))
+	fmt.Println("  after shutdown:", late.Err)
+
+	// Outbox - the notifier is down for the first two sends; nothing is lost,
+	// the dispatcher keeps the messages and delivers them once it recovers
+	store := NewOutboxStore()
+	outboxService := NewOutboxPaymentService(creditCardProcessor, store)
+	var smsDown atomic.Int32
+	smsDown.Store(2)
+	flakySMS := func(ctx context.Context, msg OutboxMessage) error {
+		if smsDown.Add(-1) >= 0 {
+			return errors.New("sms gateway timeout")
+		}
+		smsNotifier.SendNotification(ctx, fmt.Sprintf("%s (outbox #%d, attempt %d)", msg.Body, msg.ID, msg.Attempts+1))
+		return nil
+	}
+	outboxService.ExecutePayment(ctx, NewPayment("PAY-951", 30, "USD"))
+	outboxService.ExecutePayment(ctx, NewPayment("PAY-952", 45, "USD"))
+	fmt.Printf("Outbox after commit: %d pending, PAY-951 saved: %v\n", len(store.Pending()), store.FindPaymentByID(ctx, "PAY-951") != nil)
+	dispatcher := NewOutboxDispatcher(store, flakySMS, 5*time.Millisecond)
+	fmt.Printf("First pass delivered %d\n", dispatcher.DispatchOnce(ctx))
+	for _, msg := range store.Pending() {
+		fmt.Printf("  still pending #%d %s %s: %d attempt(s), %s\n", msg.ID, msg.PaymentID, msg.Kind, msg.Attempts, msg.LastError)
+	}
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	dispatcher.Start(dispatchCtx)
+	outboxService.ExecutePayment(ctx, NewPayment("PAY-953", 12, "USD"))
+	stopDispatch()
+	dispatcher.Wait()
+	fmt.Printf("Outbox after dispatcher stopped: %d pending\n", len(store.Pending()))
+
+	// Webhooks - a local httptest.Server plays the partner: it checks the
+	// signature and is briefly unavailable, so the first delivery is retried
+	const webhookSecret = "whsec-demo"
+	var webhookCalls atomic.Int32 // the handler runs on the server's goroutines
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		call := webhookCalls.Add(1)
+		switch {
+		case !VerifyWebhook([]byte(webhookSecret), body, r.Header.Get(WebhookSignatureHeader)):
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+		case call == 1:
+			http.Error(w, "warming up", http.StatusServiceUnavailable)
+		default:
+			fmt.Printf("Partner received (call %d): %s\n", call, body)
+		}
+	}))
+	defer partner.Close()
+	webhook := NewHTTPWebhookNotifier(partner.URL, webhookSecret, time.Second, 3)
+	NewPaymentService(creditCardProcessor, webhook).ExecutePayment(ctx, NewPayment("PAY-901", 18, "USD"))
+	webhook.SendNotification(ctx, "daily settlement done")
+	fmt.Println("webhook error:", webhook.Err())
+	forged := NewHTTPWebhookNotifier(partner.URL, "wrong-secret", time.Second, 3)
+	forged.SendNotification(ctx, "forged")
+	fmt.Println("forged webhook:", forged.Err(), "| calls so far:", webhookCalls.Load())
+
+	// Subscription billing - daily runs over six weeks of a fake clock. Bob's
+	// card is declined until he updates it; Carol's never works and is canceled
+	billingClock := &ManualClock{now: time.Date(2024, 1, 31, 6, 0, 0, 0, time.UTC)}
+	cardUpdated := false
+	subscriptionCharges := ProcessorFunc(func(ctx context.Context, payment *Payment) (Receipt, error) {
+		if payment.account == "carol" || (payment.account == "bob" && !cardUpdated) {
+			return Receipt{}, fmt.Errorf("%w: card expired", ErrPaymentDeclined)
+		}
+		return newReceipt("subscriptions", "sub-"+payment.id, payment), nil
+	})
+	billingEvents := NewEventBus()
+	for _, kind := range []PaymentEventKind{RenewalSucceeded, RenewalFailed, RenewalAbandoned} {
+		billingEvents.Subscribe(kind, func(ctx context.Context, event PaymentEvent) {
+			fmt.Printf("  %s %s: %s (%s)\n", billingClock.Now().Format("Jan 02"), event.Kind, event.Payment.id, event.Subscription.Status())
+		})
+	}
+	billing := NewBillingEngine(NewPaymentService(subscriptionCharges, &RecordingNotifier{}), billingClock, billingEvents,
+		24*time.Hour, 3*24*time.Hour, 7*24*time.Hour)
+	subscriptions := []*Subscription{
+		NewSubscription("SUB-alice", "alice", 9.99, "USD", Monthly, billingClock.Now()),
+		NewSubscription("SUB-bob", "bob", 4.50, "EUR", Weekly, billingClock.Now()),
+		NewSubscription("SUB-carol", "carol", 99, "USD", Yearly, billingClock.Now()),
+	}
+	for _, sub := range subscriptions {
+		billing.Add(sub)
+	}
+	fmt.Println("Subscription billing:")
+	for day := 0; day < 42; day++ {
+		if day == 3 {
+			cardUpdated = true
+		}
+		billing.RunDue(ctx)
+		billingClock.Advance(24 * time.Hour)
+	}
+	for _, sub := range subscriptions {
+		fmt.Printf("  %s: %s, %d period(s) paid\n", sub.ID(), sub.Status(), sub.PaidPeriods())
+	}
 
-	// Demonstrate LSP
-	refundProcessor := &CreditCardRefundProcessor{}
-	refundProcessor.ProcessRefund(payment)
+	fmt.Println("Metrics:")
+	metrics.Print(os.Stdout)
 }
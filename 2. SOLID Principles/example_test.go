@@ -0,0 +1,1031 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProcessor charges everything and counts how often it was asked
+func countingProcessor(calls *atomic.Int32) PaymentProcessor {
+	return ProcessorFunc(func(ctx context.Context, payment *Payment) (Receipt, error) {
+		calls.Add(1)
+		return newReceipt("counting", "ref-"+payment.id, payment), nil
+	})
+}
+
+// TestEveryEntryPointScreens: a payment the screener declines must not reach
+// the processor, whichever way it is submitted
+func TestEveryEntryPointScreens(t *testing.T) {
+	ctx := context.Background()
+	blocked := func() FraudScreener { return NewRulesScreener(0, 0, []string{"KP"}, nil) }
+	entryPoints := []struct {
+		name string
+		run  func(processor PaymentProcessor, payment *Payment) error
+	}{
+		{"Charge", func(processor PaymentProcessor, payment *Payment) error {
+			_, err := NewPaymentService(processor, &RecordingNotifier{}).WithFraudScreener(blocked()).Charge(ctx, payment)
+			return err
+		}},
+		{"Submit without workers", func(processor PaymentProcessor, payment *Payment) error {
+			service := NewPaymentService(processor, &RecordingNotifier{}).WithFraudScreener(blocked())
+			return (<-service.Submit(ctx, payment)).Err
+		}},
+		{"Submit with workers", func(processor PaymentProcessor, payment *Payment) error {
+			service := NewPaymentService(processor, &RecordingNotifier{}).WithFraudScreener(blocked()).WithWorkers(2, 1)
+			defer service.Shutdown(ctx)
+			return (<-service.Submit(ctx, payment)).Err
+		}},
+		{"ExecuteBatch", func(processor PaymentProcessor, payment *Payment) error {
+			service := NewPaymentService(processor, &RecordingNotifier{}).WithFraudScreener(blocked()).WithBatchConcurrency(2)
+			return service.ExecuteBatch(ctx, []*Payment{payment}).Outcomes[0].Err
+		}},
+		{"EnhancedPaymentService", func(processor PaymentProcessor, payment *Payment) error {
+			enhanced := NewEnhancedPaymentService(processor, &RecordingNotifier{}, NewMemoryLogger(LevelDebug), NewInMemoryPaymentRepository())
+			enhanced.WithFraudScreener(blocked())
+			var failure error
+			enhanced.Events().Subscribe(PaymentFailed, func(ctx context.Context, event PaymentEvent) { failure = event.Err })
+			enhanced.ExecutePayment(ctx, payment)
+			return failure
+		}},
+		{"OutboxPaymentService", func(processor PaymentProcessor, payment *Payment) error {
+			store := NewOutboxStore()
+			outbox := NewOutboxPaymentService(processor, store)
+			outbox.WithFraudScreener(blocked())
+			if outbox.ExecutePayment(ctx, payment) {
+				return nil
+			}
+			// the outbox records only the outcome; the processor count and the
+			// status checked below show it was the screener that stopped it
+			if pending := store.Pending(); len(pending) == 1 && pending[0].Kind == PaymentFailed {
+				return ErrFraudDeclined
+			}
+			return errors.New("no PaymentFailed message in the outbox")
+		}},
+	}
+	for _, ep := range entryPoints {
+		t.Run(ep.name, func(t *testing.T) {
+			var calls atomic.Int32
+			payment := NewPayment("PAY-T1", 20, "USD").FromCountry("KP")
+			err := ep.run(countingProcessor(&calls), payment)
+			if !errors.Is(err, ErrFraudDeclined) {
+				t.Errorf("err = %v, want ErrFraudDeclined", err)
+			}
+			if calls.Load() != 0 {
+				t.Errorf("processor called %d times for a declined payment", calls.Load())
+			}
+			if payment.Status() != StatusFailed {
+				t.Errorf("status = %s, want Failed", payment.Status())
+			}
+
+			var approvedCalls atomic.Int32
+			approved := NewPayment("PAY-T2", 20, "USD").FromCountry("DE")
+			if err := ep.run(countingProcessor(&approvedCalls), approved); err != nil {
+				t.Errorf("approved payment: %v", err)
+			}
+			if approvedCalls.Load() != 1 || approved.Status() != StatusCaptured {
+				t.Errorf("approved payment: %d calls, status %s", approvedCalls.Load(), approved.Status())
+			}
+		})
+	}
+}
+
+// countingScreener delegates to next and counts the payments it screened
+type countingScreener struct {
+	next  FraudScreener
+	calls atomic.Int32
+}
+
+func (c *countingScreener) Screen(ctx context.Context, payment *Payment) FraudResult {
+	c.calls.Add(1)
+	return c.next.Screen(ctx, payment)
+}
+
+// A duplicate is reported as one even when the first of its id was rejected,
+// and is not screened a second time
+func TestBatchDuplicateOfRejectedPayment(t *testing.T) {
+	var calls atomic.Int32
+	screener := &countingScreener{next: NewRulesScreener(0, 0, []string{"KP"}, nil)}
+	service := NewPaymentService(countingProcessor(&calls), &RecordingNotifier{}).WithFraudScreener(screener)
+	batch := service.ExecuteBatch(context.Background(), []*Payment{
+		NewPayment("PAY-D1", 20, "USD").FromCountry("KP"),
+		NewPayment("PAY-D1", 20, "USD").FromCountry("DE"),
+		NewPayment("PAY-D2", 5, "XXX"), // fails validation
+		NewPayment("PAY-D2", 5, "USD"),
+	})
+	for i, want := range []error{ErrFraudDeclined, ErrDuplicatePayment, ErrInvalidPayment, ErrDuplicatePayment} {
+		if err := batch.Outcomes[i].Err; !errors.Is(err, want) {
+			t.Errorf("outcome %d: err = %v, want %v", i, err, want)
+		}
+	}
+	if screener.calls.Load() != 1 || calls.Load() != 0 {
+		t.Errorf("screened %d times and charged %d, want 1 and 0", screener.calls.Load(), calls.Load())
+	}
+}
+
+// TestEveryEntryPointValidates: WithValidation replaces DefaultValidation on
+// every path, ExecuteBatch included
+func TestEveryEntryPointValidates(t *testing.T) {
+	ctx := context.Background()
+	onlyEUR := SupportedCurrencies("EUR")
+	var calls atomic.Int32
+	service := NewPaymentService(countingProcessor(&calls), &RecordingNotifier{}).WithValidation(onlyEUR)
+
+	batch := service.ExecuteBatch(ctx, []*Payment{NewPayment("PAY-V1", 5, "USD"), NewPayment("PAY-V2", 5, "EUR")})
+	if !errors.Is(batch.Outcomes[0].Err, ErrInvalidPayment) || batch.Outcomes[1].Err != nil {
+		t.Errorf("batch outcomes: %v, %v", batch.Outcomes[0].Err, batch.Outcomes[1].Err)
+	}
+	if status := batch.Outcomes[0].Payment.Status(); status != StatusFailed {
+		t.Errorf("rejected batch payment is %s, want Failed", status)
+	}
+	if outcome := <-service.Submit(ctx, NewPayment("PAY-V3", 5, "USD")); !errors.Is(outcome.Err, ErrInvalidPayment) {
+		t.Errorf("Submit: %v", outcome.Err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("processor called %d times, want 1", calls.Load())
+	}
+}
+
+func canceled() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func expired() context.Context {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel() // the deadline has passed, so ctx keeps reporting it
+	return ctx
+}
+
+// TestContextAbortsPipeline: an ended ctx stops a payment wherever it is,
+// and a payment that was not charged is left retryable
+func TestContextAbortsPipeline(t *testing.T) {
+	tests := []struct {
+		name       string
+		run        func(t *testing.T, calls *atomic.Int32) (*Payment, error)
+		wantErr    error
+		wantStatus PaymentStatus
+		wantCalls  int32
+	}{
+		{
+			name: "canceled before the processor",
+			run: func(t *testing.T, calls *atomic.Int32) (*Payment, error) {
+				payment := NewPayment("PAY-C1", 10, "USD")
+				_, err := NewPaymentService(countingProcessor(calls), &RecordingNotifier{}).Charge(canceled(), payment)
+				return payment, err
+			},
+			wantErr: context.Canceled, wantStatus: StatusPending,
+		},
+		{
+			name: "deadline already expired",
+			run: func(t *testing.T, calls *atomic.Int32) (*Payment, error) {
+				payment := NewPayment("PAY-C2", 10, "USD")
+				_, err := NewPaymentService(countingProcessor(calls), &RecordingNotifier{}).Charge(expired(), payment)
+				return payment, err
+			},
+			wantErr: context.DeadlineExceeded, wantStatus: StatusPending,
+		},
+		{
+			name: "deadline expires while the processor works",
+			run: func(t *testing.T, calls *atomic.Int32) (*Payment, error) {
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				defer cancel()
+				payment := NewPayment("PAY-C3", 10, "USD")
+				start := time.Now()
+				_, err := NewPaymentService(&SimulatedProcessor{latency: time.Minute}, &RecordingNotifier{}).Charge(ctx, payment)
+				if elapsed := time.Since(start); elapsed > 5*time.Second {
+					t.Errorf("processor ignored the deadline for %v", elapsed)
+				}
+				return payment, err
+			},
+			wantErr: context.DeadlineExceeded, wantStatus: StatusAuthorized,
+		},
+		{
+			name: "canceled between retries",
+			run: func(t *testing.T, calls *atomic.Int32) (*Payment, error) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				flaky := ProcessorFunc(func(ctx context.Context, payment *Payment) (Receipt, error) {
+					calls.Add(1)
+					cancel() // the caller gives up while the first attempt fails
+					return Receipt{}, ErrNetwork
+				})
+				retrying := NewRetryingProcessor(flaky, RetryPolicy{MaxAttempts: 5, BaseDelay: time.Minute})
+				payment := NewPayment("PAY-C4", 10, "USD")
+				_, err := NewPaymentService(retrying, &RecordingNotifier{}).Charge(ctx, payment)
+				return payment, err
+			},
+			wantErr: context.Canceled, wantStatus: StatusAuthorized, wantCalls: 1,
+		},
+		{
+			name: "canceled part-way through a batch",
+			run: func(t *testing.T, calls *atomic.Int32) (*Payment, error) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				cancelling := ProcessorFunc(func(ctx context.Context, payment *Payment) (Receipt, error) {
+					calls.Add(1)
+					cancel()
+					return newReceipt("cancelling", "ref", payment), nil
+				})
+				payments := []*Payment{NewPayment("PAY-C5", 10, "USD"), NewPayment("PAY-C6", 10, "USD")}
+				result := NewPaymentService(cancelling, &RecordingNotifier{}).ExecuteBatch(ctx, payments)
+				if result.Outcomes[0].Err != nil || payments[0].Status() != StatusCaptured {
+					t.Errorf("first payment: %v, %s", result.Outcomes[0].Err, payments[0].Status())
+				}
+				return payments[1], result.Outcomes[1].Err
+			},
+			wantErr: context.Canceled, wantStatus: StatusPending, wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			payment, err := tt.run(t, &calls)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if payment.Status() != tt.wantStatus {
+				t.Errorf("status = %s, want %s", payment.Status(), tt.wantStatus)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("processor called %d times, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+// A payment the processor has charged is saved even when ctx ends right after
+// the charge; a repository that skips writes on a done ctx must still get it
+func TestCaptureSavedAfterCancel(t *testing.T) {
+	entryPoints := map[string]func(ctx context.Context, service *EnhancedPaymentService, payment *Payment) bool{
+		"ExecutePayment": func(ctx context.Context, service *EnhancedPaymentService, payment *Payment) bool {
+			return service.ExecutePayment(ctx, payment)
+		},
+		"ExecuteBatch": func(ctx context.Context, service *EnhancedPaymentService, payment *Payment) bool {
+			return service.ExecuteBatch(ctx, []*Payment{payment}).Succeeded == 1
+		},
+		"Submit": func(ctx context.Context, service *EnhancedPaymentService, payment *Payment) bool {
+			service.WithWorkers(1, 1)
+			defer service.Shutdown(context.Background())
+			return (<-service.Submit(ctx, payment)).Err == nil
+		},
+	}
+	for name, run := range entryPoints {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			chargeThenCancel := ProcessorFunc(func(ctx context.Context, payment *Payment) (Receipt, error) {
+				cancel() // the caller gives up just after the money moved
+				return newReceipt("cancelling", "ref-"+payment.id, payment), nil
+			})
+			repo := NewJSONFileRepository(filepath.Join(t.TempDir(), "payments.json"))
+			service := NewEnhancedPaymentService(chargeThenCancel, &RecordingNotifier{}, NewMemoryLogger(LevelDebug), repo)
+			payment := NewPayment("PAY-SAVE-1", 10, "USD")
+			if !run(ctx, service, payment) || payment.Status() != StatusCaptured {
+				t.Fatalf("charge failed, status %s", payment.Status())
+			}
+			found := repo.FindPaymentByID(context.Background(), payment.id)
+			if found == nil || found.Status() != StatusCaptured {
+				t.Fatalf("captured payment not saved: found %v, repository error %v", found, repo.Err())
+			}
+			if err := repo.Err(); err != nil {
+				t.Errorf("repository error: %v", err)
+			}
+		})
+	}
+}
+
+func TestContextAbortsRefunds(t *testing.T) {
+	refunders := map[string]RefundProcessor{
+		"credit card": &CreditCardRefundProcessor{},
+		"paypal":      &PayPalRefundProcessor{},
+		"capped":      &CappedRefundProcessor{max: 1000},
+	}
+	for name, refunder := range refunders {
+		for ctxName, ctx := range map[string]context.Context{"canceled": canceled(), "expired": expired()} {
+			t.Run(name+"/"+ctxName, func(t *testing.T) {
+				payment := NewPayment("PAY-R1", 10, "USD")
+				payment.Authorize()
+				payment.Capture()
+				err := NewPaymentService(&CreditCardProcessor{}, &RecordingNotifier{}).WithRefunds(refunder).ExecuteRefund(ctx, payment)
+				if !errors.Is(err, ctx.Err()) {
+					t.Errorf("err = %v, want %v", err, ctx.Err())
+				}
+				if payment.Status() != StatusCaptured {
+					t.Errorf("status = %s, want Captured", payment.Status())
+				}
+			})
+		}
+	}
+}
+
+func TestContextAbortsVault(t *testing.T) {
+	vault := NewCardVault([]byte("test-secret"))
+	card, err := NewCardData("4242424242424242", 12, 2030)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := vault.Tokenize(context.Background(), card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vault.Tokenize(canceled(), card); !errors.Is(err, context.Canceled) {
+		t.Errorf("Tokenize: %v", err)
+	}
+	if _, err := vault.Detokenize(canceled(), token); !errors.Is(err, context.Canceled) {
+		t.Errorf("Detokenize canceled: %v", err)
+	}
+	if _, err := vault.Detokenize(expired(), token); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Detokenize expired: %v", err)
+	}
+	if got, err := vault.Detokenize(context.Background(), token); err != nil || got.Last4() != "4242" {
+		t.Errorf("Detokenize: %v, %v", got, err)
+	}
+}
+
+// TestRepositoryContract is the behaviour every PaymentRepository must share
+func TestRepositoryContract(t *testing.T) {
+	repositories := map[string]func(t *testing.T) PaymentRepository{
+		"in memory": func(t *testing.T) PaymentRepository { return NewInMemoryPaymentRepository() },
+		"json file": func(t *testing.T) PaymentRepository {
+			return NewJSONFileRepository(filepath.Join(t.TempDir(), "payments.json"))
+		},
+	}
+	for name, newRepo := range repositories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			if repo.FindPaymentByID(ctx, "missing") != nil {
+				t.Error("unknown id should return nil")
+			}
+
+			payment := NewPayment("CONTRACT-1", 12.5, "EUR").ForAccount("acct-7").FromCountry("DE").WithCard("tok_0123456789abcdef")
+			repo.SavePayment(ctx, payment)
+			found := repo.FindPaymentByID(ctx, "CONTRACT-1")
+			if found == nil {
+				t.Fatal("saved payment not found")
+			}
+			if found.String() != payment.String() || found.amount != payment.amount ||
+				found.account != payment.account || found.country != payment.country || found.card != payment.card {
+				t.Errorf("round trip lost data:\n got  %+v\n want %+v", *found, *payment)
+			}
+
+			payment.Authorize()
+			payment.Capture()
+			repo.SavePayment(ctx, payment)
+			found = repo.FindPaymentByID(ctx, "CONTRACT-1")
+			if found == nil || found.Status() != StatusCaptured {
+				t.Fatalf("second save should replace the first, found %v", found)
+			}
+			if got, want := found.History(), payment.History(); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("history = %v, want %v", got, want)
+			}
+			if err := found.Refund(); err != nil {
+				t.Errorf("found payment lost its state: %v", err)
+			}
+			if errRepo, ok := repo.(interface{ Err() error }); ok && errRepo.Err() != nil {
+				t.Errorf("repository error: %v", errRepo.Err())
+			}
+		})
+	}
+}
+
+// TestRepositoryStoresMinorUnits: amounts are stored as {minor, currency},
+// whatever the currency's number of decimals
+func TestRepositoryStoresMinorUnits(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "payments.json")
+	repo := NewJSONFileRepository(path)
+	amounts := []struct {
+		id     string
+		amount float64
+		code   string
+		minor  int64
+	}{
+		{"PAY-JPY", 1500, "JPY", 1500},
+		{"PAY-BHD", 12.345, "BHD", 12345},
+		{"PAY-USD", 0.1 + 0.2, "USD", 30},
+	}
+	for _, a := range amounts {
+		repo.SavePayment(ctx, NewPayment(a.id, a.amount, a.code))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored map[string]struct {
+		Amount map[string]any `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range amounts {
+		want := map[string]any{"minor": float64(a.minor), "currency": a.code}
+		if got := stored[a.id].Amount; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s stored as %v, want %v", a.id, got, want)
+		}
+		found := repo.FindPaymentByID(ctx, a.id)
+		if found == nil || found.Amount().Minor() != a.minor || found.Amount().Currency() != a.code {
+			t.Errorf("%s loaded as %v", a.id, found)
+		}
+	}
+	if err := repo.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLowestFeeUsesRegisteredFees(t *testing.T) {
+	router, err := NewProcessorRouter(LowestFeeStrategy{},
+		Route{Name: "paypal"}, Route{Name: "credit_card"}, Route{Name: "old_bank", MaxAmount: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		amount float64
+		want   string
+		fee    float64
+	}{
+		{amount: 5, want: "credit_card", fee: 0.15}, // 2.9% is below the 0.25 flat fee
+		{amount: 50, want: "old_bank", fee: 0.25},
+		{amount: 900, want: "credit_card", fee: 21.60}, // over old_bank's limit, 2.4% tier beats 3.49%
+	} {
+		receipt, err := router.ProcessPayment(context.Background(), NewPayment("PAY-T", tc.amount, "USD"))
+		if err != nil {
+			t.Fatalf("%.2f: %v", tc.amount, err)
+		}
+		if receipt.Fees.Fee != NewMoney(tc.fee, "USD") || receipt.Processor != tc.want {
+			t.Errorf("%.2f: got %s with fee %s, want %s with fee %.2f", tc.amount, receipt.Processor, receipt.Fees.Fee, tc.want, tc.fee)
+		}
+	}
+}
+
+func TestRouterProbesDownRoute(t *testing.T) {
+	clock := &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	bank := &OldBankGateway{offline: true}
+	router, err := NewProcessorRouter(FirstMatchStrategy{},
+		Route{Name: "old_bank", Processor: NewOldBankGatewayAdapter(bank)}, Route{Name: "credit_card"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router.WithRecovery(time.Minute, clock)
+	charge := func() (Receipt, error) {
+		return router.ProcessPayment(context.Background(), NewPayment("PAY-T", 20, "USD"))
+	}
+
+	if _, err := charge(); !errors.Is(err, ErrNetwork) {
+		t.Fatalf("first charge: got %v, want ErrNetwork", err)
+	}
+	if receipt, _ := charge(); receipt.Processor == "old_bank" {
+		t.Fatal("a down route was used before probeAfter")
+	}
+	clock.Advance(time.Minute)
+	if _, err := charge(); !errors.Is(err, ErrNetwork) {
+		t.Fatalf("probe while still offline: got %v, want ErrNetwork", err)
+	}
+	clock.Advance(30 * time.Second)
+	bank.offline = false
+	if receipt, _ := charge(); receipt.Processor == "old_bank" {
+		t.Fatal("a failed probe must restart the wait")
+	}
+	clock.Advance(30 * time.Second)
+	if receipt, err := charge(); err != nil || receipt.Processor != "old_bank" {
+		t.Fatalf("probe after recovery: got %s, %v", receipt.Processor, err)
+	}
+	if receipt, err := charge(); err != nil || receipt.Processor != "old_bank" {
+		t.Fatalf("a successful probe must restore the route: got %s, %v", receipt.Processor, err)
+	}
+
+	router.SetHealthy("old_bank", false)
+	clock.Advance(time.Hour)
+	if receipt, _ := charge(); receipt.Processor == "old_bank" {
+		t.Fatal("a route an operator took out must not be probed")
+	}
+}
+
+// checkSubstitutability is the behaviour every processor/refunder pair must
+// share, so any pair can be swapped into PaymentService without surprises
+func checkSubstitutability(processor PaymentProcessor, refunder RefundProcessor, currency string) error {
+	ctx := context.Background()
+	notifier := &RecordingNotifier{}
+	service := NewPaymentService(processor, notifier).WithRefunds(refunder)
+
+	payment := NewPayment("LSP-1", 250, currency)
+	receipt, err := service.process(ctx, payment)
+	if err != nil {
+		return fmt.Errorf("charge: %w", err)
+	}
+	if receipt.PaymentID != payment.id || receipt.Amount != payment.amount {
+		return fmt.Errorf("receipt does not describe the payment: %+v", receipt)
+	}
+	if payment.Status() != StatusCaptured {
+		return fmt.Errorf("processor changed the lifecycle: %s", payment.Status())
+	}
+	if err := service.ExecuteRefund(ctx, payment); err != nil {
+		return fmt.Errorf("refund of a captured payment: %w", err)
+	}
+	if payment.Status() != StatusRefunded {
+		return fmt.Errorf("refund left payment %s", payment.Status())
+	}
+	if err := service.ExecuteRefund(ctx, payment); !errors.Is(err, ErrInvalidTransition) {
+		return fmt.Errorf("second refund should be an invalid transition, got %v", err)
+	}
+	if err := service.ExecuteRefund(ctx, NewPayment("LSP-2", 10, currency)); !errors.Is(err, ErrInvalidTransition) {
+		return fmt.Errorf("refund of an uncaptured payment should be an invalid transition, got %v", err)
+	}
+	if messages := notifier.Messages(); len(messages) != 1 || messages[0] != "Refund issued: LSP-1" {
+		return fmt.Errorf("unexpected notifications %q", messages)
+	}
+	return nil
+}
+
+const testWebhookSecret = "whsec-test"
+
+// webhookPartner answers with statuses in order, repeating the last one, and
+// rejects any request whose signature does not verify with 401
+type webhookPartner struct {
+	statuses []int
+	onCall   func(call int)
+
+	mu     sync.Mutex // the handler runs on the server's goroutines
+	bodies [][]byte
+}
+
+func (p *webhookPartner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	p.mu.Lock()
+	p.bodies = append(p.bodies, body)
+	call := len(p.bodies)
+	p.mu.Unlock()
+	if p.onCall != nil {
+		p.onCall(call)
+	}
+	if !VerifyWebhook([]byte(testWebhookSecret), body, r.Header.Get(WebhookSignatureHeader)) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	if status := p.statuses[min(call, len(p.statuses))-1]; status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+func (p *webhookPartner) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.bodies)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	partner := &webhookPartner{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(partner)
+	defer server.Close()
+
+	webhook := NewHTTPWebhookNotifier(server.URL, testWebhookSecret, time.Second, 3)
+	if err := webhook.NotifyPayment(context.Background(), NewPayment("PAY-W1", 18, "USD").View(true)); err != nil {
+		t.Fatalf("signed delivery: %v", err)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(partner.bodies[0], &payload); err != nil || payload.Event != "payment" || payload.Payment.ID != "PAY-W1" {
+		t.Fatalf("partner received %s (%v)", partner.bodies[0], err)
+	}
+	tampered := append([]byte(nil), partner.bodies[0]...)
+	tampered[len(tampered)-2] ^= 1
+	if VerifyWebhook([]byte(testWebhookSecret), tampered, SignWebhook([]byte(testWebhookSecret), partner.bodies[0])) {
+		t.Error("a changed body verified against the original signature")
+	}
+
+	forged := NewHTTPWebhookNotifier(server.URL, "wrong-secret", time.Second, 3)
+	if err := forged.Notify(context.Background(), "forged"); !errors.Is(err, ErrWebhookFailed) {
+		t.Fatalf("wrong secret: got %v, want ErrWebhookFailed", err)
+	}
+	if partner.calls() != 2 {
+		t.Errorf("partner saw %d calls, want 2: a 401 is not retried", partner.calls())
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		wantCall int
+	}{
+		{"5xx is retried", []int{503, 200}, false, 2},
+		{"4xx is not retried", []int{400, 200}, true, 1},
+		{"4xx after a 5xx stops", []int{502, 404, 200}, true, 2},
+		{"attempts are capped", []int{500}, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partner := &webhookPartner{statuses: tt.statuses}
+			server := httptest.NewServer(partner)
+			defer server.Close()
+			webhook := NewHTTPWebhookNotifier(server.URL, testWebhookSecret, time.Second, 3)
+			webhook.backoff = time.Millisecond
+
+			err := webhook.Notify(context.Background(), "hello")
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrWebhookFailed)) {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if webhook.Err() != err {
+				t.Errorf("Err() = %v, want the last delivery error %v", webhook.Err(), err)
+			}
+			if partner.calls() != tt.wantCall {
+				t.Errorf("partner saw %d calls, want %d", partner.calls(), tt.wantCall)
+			}
+		})
+	}
+}
+
+// A canceled ctx ends the backoff at once instead of sleeping through the retries
+func TestWebhookCancelStopsRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	partner := &webhookPartner{statuses: []int{503}, onCall: func(int) { cancel() }}
+	server := httptest.NewServer(partner)
+	defer server.Close()
+	webhook := NewHTTPWebhookNotifier(server.URL, testWebhookSecret, time.Second, 10)
+	webhook.backoff = time.Minute
+
+	start := time.Now()
+	err := webhook.Notify(ctx, "hello")
+	if !errors.Is(err, ErrWebhookFailed) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want ErrWebhookFailed wrapping context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("delivery kept going for %v after cancel", elapsed)
+	}
+	if partner.calls() != 1 {
+		t.Errorf("partner saw %d calls, want 1", partner.calls())
+	}
+}
+
+// scriptedProcessor returns its results in order and counts the calls that reach it
+type scriptedProcessor struct {
+	results []error
+	calls   int
+}
+
+func (p *scriptedProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	err := p.results[p.calls]
+	p.calls++
+	if err != nil {
+		return Receipt{}, err
+	}
+	return newReceipt("scripted", "ref-"+payment.id, payment), nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	outage := fmt.Errorf("%w: bank down", ErrNetwork)
+	type step struct {
+		advance time.Duration
+		before  BreakerState // State() after advancing, before the call; empty skips the check
+		result  error        // what the wrapped processor returns if the call reaches it
+		reached bool
+		wantErr error
+		want    BreakerState
+	}
+	// Window 4, rate 0.5: the fourth call fills the window with two failures
+	opened := []step{
+		{result: nil, reached: true, want: BreakerClosed},
+		{result: outage, reached: true, wantErr: ErrNetwork, want: BreakerClosed},
+		{result: nil, reached: true, want: BreakerClosed},
+		{result: outage, reached: true, wantErr: ErrNetwork, want: BreakerOpen},
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"closed below the failure rate", []step{
+			{result: outage, reached: true, wantErr: ErrNetwork, want: BreakerClosed},
+			{result: nil, reached: true, want: BreakerClosed},
+			{result: nil, reached: true, want: BreakerClosed},
+			{result: nil, reached: true, want: BreakerClosed},
+			{result: nil, reached: true, want: BreakerClosed},
+		}},
+		{"declines do not count as failures", []step{
+			{result: ErrPaymentDeclined, reached: true, wantErr: ErrPaymentDeclined, want: BreakerClosed},
+			{result: ErrPaymentDeclined, reached: true, wantErr: ErrPaymentDeclined, want: BreakerClosed},
+			{result: ErrPaymentDeclined, reached: true, wantErr: ErrPaymentDeclined, want: BreakerClosed},
+			{result: ErrPaymentDeclined, reached: true, wantErr: ErrPaymentDeclined, want: BreakerClosed},
+		}},
+		{"opens when the window reaches the rate", opened},
+		{"rejects while open, without calling through", append(opened[:4:4],
+			step{wantErr: ErrCircuitOpen, want: BreakerOpen},
+			step{advance: 29 * time.Second, before: BreakerOpen, wantErr: ErrCircuitOpen, want: BreakerOpen},
+		)},
+		{"half-open after the cool-down, a good trial closes", append(opened[:4:4],
+			step{advance: 30 * time.Second, before: BreakerHalfOpen, result: nil, reached: true, want: BreakerClosed},
+			step{result: outage, reached: true, wantErr: ErrNetwork, want: BreakerClosed}, // a fresh window
+		)},
+		{"a failed trial re-opens for another cool-down", append(opened[:4:4],
+			step{advance: 30 * time.Second, before: BreakerHalfOpen, result: outage, reached: true, wantErr: ErrNetwork, want: BreakerOpen},
+			step{advance: 29 * time.Second, before: BreakerOpen, wantErr: ErrCircuitOpen, want: BreakerOpen},
+			step{advance: time.Second, before: BreakerHalfOpen, result: nil, reached: true, want: BreakerClosed},
+		)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+			next := &scriptedProcessor{}
+			for _, st := range tt.steps {
+				next.results = append(next.results, st.result)
+			}
+			breaker := NewCircuitBreakerProcessor(next, BreakerSettings{Window: 4, FailureRate: 0.5, CoolDown: 30 * time.Second}, clock)
+			for i, st := range tt.steps {
+				clock.Advance(st.advance)
+				if st.before != "" && breaker.State() != st.before {
+					t.Fatalf("step %d: state %s before the call, want %s", i+1, breaker.State(), st.before)
+				}
+				calls := next.calls
+				_, err := breaker.ProcessPayment(context.Background(), NewPayment(fmt.Sprintf("PAY-B%d", i+1), 10, "USD"))
+				if reached := next.calls > calls; reached != st.reached {
+					t.Errorf("step %d: reached the processor %v, want %v", i+1, reached, st.reached)
+				}
+				if !errors.Is(err, st.wantErr) || (st.wantErr == nil && err != nil) {
+					t.Errorf("step %d: err = %v, want %v", i+1, err, st.wantErr)
+				}
+				if errors.Is(err, ErrCircuitOpen) != errors.Is(st.wantErr, ErrCircuitOpen) {
+					t.Errorf("step %d: err = %v, want %v", i+1, err, st.wantErr)
+				}
+				if got := breaker.State(); got != st.want {
+					t.Fatalf("step %d: state %s, want %s", i+1, got, st.want)
+				}
+			}
+		})
+	}
+}
+
+// Only one trial call goes through while half-open; the rest are rejected
+// until it reports back. Run with -race: the clock moves while calls are made.
+func TestCircuitBreakerHalfOpenUnderConcurrency(t *testing.T) {
+	clock := &ManualClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	var trials atomic.Int32
+	release := make(chan struct{})
+	next := ProcessorFunc(func(ctx context.Context, payment *Payment) (Receipt, error) {
+		if payment.id == "PAY-OPEN" {
+			return Receipt{}, ErrNetwork
+		}
+		trials.Add(1)
+		<-release
+		return newReceipt("slow", "ref", payment), nil
+	})
+	breaker := NewCircuitBreakerProcessor(next, BreakerSettings{Window: 1, FailureRate: 1, CoolDown: time.Minute}, clock)
+	breaker.ProcessPayment(context.Background(), NewPayment("PAY-OPEN", 10, "USD"))
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("state %s, want open", breaker.State())
+	}
+
+	const callers = 8
+	var wg sync.WaitGroup
+	var rejected atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := breaker.ProcessPayment(context.Background(), NewPayment("PAY-TRIAL", 10, "USD")); errors.Is(err, ErrCircuitOpen) {
+				rejected.Add(1)
+			}
+		}()
+		clock.Advance(10 * time.Second) // crosses the cool-down part-way through
+	}
+	// hold the trial until every other caller has been turned away
+	for deadline := time.Now().Add(5 * time.Second); trials.Load()+rejected.Load() < callers; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			close(release)
+			t.Fatalf("stuck at %d trials and %d rejected of %d callers", trials.Load(), rejected.Load(), callers)
+		}
+	}
+	close(release)
+	wg.Wait()
+	if trials.Load() != 1 || rejected.Load() != callers-1 {
+		t.Fatalf("%d trials and %d rejected, want 1 and %d", trials.Load(), rejected.Load(), callers-1)
+	}
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("state %s after a good trial, want closed", breaker.State())
+	}
+}
+
+func TestSubstitutability(t *testing.T) {
+	gateway := httptest.NewServer(NewMockGateway())
+	defer gateway.Close()
+	httpProcessor := NewHTTPGatewayProcessor(gateway.URL, time.Second)
+	registeredCard, err := NewProcessor("credit_card")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registeredPayPal, err := NewProcessor("paypal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decorated := NewRetryingProcessor(
+		NewTimingProcessor(
+			NewCircuitBreakerProcessor(registeredCard, BreakerSettings{Window: 4, FailureRate: 0.5, CoolDown: time.Second}, SystemClock{}),
+			func(string, time.Duration) {}),
+		RetryPolicy{MaxAttempts: 3})
+
+	for _, tc := range []struct {
+		name       string
+		processor  PaymentProcessor
+		refunder   RefundProcessor
+		currencies []string
+	}{
+		{"credit card", &CreditCardProcessor{}, &CreditCardRefundProcessor{}, []string{"USD", "EUR", "JPY"}},
+		{"registered credit card", registeredCard, &CreditCardRefundProcessor{}, []string{"USD", "BHD"}},
+		{"decorated credit card", decorated, &CreditCardRefundProcessor{}, []string{"USD"}},
+		{"paypal", &PayPalProcessor{}, &PayPalRefundProcessor{}, []string{"USD", "EUR", "GBP"}},
+		{"registered paypal", registeredPayPal, &PayPalRefundProcessor{}, []string{"USD", "EUR", "GBP"}},
+		{"http gateway", httpProcessor, httpProcessor, []string{"USD", "EUR"}},
+	} {
+		for _, currency := range tc.currencies {
+			t.Run(tc.name+"/"+currency, func(t *testing.T) {
+				if err := checkSubstitutability(tc.processor, tc.refunder, currency); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+// CappedRefundProcessor is kept as the counter-example: the same check must catch it
+func TestCappedRefunderIsNotSubstitutable(t *testing.T) {
+	err := checkSubstitutability(&CreditCardProcessor{}, &CappedRefundProcessor{max: 100}, "USD")
+	if !errors.Is(err, ErrPaymentDeclined) {
+		t.Fatalf("got %v, want the capped refund to be declined", err)
+	}
+}
+
+// netProcessor captures everything at no fee and allocates nothing itself,
+// so the benchmarks below measure only the payments
+type netProcessor struct{}
+
+func (netProcessor) ProcessPayment(ctx context.Context, payment *Payment) (Receipt, error) {
+	if payment.amount.minor%97 == 0 {
+		return Receipt{}, fmt.Errorf("%w: %s", ErrPaymentDeclined, payment.id)
+	}
+	return Receipt{PaymentID: payment.id, Amount: payment.amount, Fees: FeeBreakdown{Net: payment.amount}}, nil
+}
+
+func settlementRequests(n int) []ChargeRequest {
+	requests := make([]ChargeRequest, n)
+	for i := range requests {
+		requests[i] = ChargeRequest{ID: fmt.Sprintf("SET-%d", i), Amount: float64(i%500 + 1), Currency: "USD"}
+	}
+	return requests
+}
+
+func TestSettlementRun(t *testing.T) {
+	requests := append(settlementRequests(1000), ChargeRequest{ID: "SET-BAD", Amount: -1, Currency: "USD"},
+		ChargeRequest{ID: "SET-EUR", Amount: 10, Currency: "EUR"})
+	var want int64
+	wantFailed := 1
+	for _, r := range requests[:1000] {
+		if minor := NewMoney(r.Amount, r.Currency).minor; minor%97 == 0 {
+			wantFailed++
+		} else {
+			want += minor
+		}
+	}
+	for _, pooled := range []bool{false, true} {
+		result := NewSettlementRun(netProcessor{}, pooled).Run(context.Background(), requests)
+		if result.Settled != len(requests)-wantFailed || result.Failed != wantFailed {
+			t.Errorf("pooled=%v: settled %d failed %d, want %d and %d", pooled, result.Settled, result.Failed, len(requests)-wantFailed, wantFailed)
+		}
+		if result.Net["USD"] != (Money{minor: want, currency: "USD"}) || result.Net["EUR"] != NewMoney(10, "EUR") {
+			t.Errorf("pooled=%v: net %v", pooled, result.Net)
+		}
+	}
+}
+
+func TestPooledPaymentStartsClean(t *testing.T) {
+	p := acquirePayment("PAY-1", 10, "USD").ForAccount("alice").FromCountry("DE")
+	p.Authorize()
+	p.Capture()
+	releasePayment(p)
+	for i := 0; i < 10; i++ { // the pool may hand back any released payment
+		q := acquirePayment("PAY-2", 5, "EUR")
+		if q.account != "" || q.country != "" || q.Status() != StatusPending || len(q.history) != 1 || q.amount != NewMoney(5, "EUR") {
+			t.Fatalf("acquired payment carries old data: %+v", q)
+		}
+		releasePayment(q)
+	}
+}
+
+// The race detector makes sync.Pool drop a share of what is put back, so the
+// pooled run is held to a fraction of the per-payment run rather than to zero
+func TestSettlementPoolAllocations(t *testing.T) {
+	requests := settlementRequests(100)
+	perPayment := func(pooled bool) float64 {
+		run := NewSettlementRun(netProcessor{}, pooled)
+		return testing.AllocsPerRun(20, func() { run.Run(context.Background(), requests) }) / float64(len(requests))
+	}
+	plain, pooled := perPayment(false), perPayment(true)
+	if plain < 2 {
+		t.Errorf("per-payment run: %.2f allocs per payment, want at least a Payment and its history", plain)
+	}
+	if pooled > plain/2 {
+		t.Errorf("pooled run: %.2f allocs per payment, want well under the %.2f of the per-payment run", pooled, plain)
+	}
+}
+
+// The settlement benchmarks run a million charges through ExecuteBatch, the
+// scale the pool is for. allocs/op shows the Payments saved; gc/op and
+// gc-pause-ns/op show what that is worth to the collector:
+//
+//	go test -run XXX -bench Settlement -benchmem example.go example_test.go
+func BenchmarkSettlementPerPayment(b *testing.B) {
+	benchmarkSettlement(b, false)
+}
+
+func BenchmarkSettlementPooled(b *testing.B) {
+	benchmarkSettlement(b, true)
+}
+
+var millionRequests = sync.OnceValue(func() []ChargeRequest { return settlementRequests(1_000_000) })
+
+func benchmarkSettlement(b *testing.B, pooled bool) {
+	requests := millionRequests()
+	run := NewSettlementRun(netProcessor{}, pooled)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		run.Run(context.Background(), requests)
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
+
+func sampleReceipts() []Receipt {
+	var receipts []Receipt
+	for _, tc := range []struct {
+		amount   float64
+		currency string
+		fees     FeeStrategy
+	}{
+		{40, "USD", PercentageFee{Percent: 2.9}},
+		{0.10, "USD", FlatFee{Amount: 0.25}},
+		{1500, "JPY", PercentageFee{Percent: 3.49}},
+		{12.345, "BHD", PercentageFee{Percent: 1}},
+		{250, "EUR", NewTieredFee(FeeTier{UpTo: 100, Strategy: PercentageFee{Percent: 2.9}}, FeeTier{Strategy: PercentageFee{Percent: 2.4}})},
+	} {
+		amount := NewMoney(tc.amount, tc.currency)
+		receipts = append(receipts, Receipt{PaymentID: "PAY-001", Processor: "credit_card", Amount: amount, Fees: ApplyFee(tc.fees, amount)})
+	}
+	return receipts
+}
+
+func TestAppendReceiptMatchesString(t *testing.T) {
+	for _, receipt := range sampleReceipts() {
+		if got, want := string(receipt.AppendReceipt(nil)), receipt.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	for _, m := range []Money{{minor: -5, currency: "USD"}, {minor: 7, currency: "BHD"}, {currency: "JPY"}} {
+		if got, want := string(m.AppendTo(nil)), m.String(); got != want {
+			t.Errorf("Money: got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestAppendReceiptAllocations(t *testing.T) {
+	receipt := sampleReceipts()[0]
+	buf := make([]byte, 0, 256)
+	if allocs := testing.AllocsPerRun(100, func() { buf = receipt.AppendReceipt(buf[:0]) }); allocs != 0 {
+		t.Errorf("AppendReceipt into a reused buffer: %.0f allocs/op, want 0", allocs)
+	}
+}
+
+var receiptSink string
+
+func BenchmarkReceiptString(b *testing.B) {
+	receipt := sampleReceipts()[0]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		receiptSink = receipt.String()
+	}
+}
+
+func BenchmarkReceiptAppend(b *testing.B) {
+	receipt := sampleReceipts()[0]
+	buf := make([]byte, 0, 256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = receipt.AppendReceipt(buf[:0])
+	}
+}
@@ -0,0 +1,243 @@
+// Interface Segregation Principle - Go
+// Flow: fat Machine interface (problem) -> stubbed methods failing at runtime ->
+// Printer / Scanner / Faxer -> clients and test doubles that need one method
+//
+// The same split already runs the payment example: Notifier and Logger are
+// separate, so a service that only notifies never depends on logging.
+//
+// Run: go run example.go
+// Test: go test example.go example_test.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type Document struct {
+	Name  string
+	Pages int
+}
+
+var ErrNotSupported = errors.New("operation not supported by this machine")
+
+// ============================================================================
+// 1. VIOLATION - one interface for every office machine
+// ============================================================================
+
+type Machine interface {
+	Print(doc Document) error
+	Scan(doc Document) (Document, error)
+	Fax(doc Document, number string) error
+}
+
+type MultiFunctionPrinter struct {
+	log []string
+}
+
+func (m *MultiFunctionPrinter) Print(doc Document) error {
+	m.log = append(m.log, "print "+doc.Name)
+	return nil
+}
+
+func (m *MultiFunctionPrinter) Scan(doc Document) (Document, error) {
+	m.log = append(m.log, "scan "+doc.Name)
+	return Document{Name: doc.Name + ".pdf", Pages: doc.Pages}, nil
+}
+
+func (m *MultiFunctionPrinter) Fax(doc Document, number string) error {
+	m.log = append(m.log, "fax "+doc.Name+" to "+number)
+	return nil
+}
+
+// BasicPrinter can only print, but Machine forces it to stub the rest.
+// The compiler is satisfied; callers find out at runtime.
+type BasicPrinter struct {
+	printed int
+}
+
+func (b *BasicPrinter) Print(doc Document) error {
+	b.printed += doc.Pages
+	return nil
+}
+
+func (b *BasicPrinter) Scan(doc Document) (Document, error) {
+	return Document{}, fmt.Errorf("%w: scan", ErrNotSupported)
+}
+
+func (b *BasicPrinter) Fax(doc Document, number string) error {
+	return fmt.Errorf("%w: fax", ErrNotSupported)
+}
+
+// PrintReport only prints, yet depends on all of Machine
+func PrintReport(m Machine, docs ...Document) error {
+	for _, doc := range docs {
+		if err := m.Print(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchiveDocuments compiles with a BasicPrinter and fails when it runs
+func ArchiveDocuments(m Machine, docs ...Document) ([]Document, error) {
+	var archived []Document
+	for _, doc := range docs {
+		scanned, err := m.Scan(doc)
+		if err != nil {
+			return archived, err
+		}
+		archived = append(archived, scanned)
+	}
+	return archived, nil
+}
+
+// ============================================================================
+// 2. FIX - one small interface per capability, composed where needed
+// ============================================================================
+
+type Printer interface {
+	Print(doc Document) error
+}
+
+type Scanner interface {
+	Scan(doc Document) (Document, error)
+}
+
+type Faxer interface {
+	Fax(doc Document, number string) error
+}
+
+// MultiFunctionDevice is for the rare client that really needs everything
+type MultiFunctionDevice interface {
+	Printer
+	Scanner
+	Faxer
+}
+
+// SimplePrinter has no stubs: it implements Printer and nothing else
+type SimplePrinter struct {
+	printed int
+}
+
+func (s *SimplePrinter) Print(doc Document) error {
+	s.printed += doc.Pages
+	return nil
+}
+
+// Compile-time checks: what each type can be used as, stated once
+var (
+	_ MultiFunctionDevice = (*MultiFunctionPrinter)(nil)
+	_ Printer             = (*SimplePrinter)(nil)
+)
+
+// Clients ask only for the capability they use
+func PrintAll(p Printer, docs ...Document) error {
+	for _, doc := range docs {
+		if err := p.Print(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Archive cannot be given a SimplePrinter: that mistake no longer compiles
+func Archive(s Scanner, docs ...Document) ([]Document, error) {
+	var archived []Document
+	for _, doc := range docs {
+		scanned, err := s.Scan(doc)
+		if err != nil {
+			return archived, err
+		}
+		archived = append(archived, scanned)
+	}
+	return archived, nil
+}
+
+// SendOrPrint discovers an optional capability instead of assuming it,
+// like io.Copy checking for io.WriterTo
+func SendOrPrint(p Printer, doc Document, number string) (string, error) {
+	if faxer, ok := p.(Faxer); ok {
+		return "faxed", faxer.Fax(doc, number)
+	}
+	return "printed for mailing", p.Print(doc)
+}
+
+// ============================================================================
+// 3. CHECKS - test doubles show what each client really depends on
+// ============================================================================
+
+// printSpy is all a test of PrintAll needs: one method
+type printSpy struct {
+	names []string
+}
+
+func (s *printSpy) Print(doc Document) error {
+	s.names = append(s.names, doc.Name)
+	return nil
+}
+
+// machineSpy is what a test of PrintReport needs: two methods it never calls
+type machineSpy struct {
+	printSpy
+}
+
+func (machineSpy) Scan(Document) (Document, error) { panic("PrintReport must not scan") }
+func (machineSpy) Fax(Document, string) error      { panic("PrintReport must not fax") }
+
+// dependsOn lists the methods a client's parameter type makes callers provide
+func dependsOn[T any]() string {
+	t := reflect.TypeFor[T]()
+	names := make([]string, t.NumMethod())
+	for i := range names {
+		names[i] = t.Method(i).Name
+	}
+	return fmt.Sprintf("%-20s %d method(s): %s", t.Name(), t.NumMethod(), strings.Join(names, ", "))
+}
+
+func check(name string, ok bool, detail string) {
+	status := "PASS"
+	if !ok {
+		status = "FAIL"
+	}
+	fmt.Printf("  %s %-34s %s\n", status, name, detail)
+}
+
+func main() {
+	report := []Document{{Name: "q1-report", Pages: 12}, {Name: "q2-report", Pages: 9}}
+
+	fmt.Println("1. Fat Machine interface:")
+	basic := &BasicPrinter{}
+	err := PrintReport(basic, report...)
+	check("PrintReport(BasicPrinter)", err == nil, fmt.Sprintf("%d pages", basic.printed))
+	_, err = ArchiveDocuments(basic, report...)
+	check("ArchiveDocuments(BasicPrinter)", err == nil, fmt.Sprintf("compiled, then: %v", err))
+	spy := &machineSpy{}
+	err = PrintReport(spy, report...)
+	check("PrintReport(machineSpy)", err == nil, "test double had to stub Scan and Fax")
+
+	fmt.Println("2. Segregated interfaces:")
+	simple := &SimplePrinter{}
+	err = PrintAll(simple, report...)
+	check("PrintAll(SimplePrinter)", err == nil, fmt.Sprintf("%d pages", simple.printed))
+	mfp := &MultiFunctionPrinter{}
+	archived, err := Archive(mfp, report...)
+	check("Archive(MultiFunctionPrinter)", err == nil && len(archived) == 2, fmt.Sprint(archived))
+	// Archive(simple, report...) would not compile: *SimplePrinter does not implement Scanner
+	for _, p := range []Printer{simple, mfp} {
+		how, err := SendOrPrint(p, Document{Name: "contract", Pages: 3}, "+1-555-0100")
+		check(fmt.Sprintf("SendOrPrint(%T)", p), err == nil, how)
+	}
+	fmt.Printf("  machine log: %v\n", mfp.log)
+
+	fmt.Println("3. What each client makes its callers and tests implement:")
+	printOnly := &printSpy{}
+	err = PrintAll(printOnly, report...)
+	check("PrintAll(printSpy)", err == nil && len(printOnly.names) == 2, fmt.Sprint(printOnly.names))
+	fmt.Println("  PrintReport:", dependsOn[Machine]())
+	fmt.Println("  PrintAll:   ", dependsOn[Printer]())
+	fmt.Println("  Archive:    ", dependsOn[Scanner]())
+}
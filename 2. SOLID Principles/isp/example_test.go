@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+var report = []Document{{Name: "q1-report", Pages: 12}, {Name: "q2-report", Pages: 9}}
+
+// A test of PrintAll needs a one-method double
+func TestPrintAllNeedsOnlyAPrinter(t *testing.T) {
+	spy := &printSpy{}
+	if err := PrintAll(spy, report...); err != nil {
+		t.Fatal(err)
+	}
+	if len(spy.names) != 2 || spy.names[0] != "q1-report" || spy.names[1] != "q2-report" {
+		t.Fatalf("printed %v", spy.names)
+	}
+}
+
+// A test of PrintReport needs a double that stubs Scan and Fax it never calls
+func TestPrintReportNeedsAWholeMachine(t *testing.T) {
+	spy := &machineSpy{}
+	if err := PrintReport(spy, report...); err != nil {
+		t.Fatal(err)
+	}
+	if len(spy.names) != 2 {
+		t.Fatalf("printed %v", spy.names)
+	}
+}
+
+// The fat interface lets a BasicPrinter into ArchiveDocuments; it compiles and
+// the stub fails at runtime. The segregated Archive rejects it at compile time.
+func TestFatInterfaceFailsAtRuntime(t *testing.T) {
+	basic := &BasicPrinter{}
+	if err := PrintReport(basic, report...); err != nil || basic.printed != 21 {
+		t.Fatalf("PrintReport: %v, %d pages", err, basic.printed)
+	}
+	archived, err := ArchiveDocuments(basic, report...)
+	if !errors.Is(err, ErrNotSupported) || len(archived) != 0 {
+		t.Fatalf("ArchiveDocuments: got %v, %v; want ErrNotSupported", archived, err)
+	}
+	if _, ok := any(&SimplePrinter{}).(Scanner); ok {
+		t.Fatal("SimplePrinter must not be usable as a Scanner")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	mfp := &MultiFunctionPrinter{}
+	archived, err := Archive(mfp, report...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Document{{Name: "q1-report.pdf", Pages: 12}, {Name: "q2-report.pdf", Pages: 9}}
+	if len(archived) != len(want) || archived[0] != want[0] || archived[1] != want[1] {
+		t.Fatalf("got %v, want %v", archived, want)
+	}
+}
+
+func TestSendOrPrintDiscoversFax(t *testing.T) {
+	doc := Document{Name: "contract", Pages: 3}
+	for _, tc := range []struct {
+		printer Printer
+		want    string
+	}{
+		{&SimplePrinter{}, "printed for mailing"},
+		{&MultiFunctionPrinter{}, "faxed"},
+	} {
+		how, err := SendOrPrint(tc.printer, doc, "+1-555-0100")
+		if err != nil || how != tc.want {
+			t.Errorf("%T: got %q, %v; want %q", tc.printer, how, err, tc.want)
+		}
+	}
+}
+
+func TestClientDependencies(t *testing.T) {
+	for _, tc := range []struct {
+		client string
+		got    string
+		want   string
+	}{
+		{"PrintReport", dependsOn[Machine](), "Machine              3 method(s): Fax, Print, Scan"},
+		{"PrintAll", dependsOn[Printer](), "Printer              1 method(s): Print"},
+		{"Archive", dependsOn[Scanner](), "Scanner              1 method(s): Scan"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.client, tc.got, tc.want)
+		}
+	}
+}
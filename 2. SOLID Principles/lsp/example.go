@@ -0,0 +1,321 @@
+// Liskov Substitution Principle - Go
+// Flow: Rectangle/Square violation -> immutable shapes that pass ->
+// processors that break the charge/refund contract -> split interfaces that pass
+//
+// Every design is run through the same substitutability checks, so the
+// violations show up as FAIL lines and the fixes as PASS lines.
+//
+// Run: go run example.go
+// Test: go test example.go example_test.go (add -violations to watch the violating designs fail)
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// 1. VIOLATION - a Square is a Rectangle mathematically, but not behaviourally
+// ============================================================================
+
+// MutableRectangle is the contract callers rely on: setting the width leaves
+// the height alone, and the area is width * height
+type MutableRectangle interface {
+	SetWidth(w float64)
+	SetHeight(h float64)
+	Width() float64
+	Height() float64
+	Area() float64
+}
+
+type Rectangle struct {
+	width, height float64
+}
+
+func (r *Rectangle) SetWidth(w float64)  { r.width = w }
+func (r *Rectangle) SetHeight(h float64) { r.height = h }
+func (r *Rectangle) Width() float64      { return r.width }
+func (r *Rectangle) Height() float64     { return r.height }
+func (r *Rectangle) Area() float64       { return r.width * r.height }
+
+// Square keeps its sides equal, so each setter silently changes the other side
+type Square struct {
+	Rectangle
+}
+
+func (s *Square) SetWidth(w float64)  { s.width, s.height = w, w }
+func (s *Square) SetHeight(h float64) { s.width, s.height = h, h }
+
+// CheckRectangleContract is written against MutableRectangle only; any
+// implementation must pass it for callers to be able to substitute it
+func CheckRectangleContract(r MutableRectangle) error {
+	r.SetWidth(5)
+	r.SetHeight(4)
+	if r.Width() != 5 {
+		return fmt.Errorf("SetHeight changed the width to %g", r.Width())
+	}
+	if got := r.Area(); got != 20 {
+		return fmt.Errorf("area after 5 x 4 is %g, want 20", got)
+	}
+	return nil
+}
+
+// ============================================================================
+// 2. FIX - immutable shapes; resizing returns a new value of the right kind
+// ============================================================================
+
+// Shape promises only what every shape can keep: an area, and scaling by k
+// multiplies that area by k*k
+type Shape interface {
+	Area() float64
+	Scale(k float64) Shape
+}
+
+type Rect struct {
+	width, height float64
+}
+
+func NewRect(width, height float64) Rect { return Rect{width: width, height: height} }
+
+func (r Rect) Area() float64         { return r.width * r.height }
+func (r Rect) Scale(k float64) Shape { return Rect{r.width * k, r.height * k} }
+
+// WithWidth is only on Rect: changing one side is not something a Square can do
+func (r Rect) WithWidth(w float64) Rect { return Rect{w, r.height} }
+
+type Sq struct {
+	side float64
+}
+
+func NewSq(side float64) Sq { return Sq{side: side} }
+
+func (s Sq) Area() float64         { return s.side * s.side }
+func (s Sq) Scale(k float64) Shape { return Sq{s.side * k} }
+
+// WithSide returns a Square, so a square stays a square
+func (s Sq) WithSide(side float64) Sq { return Sq{side} }
+
+// AsRect is the honest conversion when a caller really needs a rectangle
+func (s Sq) AsRect() Rect { return Rect{s.side, s.side} }
+
+func CheckShapeContract(s Shape) error {
+	before := s.Area()
+	scaled := s.Scale(3)
+	if got, want := scaled.Area(), before*9; got != want {
+		return fmt.Errorf("area after scaling by 3 is %g, want %g", got, want)
+	}
+	if s.Area() != before {
+		return fmt.Errorf("Scale changed the original: area %g, was %g", s.Area(), before)
+	}
+	return nil
+}
+
+// ============================================================================
+// 3. VIOLATION - processors that weaken the charge/refund contract
+// ============================================================================
+
+var (
+	ErrRefundsNotSupported = errors.New("refunds not supported")
+	ErrUnknownCharge       = errors.New("unknown charge")
+)
+
+// PaymentProcessor's contract: Charge takes exactly amount, and any charge it
+// made can be refunded in full
+type PaymentProcessor interface {
+	Charge(amount float64) (chargeID string, err error)
+	Refund(chargeID string) error
+	Charged(chargeID string) float64
+}
+
+// ledger is the shared bookkeeping of the demo processors
+type ledger struct {
+	charges map[string]float64
+	next    int
+}
+
+func (l *ledger) record(prefix string, amount float64) string {
+	if l.charges == nil {
+		l.charges = make(map[string]float64)
+	}
+	l.next++
+	id := fmt.Sprintf("%s-%d", prefix, l.next)
+	l.charges[id] = amount
+	return id
+}
+
+func (l *ledger) Charged(chargeID string) float64 { return l.charges[chargeID] }
+
+func (l *ledger) Refund(chargeID string) error {
+	if _, ok := l.charges[chargeID]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCharge, chargeID)
+	}
+	delete(l.charges, chargeID)
+	return nil
+}
+
+type CardProcessor struct{ ledger }
+
+func (p *CardProcessor) Charge(amount float64) (string, error) {
+	return p.record("card", amount), nil
+}
+
+// FreePaymentProcessor handles free trials. It can't refund, so it breaks
+// the promise every caller of PaymentProcessor.Refund relies on.
+type FreePaymentProcessor struct{ ledger }
+
+func (p *FreePaymentProcessor) Charge(amount float64) (string, error) {
+	return p.record("free", 0), nil
+}
+
+func (p *FreePaymentProcessor) Refund(chargeID string) error {
+	return fmt.Errorf("%w: %s", ErrRefundsNotSupported, chargeID)
+}
+
+// FlatFeeProcessor quietly adds its fee to what it charges, breaking the
+// postcondition that Charge takes exactly amount
+type FlatFeeProcessor struct {
+	ledger
+	fee float64
+}
+
+func (p *FlatFeeProcessor) Charge(amount float64) (string, error) {
+	return p.record("flat", amount+p.fee), nil
+}
+
+func CheckProcessorContract(p PaymentProcessor) error {
+	id, err := p.Charge(40)
+	if err != nil {
+		return fmt.Errorf("charge: %w", err)
+	}
+	var violations []error
+	if got := p.Charged(id); got != 40 {
+		violations = append(violations, fmt.Errorf("charged %.2f for a 40.00 payment", got))
+	}
+	if err := p.Refund(id); err != nil {
+		violations = append(violations, fmt.Errorf("refund of its own charge: %w", err))
+	}
+	return errors.Join(violations...)
+}
+
+// ============================================================================
+// 4. FIX - promise less, in smaller interfaces, and report fees separately
+// ============================================================================
+
+// Receipt states the amount charged and, separately, the fee kept from it
+type Receipt struct {
+	ID      string
+	Charged float64
+	Fee     float64
+}
+
+// Charger only promises to charge exactly amount
+type Charger interface {
+	Charge(amount float64) (Receipt, error)
+}
+
+// RefundableCharger adds the refund promise; only processors that keep it implement it
+type RefundableCharger interface {
+	Charger
+	Refund(chargeID string) error
+}
+
+type Card struct{ ledger }
+
+func (c *Card) Charge(amount float64) (Receipt, error) {
+	return Receipt{ID: c.record("card", amount), Charged: amount}, nil
+}
+
+// FreeTrial charges nothing, so amount must be zero - and it has no Refund
+// method for anyone to be surprised by
+type FreeTrial struct {
+	started int
+}
+
+func (f *FreeTrial) Charge(amount float64) (Receipt, error) {
+	if amount != 0 {
+		return Receipt{}, fmt.Errorf("free trial cannot charge %.2f", amount)
+	}
+	f.started++
+	return Receipt{ID: fmt.Sprintf("trial-%d", f.started)}, nil
+}
+
+// FlatFee charges exactly amount and reports its fee on the receipt instead
+type FlatFee struct {
+	ledger
+	fee float64
+}
+
+func NewFlatFee(fee float64) *FlatFee { return &FlatFee{fee: fee} }
+
+func (f *FlatFee) Charge(amount float64) (Receipt, error) {
+	return Receipt{ID: f.record("flat", amount), Charged: amount, Fee: min(f.fee, amount)}, nil
+}
+
+// CheckChargerContract holds for every Charger; a processor that can't take
+// the amount says so with an error rather than charging something else
+func CheckChargerContract(c Charger, amount float64) error {
+	receipt, err := c.Charge(amount)
+	if err != nil {
+		return nil // declining is allowed, charging a different amount is not
+	}
+	if receipt.Charged != amount {
+		return fmt.Errorf("charged %.2f for a %.2f payment", receipt.Charged, amount)
+	}
+	if receipt.Fee < 0 || receipt.Fee > receipt.Charged {
+		return fmt.Errorf("fee %.2f outside 0..%.2f", receipt.Fee, receipt.Charged)
+	}
+	return nil
+}
+
+// CheckRefundContract holds for every RefundableCharger
+func CheckRefundContract(r RefundableCharger) error {
+	receipt, err := r.Charge(40)
+	if err != nil {
+		return fmt.Errorf("charge: %w", err)
+	}
+	if err := r.Refund(receipt.ID); err != nil {
+		return fmt.Errorf("refund of its own charge: %w", err)
+	}
+	return nil
+}
+
+func report(name string, err error) {
+	if err != nil {
+		fmt.Printf("  FAIL %-22s %s\n", name, strings.ReplaceAll(err.Error(), "\n", "; "))
+		return
+	}
+	fmt.Printf("  PASS %s\n", name)
+}
+
+func main() {
+	fmt.Println("1. Rectangle contract, mutable design:")
+	report("Rectangle", CheckRectangleContract(&Rectangle{}))
+	report("Square", CheckRectangleContract(&Square{}))
+
+	fmt.Println("2. Shape contract, immutable design:")
+	for _, s := range []Shape{NewRect(5, 4), NewSq(3), NewRect(2, 8).WithWidth(3), NewSq(2).WithSide(6)} {
+		report(fmt.Sprintf("%T %v", s, s), CheckShapeContract(s))
+	}
+	fmt.Printf("  a square used as a rectangle: %v\n", NewSq(4).AsRect().WithWidth(6))
+
+	fmt.Println("3. Processor contract, one fat interface:")
+	report("CardProcessor", CheckProcessorContract(&CardProcessor{}))
+	report("FreePaymentProcessor", CheckProcessorContract(&FreePaymentProcessor{}))
+	report("FlatFeeProcessor", CheckProcessorContract(&FlatFeeProcessor{fee: 0.30}))
+
+	fmt.Println("4. Processor contracts, split interfaces:")
+	chargers := map[string]Charger{"Card": &Card{}, "FreeTrial": &FreeTrial{}, "FlatFee": NewFlatFee(0.30)}
+	for _, name := range []string{"Card", "FreeTrial", "FlatFee"} {
+		charger := chargers[name]
+		for _, amount := range []float64{0, 0.10, 40} {
+			report(fmt.Sprintf("%s charge %.2f", name, amount), CheckChargerContract(charger, amount))
+		}
+		if refundable, ok := charger.(RefundableCharger); ok {
+			report(name+" refund", CheckRefundContract(refundable))
+		} else {
+			fmt.Printf("  n/a  %s refund: not a RefundableCharger, so no caller can ask\n", name)
+		}
+	}
+}
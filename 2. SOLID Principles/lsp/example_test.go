@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+// The violating designs are kept in example.go on purpose, so the tests that
+// hold them to their interface's contract fail. They are skipped unless asked for:
+//
+//	go test example.go example_test.go -violations
+var violations = flag.Bool("violations", false, "run the contract tests the violating designs are expected to fail")
+
+func skipViolation(t *testing.T, why string) {
+	t.Helper()
+	if !*violations {
+		t.Skip("expected to fail, run with -violations to see it: " + why)
+	}
+}
+
+func TestRectangleContract(t *testing.T) {
+	if err := CheckRectangleContract(&Rectangle{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSquareIsARectangle(t *testing.T) {
+	skipViolation(t, "Square's setters change both sides, so SetHeight changes the width")
+	if err := CheckRectangleContract(&Square{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShapeContract(t *testing.T) {
+	for _, s := range []Shape{NewRect(5, 4), NewSq(3), NewRect(2, 8).WithWidth(3), NewSq(2).WithSide(6), NewSq(4).AsRect()} {
+		if err := CheckShapeContract(s); err != nil {
+			t.Errorf("%T %v: %v", s, s, err)
+		}
+	}
+}
+
+func TestProcessorContract(t *testing.T) {
+	if err := CheckProcessorContract(&CardProcessor{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFreePaymentProcessorIsAPaymentProcessor(t *testing.T) {
+	skipViolation(t, "FreePaymentProcessor cannot refund the charges it makes")
+	if err := CheckProcessorContract(&FreePaymentProcessor{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlatFeeProcessorIsAPaymentProcessor(t *testing.T) {
+	skipViolation(t, "FlatFeeProcessor adds its fee to the amount it charges")
+	if err := CheckProcessorContract(&FlatFeeProcessor{fee: 0.30}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The checks are only worth something if they catch the violations, so this
+// runs by default and expects each violating design to fail the right way
+func TestContractsCatchViolations(t *testing.T) {
+	if err := CheckRectangleContract(&Square{}); err == nil {
+		t.Error("Square passed the Rectangle contract")
+	}
+	if err := CheckProcessorContract(&FreePaymentProcessor{}); !errors.Is(err, ErrRefundsNotSupported) {
+		t.Errorf("FreePaymentProcessor: got %v, want ErrRefundsNotSupported", err)
+	}
+	if err := CheckProcessorContract(&FlatFeeProcessor{fee: 0.30}); err == nil {
+		t.Error("FlatFeeProcessor passed the processor contract")
+	}
+}
+
+func TestChargerContract(t *testing.T) {
+	for name, charger := range map[string]Charger{"Card": &Card{}, "FreeTrial": &FreeTrial{}, "FlatFee": NewFlatFee(0.30)} {
+		for _, amount := range []float64{0, 0.10, 40} {
+			if err := CheckChargerContract(charger, amount); err != nil {
+				t.Errorf("%s charge %.2f: %v", name, amount, err)
+			}
+		}
+	}
+}
+
+func TestRefundContract(t *testing.T) {
+	for name, refundable := range map[string]RefundableCharger{"Card": &Card{}, "FlatFee": NewFlatFee(0.30)} {
+		if err := CheckRefundContract(refundable); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, ok := Charger(&FreeTrial{}).(RefundableCharger); ok {
+		t.Error("FreeTrial must not promise refunds it cannot make")
+	}
+}
+
+func TestFlatFeeIsReportedNotCharged(t *testing.T) {
+	receipt, err := NewFlatFee(0.30).Charge(0.10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Charged != 0.10 || receipt.Fee != 0.10 {
+		t.Fatalf("got charged %.2f fee %.2f, want 0.10 and a fee capped at 0.10", receipt.Charged, receipt.Fee)
+	}
+}
@@ -0,0 +1,322 @@
+// Single Responsibility Principle - Go
+// Flow: ReportManager god object (problem) -> ReportBuilder / ReportRenderer /
+// ReportSender / ReportStore -> checks that the refactoring preserved behaviour
+//
+// Each type in the fix has one reason to change: where the data comes from,
+// how a report looks, how it is delivered, or where it is kept.
+//
+// Run: go run example.go
+// Test: go test example.go example_test.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Sale struct {
+	Period string // e.g. "2024-Q1"
+	Region string
+	Amount float64
+}
+
+// Email and the file map stand in for SMTP and a disk, so both designs can be
+// run side by side and compared
+type Email struct {
+	To, Subject, Body string
+}
+
+var ErrNoSales = errors.New("no sales in period")
+
+// ============================================================================
+// 1. VIOLATION - one type fetches, computes, formats, emails and saves
+// ============================================================================
+
+// ReportManager changes whenever the database, the layout, the mail system or
+// the storage changes, and none of those can be tested on its own
+type ReportManager struct {
+	database []Sale
+	outbox   []Email
+	files    map[string]string
+}
+
+func NewReportManager(database []Sale) *ReportManager {
+	return &ReportManager{database: database, files: make(map[string]string)}
+}
+
+func (m *ReportManager) GenerateAndSend(period, recipient string) error {
+	// fetch
+	totals := make(map[string]float64)
+	var grand float64
+	count := 0
+	for _, sale := range m.database {
+		if sale.Period == period {
+			totals[sale.Region] += sale.Amount
+			grand += sale.Amount
+			count++
+		}
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s", ErrNoSales, period)
+	}
+	// format
+	regions := make([]string, 0, len(totals))
+	for region := range totals {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sales report %s\n", period)
+	for _, region := range regions {
+		fmt.Fprintf(&b, "  %-8s %10.2f\n", region, totals[region])
+	}
+	fmt.Fprintf(&b, "  %-8s %10.2f (%d sales)\n", "TOTAL", grand, count)
+	// email
+	if !strings.Contains(recipient, "@") {
+		return fmt.Errorf("invalid recipient %q", recipient)
+	}
+	m.outbox = append(m.outbox, Email{To: recipient, Subject: "Sales report " + period, Body: b.String()})
+	// persist
+	m.files["reports/"+period+".txt"] = b.String()
+	return nil
+}
+
+// ============================================================================
+// 2. FIX - one type per responsibility, wired through interfaces
+// ============================================================================
+
+// Report is plain data passed between the collaborators
+type Report struct {
+	Period  string
+	Regions []RegionTotal // sorted by region
+	Total   float64
+	Count   int
+}
+
+type RegionTotal struct {
+	Region string
+	Amount float64
+}
+
+// SalesSource is where sales come from: a database, an API, a fixture
+type SalesSource interface {
+	Sales(period string) ([]Sale, error)
+}
+
+type InMemorySales []Sale
+
+func (s InMemorySales) Sales(period string) ([]Sale, error) {
+	var matched []Sale
+	for _, sale := range s {
+		if sale.Period == period {
+			matched = append(matched, sale)
+		}
+	}
+	return matched, nil
+}
+
+// ReportBuilder only computes: it knows nothing about layout or delivery
+type ReportBuilder struct {
+	source SalesSource
+}
+
+func NewReportBuilder(source SalesSource) *ReportBuilder {
+	return &ReportBuilder{source: source}
+}
+
+func (b *ReportBuilder) Build(period string) (Report, error) {
+	sales, err := b.source.Sales(period)
+	if err != nil {
+		return Report{}, fmt.Errorf("fetch %s: %w", period, err)
+	}
+	if len(sales) == 0 {
+		return Report{}, fmt.Errorf("%w: %s", ErrNoSales, period)
+	}
+	totals := make(map[string]float64)
+	report := Report{Period: period, Count: len(sales)}
+	for _, sale := range sales {
+		totals[sale.Region] += sale.Amount
+		report.Total += sale.Amount
+	}
+	for region, amount := range totals {
+		report.Regions = append(report.Regions, RegionTotal{Region: region, Amount: amount})
+	}
+	sort.Slice(report.Regions, func(i, j int) bool { return report.Regions[i].Region < report.Regions[j].Region })
+	return report, nil
+}
+
+// ReportRenderer decides how a report looks; a new format is a new type
+type ReportRenderer interface {
+	Render(report Report) string
+	Extension() string
+}
+
+type TextRenderer struct{}
+
+func (TextRenderer) Extension() string { return "txt" }
+
+func (TextRenderer) Render(report Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sales report %s\n", report.Period)
+	for _, r := range report.Regions {
+		fmt.Fprintf(&b, "  %-8s %10.2f\n", r.Region, r.Amount)
+	}
+	fmt.Fprintf(&b, "  %-8s %10.2f (%d sales)\n", "TOTAL", report.Total, report.Count)
+	return b.String()
+}
+
+type CSVRenderer struct{}
+
+func (CSVRenderer) Extension() string { return "csv" }
+
+func (CSVRenderer) Render(report Report) string {
+	var b strings.Builder
+	b.WriteString("region,amount\n")
+	for _, r := range report.Regions {
+		fmt.Fprintf(&b, "%s,%.2f\n", r.Region, r.Amount)
+	}
+	fmt.Fprintf(&b, "TOTAL,%.2f\n", report.Total)
+	return b.String()
+}
+
+// ReportSender delivers a rendered report
+type ReportSender interface {
+	Send(email Email) error
+}
+
+// OutboxSender collects mail instead of talking to SMTP
+type OutboxSender struct {
+	sent []Email
+}
+
+func (o *OutboxSender) Send(email Email) error {
+	if !strings.Contains(email.To, "@") {
+		return fmt.Errorf("invalid recipient %q", email.To)
+	}
+	o.sent = append(o.sent, email)
+	return nil
+}
+
+// ReportStore keeps rendered reports
+type ReportStore interface {
+	Save(name, body string) error
+}
+
+type MemoryStore map[string]string
+
+func (m MemoryStore) Save(name, body string) error {
+	m[name] = body
+	return nil
+}
+
+// ReportService only coordinates; each step is someone else's job
+type ReportService struct {
+	builder  *ReportBuilder
+	renderer ReportRenderer
+	sender   ReportSender
+	store    ReportStore
+}
+
+func NewReportService(builder *ReportBuilder, renderer ReportRenderer, sender ReportSender, store ReportStore) *ReportService {
+	return &ReportService{builder: builder, renderer: renderer, sender: sender, store: store}
+}
+
+func (s *ReportService) Publish(period, recipient string) error {
+	report, err := s.builder.Build(period)
+	if err != nil {
+		return err
+	}
+	body := s.renderer.Render(report)
+	if err := s.sender.Send(Email{To: recipient, Subject: "Sales report " + period, Body: body}); err != nil {
+		return err
+	}
+	return s.store.Save("reports/"+period+"."+s.renderer.Extension(), body)
+}
+
+// ============================================================================
+// 3. CHECKS - same inputs through both designs must give the same outputs
+// ============================================================================
+
+var sales = []Sale{
+	{"2024-Q1", "north", 1200.50},
+	{"2024-Q1", "south", 830.00},
+	{"2024-Q1", "north", 99.50},
+	{"2024-Q1", "east", 410.25},
+	{"2024-Q2", "south", 2000.00},
+}
+
+func check(name string, err error) {
+	if err != nil {
+		fmt.Printf("  FAIL %-36s %v\n", name, err)
+		return
+	}
+	fmt.Printf("  PASS %s\n", name)
+}
+
+func expect(ok bool, err error) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("unexpected result (err: %v)", err)
+}
+
+// CheckBehaviourPreserved runs one publish through each design and compares
+// everything observable: the error, the mail sent and the files written
+func CheckBehaviourPreserved(period, recipient string) error {
+	legacy := NewReportManager(sales)
+	legacyErr := legacy.GenerateAndSend(period, recipient)
+
+	outbox, store := &OutboxSender{}, MemoryStore{}
+	service := NewReportService(NewReportBuilder(InMemorySales(sales)), TextRenderer{}, outbox, store)
+	err := service.Publish(period, recipient)
+
+	switch {
+	case fmt.Sprint(legacyErr) != fmt.Sprint(err):
+		return fmt.Errorf("errors differ: %v vs %v", legacyErr, err)
+	case fmt.Sprint(legacy.outbox) != fmt.Sprint(outbox.sent):
+		return fmt.Errorf("mail differs:\n%v\n%v", legacy.outbox, outbox.sent)
+	case fmt.Sprint(legacy.files) != fmt.Sprint(map[string]string(store)):
+		return fmt.Errorf("files differ: %v vs %v", legacy.files, store)
+	}
+	return nil
+}
+
+// failingSource lets ReportBuilder be tested without a database
+type failingSource struct{}
+
+func (failingSource) Sales(string) ([]Sale, error) { return nil, errors.New("connection refused") }
+
+func main() {
+	fmt.Println("1. God object:")
+	manager := NewReportManager(sales)
+	if err := manager.GenerateAndSend("2024-Q1", "cfo@example.com"); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Print(manager.outbox[0].Body)
+
+	fmt.Println("2. Behaviour preserved by the refactoring:")
+	check("2024-Q1 to cfo@example.com", CheckBehaviourPreserved("2024-Q1", "cfo@example.com"))
+	check("2024-Q2 to cfo@example.com", CheckBehaviourPreserved("2024-Q2", "cfo@example.com"))
+	check("2023-Q4 (no sales)", CheckBehaviourPreserved("2023-Q4", "cfo@example.com"))
+	check("2024-Q1 to a bad recipient", CheckBehaviourPreserved("2024-Q1", "nobody"))
+
+	fmt.Println("3. Each collaborator on its own:")
+	_, err := NewReportBuilder(failingSource{}).Build("2024-Q1")
+	check("builder reports source errors", expect(err != nil && strings.Contains(err.Error(), "connection refused"), err))
+	fixed := Report{Period: "P", Regions: []RegionTotal{{"west", 10}}, Total: 10, Count: 1}
+	check("text renderer needs no sales data", expect(strings.Contains(TextRenderer{}.Render(fixed), "west"), nil))
+	check("csv renderer needs no sales data", expect(CSVRenderer{}.Render(fixed) == "region,amount\nwest,10.00\nTOTAL,10.00\n", nil))
+
+	fmt.Println("4. A new format touches only the renderer:")
+	store := MemoryStore{}
+	csv := NewReportService(NewReportBuilder(InMemorySales(sales)), CSVRenderer{}, &OutboxSender{}, store)
+	if err := csv.Publish("2024-Q1", "ops@example.com"); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Print(store["reports/2024-Q1.csv"])
+}
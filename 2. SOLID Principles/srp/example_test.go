@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBehaviourPreserved(t *testing.T) {
+	for _, tc := range []struct{ period, recipient string }{
+		{"2024-Q1", "cfo@example.com"},
+		{"2024-Q2", "cfo@example.com"},
+		{"2023-Q4", "cfo@example.com"}, // no sales
+		{"2024-Q1", "nobody"},          // bad recipient
+	} {
+		if err := CheckBehaviourPreserved(tc.period, tc.recipient); err != nil {
+			t.Errorf("%s to %s: %v", tc.period, tc.recipient, err)
+		}
+	}
+}
+
+// Each collaborator is tested with the others left out
+
+func TestBuilderTotals(t *testing.T) {
+	report, err := NewReportBuilder(InMemorySales(sales)).Build("2024-Q1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RegionTotal{{"east", 410.25}, {"north", 1300}, {"south", 830}}
+	if report.Count != 4 || report.Total != 2540.25 || len(report.Regions) != len(want) {
+		t.Fatalf("got %+v", report)
+	}
+	for i := range want {
+		if report.Regions[i] != want[i] {
+			t.Errorf("region %d: got %+v, want %+v", i, report.Regions[i], want[i])
+		}
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	if _, err := NewReportBuilder(InMemorySales(sales)).Build("2023-Q4"); !errors.Is(err, ErrNoSales) {
+		t.Errorf("empty period: got %v, want ErrNoSales", err)
+	}
+	if _, err := NewReportBuilder(failingSource{}).Build("2024-Q1"); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("failing source: got %v", err)
+	}
+}
+
+func TestRenderersNeedNoSalesData(t *testing.T) {
+	fixed := Report{Period: "P", Regions: []RegionTotal{{"west", 10}}, Total: 10, Count: 1}
+	for _, tc := range []struct {
+		renderer ReportRenderer
+		want     string
+	}{
+		{TextRenderer{}, "Sales report P\n  west          10.00\n  TOTAL         10.00 (1 sales)\n"},
+		{CSVRenderer{}, "region,amount\nwest,10.00\nTOTAL,10.00\n"},
+	} {
+		if got := tc.renderer.Render(fixed); got != tc.want {
+			t.Errorf("%T: got %q, want %q", tc.renderer, got, tc.want)
+		}
+	}
+}
+
+func TestSenderRejectsBadRecipient(t *testing.T) {
+	outbox := &OutboxSender{}
+	if err := outbox.Send(Email{To: "nobody"}); err == nil || len(outbox.sent) != 0 {
+		t.Fatalf("got %v with %d sent", err, len(outbox.sent))
+	}
+}
+
+// A new format touches only the renderer: the file name follows its extension
+func TestPublishUsesRendererExtension(t *testing.T) {
+	store := MemoryStore{}
+	service := NewReportService(NewReportBuilder(InMemorySales(sales)), CSVRenderer{}, &OutboxSender{}, store)
+	if err := service.Publish("2024-Q1", "ops@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store["reports/2024-Q1.csv"]; !ok || len(store) != 1 {
+		t.Fatalf("stored %v", store)
+	}
+}
+
+// A failed send must not leave a saved report behind
+func TestPublishStopsAtFirstFailure(t *testing.T) {
+	store := MemoryStore{}
+	service := NewReportService(NewReportBuilder(InMemorySales(sales)), TextRenderer{}, &OutboxSender{}, store)
+	if err := service.Publish("2024-Q1", "nobody"); err == nil {
+		t.Fatal("publish to a bad recipient succeeded")
+	}
+	if len(store) != 0 {
+		t.Fatalf("stored %v after a failed send", store)
+	}
+}
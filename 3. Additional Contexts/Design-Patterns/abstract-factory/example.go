@@ -0,0 +1,143 @@
+// Abstract Factory Pattern - Go
+// Flow: Part Interfaces -> Part Families -> Composed AdvancedCar -> Family Factories -> Client
+//
+// Run: go run example.go
+
+package main
+
+import "fmt"
+
+// ============================================================================
+// 1. ABSTRACT PARTS - every family provides one of each
+// ============================================================================
+
+type Engine interface {
+	Start()
+	Horsepower() int
+}
+
+type GPS interface {
+	Navigate(destination string)
+}
+
+// ============================================================================
+// 2. CONCRETE PART FAMILIES
+// ============================================================================
+
+// Luxury family
+type V8Engine struct{}
+
+func (V8Engine) Start()          { fmt.Println("  V8 engine rumbles") }
+func (V8Engine) Horsepower() int { return 450 }
+
+type SatelliteGPS struct{}
+
+func (SatelliteGPS) Navigate(destination string) {
+	fmt.Printf("  Satellite GPS: live-traffic route to %s, heads-up display on\n", destination)
+}
+
+// Economy family
+type EcoEngine struct{}
+
+func (EcoEngine) Start()          { fmt.Println("  1.2L eco engine hums") }
+func (EcoEngine) Horsepower() int { return 90 }
+
+type BasicGPS struct{}
+
+func (BasicGPS) Navigate(destination string) {
+	fmt.Printf("  Basic GPS: offline map route to %s\n", destination)
+}
+
+// ============================================================================
+// 3. COMPOSED PRODUCT - AdvancedCar HAS-A Engine and HAS-A GPS
+// ============================================================================
+
+type AdvancedCar struct {
+	brand  string
+	tier   string
+	engine Engine // composition: behavior comes from the parts
+	gps    GPS
+}
+
+func (c *AdvancedCar) DisplayInfo() {
+	fmt.Printf("%s (%s, %d HP)\n", c.brand, c.tier, c.engine.Horsepower())
+}
+
+func (c *AdvancedCar) Drive(destination string) {
+	c.engine.Start()
+	c.gps.Navigate(destination)
+}
+
+// ============================================================================
+// 4. ABSTRACT FACTORY - creates a whole family that belongs together
+// ============================================================================
+
+type VehicleFamilyFactory interface {
+	CreateEngine() Engine
+	CreateGPS() GPS
+	CreateVehicle(brand string) *AdvancedCar
+}
+
+type LuxuryVehicleFactory struct{}
+
+func (LuxuryVehicleFactory) CreateEngine() Engine { return V8Engine{} }
+func (LuxuryVehicleFactory) CreateGPS() GPS       { return SatelliteGPS{} }
+
+func (f LuxuryVehicleFactory) CreateVehicle(brand string) *AdvancedCar {
+	return &AdvancedCar{brand: brand, tier: "luxury", engine: f.CreateEngine(), gps: f.CreateGPS()}
+}
+
+type EconomyVehicleFactory struct{}
+
+func (EconomyVehicleFactory) CreateEngine() Engine { return EcoEngine{} }
+func (EconomyVehicleFactory) CreateGPS() GPS       { return BasicGPS{} }
+
+func (f EconomyVehicleFactory) CreateVehicle(brand string) *AdvancedCar {
+	return &AdvancedCar{brand: brand, tier: "economy", engine: f.CreateEngine(), gps: f.CreateGPS()}
+}
+
+// ============================================================================
+// 5. CLIENT - picks a factory once, never mixes parts by accident
+// ============================================================================
+
+func BuildFleet(factory VehicleFamilyFactory, brands ...string) []*AdvancedCar {
+	fleet := make([]*AdvancedCar, 0, len(brands))
+	for _, brand := range brands {
+		fleet = append(fleet, factory.CreateVehicle(brand))
+	}
+	return fleet
+}
+
+func factoryFor(budget float64) VehicleFamilyFactory {
+	if budget >= 60000 {
+		return LuxuryVehicleFactory{}
+	}
+	return EconomyVehicleFactory{}
+}
+
+// ============================================================================
+// 6. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Abstract Factory Pattern in Go ===")
+
+	for _, budget := range []float64{90000, 20000} {
+		fmt.Printf("\nBudget %.0f:\n", budget)
+		for _, car := range BuildFleet(factoryFor(budget), "Aurora", "Comet") {
+			car.DisplayInfo()
+			car.Drive("Airport")
+		}
+	}
+
+	// Without the factory nothing stops a mismatched family:
+	//   &AdvancedCar{brand: "Oops", tier: "luxury", engine: V8Engine{}, gps: BasicGPS{}}
+	// With it, the only way to get a "luxury" car is LuxuryVehicleFactory.
+
+	fmt.Println("\nSwapping one part still goes through the family's factory:")
+	factory := LuxuryVehicleFactory{}
+	spare := factory.CreateEngine()
+	fmt.Printf("Spare %T with %d HP\n", spare, spare.Horsepower())
+
+	fmt.Println("\n=== Abstract Factory demonstrated ===")
+}
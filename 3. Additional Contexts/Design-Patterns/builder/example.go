@@ -0,0 +1,169 @@
+// Builder Pattern - Go
+// Flow: Telescoping Constructor (problem) -> Fluent CarBuilder -> Validated Build() -> Director
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// 1. PRODUCT - AdvancedCar composed of optional parts
+// ============================================================================
+
+type Engine struct {
+	Horsepower int
+	Fuel       string
+}
+
+type GPS struct {
+	Provider string
+}
+
+type AdvancedCar struct {
+	brand    string
+	engine   *Engine
+	gps      *GPS // optional
+	features []string
+}
+
+func (c *AdvancedCar) DisplayInfo() {
+	gps := "none"
+	if c.gps != nil {
+		gps = c.gps.Provider
+	}
+	fmt.Printf("%s: %d HP %s, GPS %s, features [%s]\n",
+		c.brand, c.engine.Horsepower, c.engine.Fuel, gps, strings.Join(c.features, ", "))
+}
+
+// ============================================================================
+// 2. THE PROBLEM - a fixed constructor with hard-coded choices
+// ============================================================================
+
+// NewAdvancedCar works until someone needs no GPS, a diesel engine, or one
+// more feature; then it grows into NewAdvancedCarWithGPSAndSunroof(...)
+func NewAdvancedCar(brand string, horsepower int) *AdvancedCar {
+	return &AdvancedCar{
+		brand:    brand,
+		engine:   &Engine{Horsepower: horsepower, Fuel: "petrol"},
+		gps:      &GPS{Provider: "default"},
+		features: []string{"air conditioning"},
+	}
+}
+
+// ============================================================================
+// 3. BUILDER - fluent setters, errors collected, checked once in Build
+// ============================================================================
+
+var (
+	ErrMissingBrand  = errors.New("brand is required")
+	ErrMissingEngine = errors.New("engine is required")
+	ErrInvalidEngine = errors.New("horsepower must be positive")
+)
+
+type CarBuilder struct {
+	car  AdvancedCar
+	errs []error
+}
+
+func NewCarBuilder(brand string) *CarBuilder {
+	return &CarBuilder{car: AdvancedCar{brand: brand}}
+}
+
+func (b *CarBuilder) WithEngine(horsepower int, fuel string) *CarBuilder {
+	if horsepower <= 0 {
+		b.errs = append(b.errs, fmt.Errorf("%w: got %d", ErrInvalidEngine, horsepower))
+		return b
+	}
+	b.car.engine = &Engine{Horsepower: horsepower, Fuel: fuel}
+	return b
+}
+
+func (b *CarBuilder) WithGPS(provider string) *CarBuilder {
+	b.car.gps = &GPS{Provider: provider}
+	return b
+}
+
+func (b *CarBuilder) WithFeature(feature string) *CarBuilder {
+	b.car.features = append(b.car.features, feature)
+	return b
+}
+
+// Build validates required parts and returns a fresh car each time, so one
+// builder can be reused as a template without the cars sharing state
+func (b *CarBuilder) Build() (*AdvancedCar, error) {
+	errs := append([]error(nil), b.errs...)
+	if b.car.brand == "" {
+		errs = append(errs, ErrMissingBrand)
+	}
+	if b.car.engine == nil && len(b.errs) == 0 {
+		errs = append(errs, ErrMissingEngine)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	car := b.car
+	engine := *b.car.engine
+	car.engine = &engine
+	if b.car.gps != nil {
+		gps := *b.car.gps
+		car.gps = &gps
+	}
+	car.features = append([]string(nil), b.car.features...)
+	return &car, nil
+}
+
+// ============================================================================
+// 4. DIRECTOR - named recipes on top of the builder
+// ============================================================================
+
+func FamilyCar(brand string) *CarBuilder {
+	return NewCarBuilder(brand).
+		WithEngine(140, "hybrid").
+		WithGPS("offline maps").
+		WithFeature("isofix").
+		WithFeature("roof rails")
+}
+
+// ============================================================================
+// 5. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Builder Pattern in Go ===")
+
+	fmt.Println("\n1. Fixed constructor (everyone gets the same options):")
+	NewAdvancedCar("Toyota", 150).DisplayInfo()
+
+	fmt.Println("\n2. Fluent builder:")
+	roadster, err := NewCarBuilder("Mazda").
+		WithEngine(180, "petrol").
+		WithFeature("soft top").
+		Build()
+	if err != nil {
+		fmt.Println("build failed:", err)
+	} else {
+		roadster.DisplayInfo()
+	}
+
+	fmt.Println("\n3. Validation instead of a half-built car:")
+	if _, err := NewCarBuilder("").WithGPS("satellite").Build(); err != nil {
+		fmt.Printf("build failed:\n%v\n", err)
+	}
+	if _, err := NewCarBuilder("Lada").WithEngine(-5, "petrol").Build(); errors.Is(err, ErrInvalidEngine) {
+		fmt.Println("build failed:", err)
+	}
+
+	fmt.Println("\n4. Director recipe reused as a template:")
+	recipe := FamilyCar("Volvo")
+	base, _ := recipe.Build()
+	upgraded, _ := recipe.WithFeature("panoramic roof").Build()
+	base.DisplayInfo()
+	upgraded.DisplayInfo()
+
+	fmt.Println("\n=== Builder demonstrated ===")
+}
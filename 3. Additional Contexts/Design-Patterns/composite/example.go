@@ -0,0 +1,186 @@
+// Composite Pattern - Go
+// Flow: Component Interface -> Leaf Vehicles -> Fleet Composite (nestable) -> VehicleManager treats both the same
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// 1. COMPONENT - one interface for a single vehicle and a whole fleet
+// ============================================================================
+
+type Vehicular interface {
+	Start()
+	Stop()
+	DisplayBasicInfo()
+	CalculateFuelEfficiency() float64 // km per liter
+}
+
+// ============================================================================
+// 2. LEAVES - individual vehicles
+// ============================================================================
+
+type Car struct {
+	brand      string
+	kmPerLiter float64
+}
+
+func NewCar(brand string, kmPerLiter float64) *Car { return &Car{brand: brand, kmPerLiter: kmPerLiter} }
+
+func (c *Car) Start()                           { fmt.Printf("%s car started\n", c.brand) }
+func (c *Car) Stop()                            { fmt.Printf("%s car stopped\n", c.brand) }
+func (c *Car) DisplayBasicInfo()                { fmt.Printf("Car: %s\n", c.brand) }
+func (c *Car) CalculateFuelEfficiency() float64 { return c.kmPerLiter }
+
+type Motorcycle struct {
+	brand      string
+	kmPerLiter float64
+}
+
+func NewMotorcycle(brand string, kmPerLiter float64) *Motorcycle {
+	return &Motorcycle{brand: brand, kmPerLiter: kmPerLiter}
+}
+
+func (m *Motorcycle) Start()                           { fmt.Printf("%s motorcycle started\n", m.brand) }
+func (m *Motorcycle) Stop()                            { fmt.Printf("%s motorcycle stopped\n", m.brand) }
+func (m *Motorcycle) DisplayBasicInfo()                { fmt.Printf("Motorcycle: %s\n", m.brand) }
+func (m *Motorcycle) CalculateFuelEfficiency() float64 { return m.kmPerLiter }
+
+// ============================================================================
+// 3. COMPOSITE - a Fleet is Vehicular and holds Vehicular children
+// ============================================================================
+
+var ErrFleetCycle = errors.New("fleet cannot contain itself")
+
+type Fleet struct {
+	name    string
+	members []Vehicular
+}
+
+func NewFleet(name string) *Fleet { return &Fleet{name: name} }
+
+// Add accepts vehicles and other fleets, refusing cycles that would recurse forever
+func (f *Fleet) Add(members ...Vehicular) error {
+	for _, member := range members {
+		if sub, ok := member.(*Fleet); ok && sub.contains(f) {
+			return fmt.Errorf("%w: %s in %s", ErrFleetCycle, f.name, sub.name)
+		}
+		f.members = append(f.members, member)
+	}
+	return nil
+}
+
+func (f *Fleet) contains(target *Fleet) bool {
+	if f == target {
+		return true
+	}
+	for _, member := range f.members {
+		if sub, ok := member.(*Fleet); ok && sub.contains(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Each operation forwards to the children; callers never see the difference
+func (f *Fleet) Start() {
+	fmt.Printf("-- starting fleet %s --\n", f.name)
+	for _, member := range f.members {
+		member.Start()
+	}
+}
+
+func (f *Fleet) Stop() {
+	for _, member := range f.members {
+		member.Stop()
+	}
+	fmt.Printf("-- fleet %s stopped --\n", f.name)
+}
+
+func (f *Fleet) DisplayBasicInfo() { f.display(0) }
+
+func (f *Fleet) display(depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Printf("%sFleet: %s (%d vehicles)\n", indent, f.name, f.Size())
+	for _, member := range f.members {
+		if sub, ok := member.(*Fleet); ok {
+			sub.display(depth + 1)
+			continue
+		}
+		fmt.Printf("%s  ", indent)
+		member.DisplayBasicInfo()
+	}
+}
+
+// CalculateFuelEfficiency averages over every vehicle, not over sub-fleets,
+// so a big nested fleet weighs as much as its vehicles
+func (f *Fleet) CalculateFuelEfficiency() float64 {
+	total, count := f.sumEfficiency()
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+func (f *Fleet) sumEfficiency() (total float64, count int) {
+	for _, member := range f.members {
+		if sub, ok := member.(*Fleet); ok {
+			t, c := sub.sumEfficiency()
+			total, count = total+t, count+c
+			continue
+		}
+		total += member.CalculateFuelEfficiency()
+		count++
+	}
+	return total, count
+}
+
+func (f *Fleet) Size() int {
+	_, count := f.sumEfficiency()
+	return count
+}
+
+// ============================================================================
+// 4. CLIENT - VehicleManager only knows Vehicular
+// ============================================================================
+
+type VehicleManager struct{}
+
+func (VehicleManager) TestVehicle(v Vehicular) {
+	v.DisplayBasicInfo()
+	v.Start()
+	fmt.Printf("Fuel efficiency: %.1f km/l\n", v.CalculateFuelEfficiency())
+	v.Stop()
+}
+
+// ============================================================================
+// 5. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Composite Pattern in Go ===")
+	manager := VehicleManager{}
+
+	fmt.Println("\n1. A single vehicle:")
+	manager.TestVehicle(NewCar("Toyota", 15))
+
+	fmt.Println("\n2. A nested fleet, tested with the same call:")
+	city := NewFleet("City")
+	city.Add(NewCar("Honda", 16), NewMotorcycle("Vespa", 35))
+	regional := NewFleet("Regional")
+	regional.Add(NewCar("Volvo", 12), city)
+	manager.TestVehicle(regional)
+
+	fmt.Println("\n3. Cycles are rejected:")
+	if err := city.Add(regional); err != nil {
+		fmt.Println("error:", err)
+	}
+
+	fmt.Println("\n=== Composite demonstrated ===")
+}
@@ -0,0 +1,157 @@
+// Facade Pattern - Go
+// Flow: Subsystems (Engine, GPS, DiagnosticsUnit) -> AdvancedCar composition -> CarDashboard facade
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ============================================================================
+// 1. SUBSYSTEMS - each with its own detailed API and ordering rules
+// ============================================================================
+
+type Engine struct {
+	running bool
+	warm    bool
+}
+
+func (e *Engine) Ignite()   { e.running = true; fmt.Println("  engine: ignition") }
+func (e *Engine) WarmUp()   { e.warm = true; fmt.Println("  engine: warmed up") }
+func (e *Engine) Shutdown() { e.running, e.warm = false, false; fmt.Println("  engine: off") }
+
+type GPS struct {
+	destination string
+	distanceKm  float64
+}
+
+func (g *GPS) AcquireSatellites() { fmt.Println("  gps: satellites locked") }
+
+func (g *GPS) PlanRoute(destination string) float64 {
+	g.destination = destination
+	g.distanceKm = float64(len(destination)) * 3.5 // stand-in for real routing
+	fmt.Printf("  gps: route to %s, %.1f km\n", destination, g.distanceKm)
+	return g.distanceKm
+}
+
+func (g *GPS) ClearRoute() { g.destination, g.distanceKm = "", 0; fmt.Println("  gps: route cleared") }
+
+// DiagnosticsUnit reports problems that must stop a trip
+type DiagnosticsUnit struct {
+	tirePressure float64 // bar
+	oilLevel     float64 // 0..1
+	tripLog      []string
+}
+
+var ErrDiagnostics = errors.New("diagnostics failed")
+
+func (d *DiagnosticsUnit) SelfTest() error {
+	switch {
+	case d.tirePressure < 1.8:
+		return fmt.Errorf("%w: tire pressure %.1f bar", ErrDiagnostics, d.tirePressure)
+	case d.oilLevel < 0.2:
+		return fmt.Errorf("%w: oil level %.0f%%", ErrDiagnostics, d.oilLevel*100)
+	}
+	fmt.Println("  diagnostics: all systems ok")
+	return nil
+}
+
+func (d *DiagnosticsUnit) Log(entry string) { d.tripLog = append(d.tripLog, entry) }
+
+// ============================================================================
+// 2. COMPOSITION - AdvancedCar owns the parts
+// ============================================================================
+
+type AdvancedCar struct {
+	brand       string
+	engine      *Engine
+	gps         *GPS
+	diagnostics *DiagnosticsUnit
+}
+
+func NewAdvancedCar(brand string, tirePressure, oilLevel float64) *AdvancedCar {
+	return &AdvancedCar{
+		brand:       brand,
+		engine:      &Engine{},
+		gps:         &GPS{},
+		diagnostics: &DiagnosticsUnit{tirePressure: tirePressure, oilLevel: oilLevel},
+	}
+}
+
+// ============================================================================
+// 3. FACADE - two calls instead of eight, in the right order
+// ============================================================================
+
+var ErrTripInProgress = errors.New("trip already in progress")
+
+type CarDashboard struct {
+	car    *AdvancedCar
+	onTrip bool
+}
+
+func NewCarDashboard(car *AdvancedCar) *CarDashboard {
+	return &CarDashboard{car: car}
+}
+
+// StartTrip checks diagnostics before anything moves
+func (d *CarDashboard) StartTrip(destination string) error {
+	if d.onTrip {
+		return ErrTripInProgress
+	}
+	fmt.Printf("[%s] start trip to %s\n", d.car.brand, destination)
+	if err := d.car.diagnostics.SelfTest(); err != nil {
+		return err
+	}
+	d.car.engine.Ignite()
+	d.car.engine.WarmUp()
+	d.car.gps.AcquireSatellites()
+	distance := d.car.gps.PlanRoute(destination)
+	d.car.diagnostics.Log(fmt.Sprintf("trip to %s (%.1f km)", destination, distance))
+	d.onTrip = true
+	return nil
+}
+
+func (d *CarDashboard) EndTrip() {
+	if !d.onTrip {
+		return
+	}
+	fmt.Printf("[%s] end trip\n", d.car.brand)
+	d.car.gps.ClearRoute()
+	d.car.engine.Shutdown()
+	d.onTrip = false
+}
+
+// TripLog stays available; the facade simplifies, it does not hide everything
+func (d *CarDashboard) TripLog() []string {
+	return append([]string(nil), d.car.diagnostics.tripLog...)
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Facade Pattern in Go ===")
+
+	fmt.Println("\n1. Simple calls for the driver:")
+	dashboard := NewCarDashboard(NewAdvancedCar("Volvo", 2.3, 0.8))
+	if err := dashboard.StartTrip("Airport"); err != nil {
+		fmt.Println("error:", err)
+	}
+	if err := dashboard.StartTrip("Mall"); errors.Is(err, ErrTripInProgress) {
+		fmt.Println("error:", err)
+	}
+	dashboard.EndTrip()
+	fmt.Printf("trip log: %v\n", dashboard.TripLog())
+
+	fmt.Println("\n2. The facade enforces the safe order:")
+	flat := NewCarDashboard(NewAdvancedCar("Fiat", 1.2, 0.9))
+	if err := flat.StartTrip("Beach"); err != nil {
+		fmt.Println("error:", err)
+	}
+
+	fmt.Println("\n=== Facade demonstrated ===")
+}
@@ -0,0 +1,143 @@
+// Factory Method Pattern - Go
+// Flow: Product Interface -> Concrete Products -> Factory Interface -> Concrete Factories -> Client
+//
+// Run: go run example.go
+
+package main
+
+import "fmt"
+
+// ============================================================================
+// 1. PRODUCT - the interface client code programs against
+// ============================================================================
+
+type Vehicular interface {
+	Start()
+	DisplayInfo()
+}
+
+// ============================================================================
+// 2. CONCRETE PRODUCTS - the tour's vehicles
+// ============================================================================
+
+type Vehicle struct {
+	brand string
+}
+
+func (v *Vehicle) DisplayInfo() {
+	fmt.Printf("Vehicle: %s\n", v.brand)
+}
+
+type Car struct {
+	Vehicle
+}
+
+func (c *Car) Start() {
+	fmt.Printf("%s car started\n", c.brand)
+}
+
+type Motorcycle struct {
+	Vehicle
+}
+
+func (m *Motorcycle) Start() {
+	fmt.Printf("%s motorcycle kick-started\n", m.brand)
+}
+
+// SportsCar is-a Car with extra state
+type SportsCar struct {
+	Car
+	topSpeed int
+}
+
+func (s *SportsCar) Start() {
+	fmt.Printf("%s sports car roars to life (top speed %d km/h)\n", s.brand, s.topSpeed)
+}
+
+// ============================================================================
+// 3. FACTORY METHOD - one interface, one factory per product
+// ============================================================================
+
+// VehicleFactory returns the interface, so callers never name a concrete type
+type VehicleFactory interface {
+	CreateVehicle(brand string) Vehicular
+}
+
+type CarFactory struct{}
+
+func (CarFactory) CreateVehicle(brand string) Vehicular {
+	return &Car{Vehicle: Vehicle{brand: brand}}
+}
+
+type MotorcycleFactory struct{}
+
+func (MotorcycleFactory) CreateVehicle(brand string) Vehicular {
+	return &Motorcycle{Vehicle: Vehicle{brand: brand}}
+}
+
+// Factories can carry configuration the product needs
+type SportsCarFactory struct {
+	TopSpeed int
+}
+
+func (f SportsCarFactory) CreateVehicle(brand string) Vehicular {
+	return &SportsCar{Car: Car{Vehicle: Vehicle{brand: brand}}, topSpeed: f.TopSpeed}
+}
+
+// FactoryFunc lets a plain function act as a factory (like http.HandlerFunc)
+type FactoryFunc func(brand string) Vehicular
+
+func (f FactoryFunc) CreateVehicle(brand string) Vehicular { return f(brand) }
+
+// ============================================================================
+// 4. CLIENT - depends only on VehicleFactory and Vehicular
+// ============================================================================
+
+// Dealership runs the same delivery steps whatever the factory builds
+type Dealership struct {
+	name    string
+	factory VehicleFactory
+}
+
+func NewDealership(name string, factory VehicleFactory) *Dealership {
+	return &Dealership{name: name, factory: factory}
+}
+
+func (d *Dealership) Deliver(brand string) Vehicular {
+	vehicle := d.factory.CreateVehicle(brand)
+	fmt.Printf("[%s] delivering: ", d.name)
+	vehicle.DisplayInfo()
+	vehicle.Start()
+	return vehicle
+}
+
+// ============================================================================
+// 5. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Factory Method Pattern in Go ===")
+
+	fmt.Println("\n1. Same client code, different factories:")
+	dealerships := []*Dealership{
+		NewDealership("City Cars", CarFactory{}),
+		NewDealership("Two Wheels", MotorcycleFactory{}),
+		NewDealership("Track Day", SportsCarFactory{TopSpeed: 320}),
+	}
+	for _, d := range dealerships {
+		d.Deliver("Acme")
+	}
+
+	fmt.Println("\n2. A new product without a new factory type:")
+	rental := NewDealership("Rentals", FactoryFunc(func(brand string) Vehicular {
+		return &Car{Vehicle: Vehicle{brand: brand + " (rental)"}}
+	}))
+	rental.Deliver("Toyota")
+
+	fmt.Println("\n3. Concrete type is still reachable when really needed:")
+	if sports, ok := dealerships[2].factory.CreateVehicle("Ferrari").(*SportsCar); ok {
+		fmt.Printf("Type assertion: SportsCar with top speed %d km/h\n", sports.topSpeed)
+	}
+
+	fmt.Println("\n=== Factory Method demonstrated ===")
+}
@@ -0,0 +1,173 @@
+// Flyweight Pattern - Go
+// Flow: Intrinsic Spec (shared) vs Extrinsic State (per vehicle) -> SpecCache -> Memory Comparison
+//
+// Run:        go run example.go [-vehicles 100000]
+// Benchmarks: go test -bench . -benchmem example.go example_test.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// 1. INTRINSIC STATE - spec data identical for every vehicle of a model/year
+// ============================================================================
+
+type VehicleSpec struct {
+	Brand       string
+	Model       string
+	Year        int
+	EngineCC    int
+	Seats       int
+	Description string
+	Features    []string
+}
+
+// loadSpec stands in for a catalog lookup; every call builds fresh strings
+func loadSpec(brand, model string, year int) VehicleSpec {
+	return VehicleSpec{
+		Brand:       brand,
+		Model:       model,
+		Year:        year,
+		EngineCC:    1500 + 100*(year%5),
+		Seats:       5,
+		Description: strings.Repeat(fmt.Sprintf("%s %s %d. ", brand, model, year), 20),
+		Features:    []string{"abs", "airbags", "cruise control", "bluetooth", fmt.Sprintf("%d infotainment", year)},
+	}
+}
+
+// ============================================================================
+// 2. WITHOUT FLYWEIGHT - every vehicle carries its own copy
+// ============================================================================
+
+type HeavyVehicle struct {
+	vin       string
+	mileageKm float64
+	spec      VehicleSpec
+}
+
+// ============================================================================
+// 3. FLYWEIGHT - vehicles point at one shared, read-only spec
+// ============================================================================
+
+type Vehicle struct {
+	vin       string  // extrinsic: unique per vehicle
+	mileageKm float64 // extrinsic
+	spec      *VehicleSpec
+}
+
+func (v *Vehicle) DisplayInfo() {
+	fmt.Printf("%s: %d %s %s, %.0f km\n", v.vin, v.spec.Year, v.spec.Brand, v.spec.Model, v.mileageKm)
+}
+
+type specKey struct {
+	brand, model string
+	year         int
+}
+
+// SpecCache hands out one *VehicleSpec per brand+model+year; specs must be
+// treated as immutable because every vehicle of that model shares them
+type SpecCache struct {
+	mu    sync.Mutex
+	specs map[specKey]*VehicleSpec
+}
+
+func NewSpecCache() *SpecCache {
+	return &SpecCache{specs: make(map[specKey]*VehicleSpec)}
+}
+
+func (c *SpecCache) Get(brand, model string, year int) *VehicleSpec {
+	key := specKey{brand, model, year}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if spec, ok := c.specs[key]; ok {
+		return spec
+	}
+	spec := loadSpec(brand, model, year)
+	c.specs[key] = &spec
+	return &spec
+}
+
+func (c *SpecCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.specs)
+}
+
+// ============================================================================
+// 4. FLEET BUILDERS - same input, two representations
+// ============================================================================
+
+var models = []struct{ brand, model string }{
+	{"Toyota", "Corolla"}, {"Honda", "Civic"}, {"Ford", "Focus"}, {"VW", "Golf"},
+}
+
+func modelFor(i int) (brand, model string, year int) {
+	m := models[i%len(models)]
+	return m.brand, m.model, 2018 + i%6
+}
+
+func BuildHeavyFleet(n int) []HeavyVehicle {
+	fleet := make([]HeavyVehicle, n)
+	for i := range fleet {
+		brand, model, year := modelFor(i)
+		fleet[i] = HeavyVehicle{vin: fmt.Sprintf("VIN%07d", i), spec: loadSpec(brand, model, year)}
+	}
+	return fleet
+}
+
+func BuildFlyweightFleet(n int, cache *SpecCache) []Vehicle {
+	fleet := make([]Vehicle, n)
+	for i := range fleet {
+		brand, model, year := modelFor(i)
+		fleet[i] = Vehicle{vin: fmt.Sprintf("VIN%07d", i), spec: cache.Get(brand, model, year)}
+	}
+	return fleet
+}
+
+// heapGrowth reports live heap bytes retained by whatever build returns
+func heapGrowth(build func() interface{}) (uint64, interface{}) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	fleet := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	return after.HeapAlloc - before.HeapAlloc, fleet
+}
+
+// ============================================================================
+// 5. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	vehicles := flag.Int("vehicles", 100000, "fleet size")
+	flag.Parse()
+
+	fmt.Println("=== Flyweight Pattern in Go ===")
+
+	fmt.Println("\n1. Shared specs:")
+	cache := NewSpecCache()
+	a := Vehicle{vin: "VIN-A", mileageKm: 1200, spec: cache.Get("Toyota", "Corolla", 2022)}
+	b := Vehicle{vin: "VIN-B", mileageKm: 56000, spec: cache.Get("Toyota", "Corolla", 2022)}
+	a.DisplayInfo()
+	b.DisplayInfo()
+	fmt.Printf("same spec pointer: %v\n", a.spec == b.spec)
+
+	fmt.Printf("\n2. Retained heap for %d vehicles:\n", *vehicles)
+	heavyBytes, _ := heapGrowth(func() interface{} { return BuildHeavyFleet(*vehicles) })
+	fleetCache := NewSpecCache()
+	flyBytes, _ := heapGrowth(func() interface{} { return BuildFlyweightFleet(*vehicles, fleetCache) })
+	fmt.Printf("  copy per vehicle: %8.1f MB\n", float64(heavyBytes)/(1<<20))
+	fmt.Printf("  flyweight:        %8.1f MB (%d shared specs)\n", float64(flyBytes)/(1<<20), fleetCache.Len())
+	if flyBytes > 0 {
+		fmt.Printf("  savings:          %.1fx\n", float64(heavyBytes)/float64(flyBytes))
+	}
+
+	fmt.Println("\n=== Flyweight demonstrated ===")
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+const benchFleet = 10000
+
+func BenchmarkBuildHeavyFleet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		BuildHeavyFleet(benchFleet)
+	}
+}
+
+func BenchmarkBuildFlyweightFleet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		BuildFlyweightFleet(benchFleet, NewSpecCache())
+	}
+}
+
+// Both builders format the same VINs; what the heavy one allocates on top of
+// that is each vehicle's own copy of the spec, which the flyweight shares
+func TestFlyweightAllocatesOnlyExtrinsicState(t *testing.T) {
+	const n = 1000
+	cache := NewSpecCache()
+	BuildFlyweightFleet(n, cache) // warm: every spec is loaded once
+	heavy := testing.AllocsPerRun(10, func() { BuildHeavyFleet(n) }) / n
+	flyweight := testing.AllocsPerRun(10, func() { BuildFlyweightFleet(n, cache) }) / n
+	if spec := heavy - flyweight; spec < 5 {
+		t.Errorf("heavy %.2f, flyweight %.2f allocs per vehicle: a spec copy should cost at least 5", heavy, flyweight)
+	}
+	if flyweight > heavy/3 {
+		t.Errorf("flyweight: %.2f allocs per vehicle, want a fraction of the heavy fleet's %.2f", flyweight, heavy)
+	}
+}
+
+func TestSpecCacheSharesOneSpecPerModel(t *testing.T) {
+	cache := NewSpecCache()
+	fleet := BuildFlyweightFleet(100, cache)
+	if cache.Len() != 12 { // 4 models and 6 years repeat together every 12 vehicles
+		t.Fatalf("%d specs cached, want 12", cache.Len())
+	}
+	if fleet[0].spec != fleet[12].spec || fleet[0].spec == fleet[1].spec {
+		t.Error("vehicles of the same model and year must share a spec, and only they")
+	}
+}
@@ -0,0 +1,295 @@
+// Interpreter Pattern - Go
+// Flow: Expression AST (Interpret) -> Tokenizer -> Recursive-Descent Parser -> Calculator.Eval
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ============================================================================
+// 1. EXPRESSION AST - each node knows how to interpret itself
+// ============================================================================
+
+var ErrDivisionByZero = errors.New("division by zero")
+
+type Expr interface {
+	Interpret() (float64, error)
+	String() string
+}
+
+type NumberExpr struct {
+	Value float64
+}
+
+func (n NumberExpr) Interpret() (float64, error) { return n.Value, nil }
+func (n NumberExpr) String() string              { return strconv.FormatFloat(n.Value, 'g', -1, 64) }
+
+// binaryExpr holds what every two-operand node shares
+type binaryExpr struct {
+	Left, Right Expr
+}
+
+func (b binaryExpr) operands() (float64, float64, error) {
+	left, err := b.Left.Interpret()
+	if err != nil {
+		return 0, 0, err
+	}
+	right, err := b.Right.Interpret()
+	return left, right, err
+}
+
+type AddExpr struct{ binaryExpr }
+
+func (e AddExpr) Interpret() (float64, error) {
+	l, r, err := e.operands()
+	return l + r, err
+}
+func (e AddExpr) String() string { return fmt.Sprintf("(%s + %s)", e.Left, e.Right) }
+
+type SubExpr struct{ binaryExpr }
+
+func (e SubExpr) Interpret() (float64, error) {
+	l, r, err := e.operands()
+	return l - r, err
+}
+func (e SubExpr) String() string { return fmt.Sprintf("(%s - %s)", e.Left, e.Right) }
+
+type MulExpr struct{ binaryExpr }
+
+func (e MulExpr) Interpret() (float64, error) {
+	l, r, err := e.operands()
+	return l * r, err
+}
+func (e MulExpr) String() string { return fmt.Sprintf("(%s * %s)", e.Left, e.Right) }
+
+type DivExpr struct{ binaryExpr }
+
+func (e DivExpr) Interpret() (float64, error) {
+	l, r, err := e.operands()
+	if err == nil && r == 0 {
+		return 0, fmt.Errorf("%w in %s", ErrDivisionByZero, e)
+	}
+	return l / r, err
+}
+func (e DivExpr) String() string { return fmt.Sprintf("(%s / %s)", e.Left, e.Right) }
+
+type NegExpr struct{ Operand Expr }
+
+func (e NegExpr) Interpret() (float64, error) {
+	v, err := e.Operand.Interpret()
+	return -v, err
+}
+func (e NegExpr) String() string { return fmt.Sprintf("-%s", e.Operand) }
+
+// ============================================================================
+// 2. TOKENIZER - text to tokens, remembering positions for error messages
+// ============================================================================
+
+type tokenKind int
+
+const (
+	tokNumber tokenKind = iota
+	tokOperator
+	tokLParen
+	tokRParen
+	tokEOF
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64
+	pos   int // 1-based column
+}
+
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string { return fmt.Sprintf("column %d: %s", e.Pos, e.Msg) }
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, &SyntaxError{Pos: start + 1, Msg: fmt.Sprintf("bad number %q", text)}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, value: value, pos: start + 1})
+		case strings.ContainsRune("+-*/", r):
+			tokens = append(tokens, token{kind: tokOperator, text: string(r), pos: i + 1})
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i + 1})
+			i++
+		default:
+			return nil, &SyntaxError{Pos: i + 1, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(runes) + 1}), nil
+}
+
+// ============================================================================
+// 3. PARSER - one function per precedence level
+// ============================================================================
+//
+//	expr   := term   (("+" | "-") term)*
+//	term   := factor (("*" | "/") factor)*
+//	factor := NUMBER | "(" expr ")" | "-" factor
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+func (p *parser) next() token { t := p.tokens[p.pos]; p.pos++; return t }
+
+func (p *parser) expr() (Expr, error) {
+	left, err := p.term()
+	for err == nil && p.peek().kind == tokOperator && (p.peek().text == "+" || p.peek().text == "-") {
+		op := p.next().text
+		var right Expr
+		if right, err = p.term(); err != nil {
+			break
+		}
+		if op == "+" {
+			left = AddExpr{binaryExpr{left, right}}
+		} else {
+			left = SubExpr{binaryExpr{left, right}}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) term() (Expr, error) {
+	left, err := p.factor()
+	for err == nil && p.peek().kind == tokOperator && (p.peek().text == "*" || p.peek().text == "/") {
+		op := p.next().text
+		var right Expr
+		if right, err = p.factor(); err != nil {
+			break
+		}
+		if op == "*" {
+			left = MulExpr{binaryExpr{left, right}}
+		} else {
+			left = DivExpr{binaryExpr{left, right}}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) factor() (Expr, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		return NumberExpr{Value: t.value}, nil
+	case t.kind == tokOperator && t.text == "-":
+		operand, err := p.factor()
+		return NegExpr{Operand: operand}, err
+	case t.kind == tokLParen:
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: "expected )"}
+		}
+		return inner, nil
+	case t.kind == tokEOF:
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unexpected end of expression"}
+	}
+	return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+}
+
+func Parse(input string) (Expr, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return expr, nil
+}
+
+// ============================================================================
+// 4. CALCULATOR - replaces the variadic Calculate(values ...interface{})
+// ============================================================================
+
+// The OOP tour keeps its variadic Calculate as the overloading example; it is
+// a separate package main and cannot import this one, so the two do not share code.
+type Calculator struct{}
+
+func (Calculator) Eval(input string) (float64, error) {
+	expr, err := Parse(input)
+	if err != nil {
+		return 0, err
+	}
+	return expr.Interpret()
+}
+
+// ============================================================================
+// 5. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Interpreter Pattern in Go ===")
+
+	fmt.Println("\n1. Building the AST by hand:")
+	ast := AddExpr{binaryExpr{NumberExpr{2}, MulExpr{binaryExpr{NumberExpr{3}, NumberExpr{4}}}}}
+	value, _ := ast.Interpret()
+	fmt.Printf("%s = %g\n", ast, value)
+
+	fmt.Println("\n2. Parsing text with precedence:")
+	calc := Calculator{}
+	for _, input := range []string{"2 + 3 * 4", "(2 + 3) * 4", "10 / 4 - -1", "1 - 2 - 3"} {
+		expr, _ := Parse(input)
+		result, err := calc.Eval(input)
+		if err != nil {
+			fmt.Printf("%-12s -> error: %v\n", input, err)
+			continue
+		}
+		fmt.Printf("%-12s -> %-22s = %g\n", input, expr, result)
+	}
+
+	fmt.Println("\n3. Error reporting:")
+	for _, input := range []string{"2 +", "2 * (3 + 4", "7 / (2 - 2)", "3 $ 4"} {
+		_, err := calc.Eval(input)
+		var syntaxErr *SyntaxError
+		kind := "runtime"
+		if errors.As(err, &syntaxErr) {
+			kind = "syntax"
+		}
+		fmt.Printf("%-12s -> %s error: %v\n", input, kind, err)
+	}
+
+	fmt.Println("\n=== Interpreter demonstrated ===")
+}
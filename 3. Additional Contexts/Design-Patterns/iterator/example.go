@@ -0,0 +1,180 @@
+// Iterator Pattern - Go
+// Flow: Heterogeneous Vehicles -> Private Storage -> VehicleIterator (slice, filtered) -> VehicleManager -> iter.Seq
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"fmt"
+	"iter"
+)
+
+// ============================================================================
+// 1. ELEMENTS - different vehicle types behind one interface
+// ============================================================================
+
+type Vehicular interface {
+	DisplayInfo() string
+	MileageKm() float64
+	LastServiceKm() float64
+}
+
+type Car struct {
+	brand                  string
+	mileage, lastServiceAt float64
+}
+
+func (c *Car) DisplayInfo() string    { return "Car " + c.brand }
+func (c *Car) MileageKm() float64     { return c.mileage }
+func (c *Car) LastServiceKm() float64 { return c.lastServiceAt }
+
+type Motorcycle struct {
+	brand                  string
+	mileage, lastServiceAt float64
+}
+
+func (m *Motorcycle) DisplayInfo() string    { return "Motorcycle " + m.brand }
+func (m *Motorcycle) MileageKm() float64     { return m.mileage }
+func (m *Motorcycle) LastServiceKm() float64 { return m.lastServiceAt }
+
+type Truck struct {
+	brand                  string
+	mileage, lastServiceAt float64
+	payloadTons            float64
+}
+
+func (t *Truck) DisplayInfo() string    { return fmt.Sprintf("Truck %s (%.0ft)", t.brand, t.payloadTons) }
+func (t *Truck) MileageKm() float64     { return t.mileage }
+func (t *Truck) LastServiceKm() float64 { return t.lastServiceAt }
+
+// ============================================================================
+// 2. ITERATOR INTERFACE + IMPLEMENTATIONS
+// ============================================================================
+
+type VehicleIterator interface {
+	HasNext() bool
+	Next() Vehicular
+}
+
+// sliceIterator walks a snapshot, so later Adds do not disturb a traversal
+type sliceIterator struct {
+	items []Vehicular
+	index int
+}
+
+func (it *sliceIterator) HasNext() bool { return it.index < len(it.items) }
+
+func (it *sliceIterator) Next() Vehicular {
+	if !it.HasNext() {
+		return nil
+	}
+	v := it.items[it.index]
+	it.index++
+	return v
+}
+
+// filterIterator wraps any iterator and looks ahead for the next match
+type filterIterator struct {
+	inner   VehicleIterator
+	keep    func(Vehicular) bool
+	pending Vehicular
+}
+
+func Filter(inner VehicleIterator, keep func(Vehicular) bool) VehicleIterator {
+	return &filterIterator{inner: inner, keep: keep}
+}
+
+func (it *filterIterator) HasNext() bool {
+	for it.pending == nil && it.inner.HasNext() {
+		if v := it.inner.Next(); it.keep(v) {
+			it.pending = v
+		}
+	}
+	return it.pending != nil
+}
+
+func (it *filterIterator) Next() Vehicular {
+	if !it.HasNext() {
+		return nil
+	}
+	v := it.pending
+	it.pending = nil
+	return v
+}
+
+// ============================================================================
+// 3. COLLECTION - VehicleManager hides how vehicles are stored
+// ============================================================================
+
+const serviceIntervalKm = 10000
+
+func NeedsMaintenance(v Vehicular) bool {
+	return v.MileageKm()-v.LastServiceKm() >= serviceIntervalKm
+}
+
+type VehicleManager struct {
+	vehicles []Vehicular // could become a map or a database without changing callers
+}
+
+func (m *VehicleManager) Add(vehicles ...Vehicular) {
+	m.vehicles = append(m.vehicles, vehicles...)
+}
+
+func (m *VehicleManager) Iterator() VehicleIterator {
+	return &sliceIterator{items: append([]Vehicular(nil), m.vehicles...)}
+}
+
+func (m *VehicleManager) MaintenanceDue() VehicleIterator {
+	return Filter(m.Iterator(), NeedsMaintenance)
+}
+
+// All adapts the same traversal to Go's range-over-func iterators (Go 1.23+)
+func (m *VehicleManager) All() iter.Seq[Vehicular] {
+	return func(yield func(Vehicular) bool) {
+		for it := m.Iterator(); it.HasNext(); {
+			if !yield(it.Next()) {
+				return
+			}
+		}
+	}
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Iterator Pattern in Go ===")
+
+	manager := &VehicleManager{}
+	manager.Add(
+		&Car{brand: "Toyota", mileage: 42000, lastServiceAt: 40000},
+		&Motorcycle{brand: "Ducati", mileage: 15500, lastServiceAt: 5000},
+		&Truck{brand: "Volvo", mileage: 210000, lastServiceAt: 195000, payloadTons: 18},
+		&Car{brand: "Honda", mileage: 9000, lastServiceAt: 0},
+	)
+
+	fmt.Println("\n1. Every vehicle (HasNext / Next):")
+	for it := manager.Iterator(); it.HasNext(); {
+		v := it.Next()
+		fmt.Printf("  %-20s %8.0f km\n", v.DisplayInfo(), v.MileageKm())
+	}
+
+	fmt.Println("\n2. Filtered: maintenance due:")
+	for it := manager.MaintenanceDue(); it.HasNext(); {
+		v := it.Next()
+		fmt.Printf("  %-20s %8.0f km since service\n", v.DisplayInfo(), v.MileageKm()-v.LastServiceKm())
+	}
+
+	fmt.Println("\n3. Same traversal with range-over-func, stopping early:")
+	for v := range manager.All() {
+		fmt.Printf("  %s\n", v.DisplayInfo())
+		if _, isTruck := v.(*Truck); isTruck {
+			fmt.Println("  (found a truck, stop)")
+			break
+		}
+	}
+
+	fmt.Println("\n=== Iterator demonstrated ===")
+}
@@ -0,0 +1,187 @@
+// Mediator Pattern - Go
+// Flow: Employees (Manager, TeamLead, Developer) -> WorkflowMediator routes every handoff -> no direct references
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ============================================================================
+// 1. MESSAGES & MEDIATOR INTERFACE
+// ============================================================================
+
+type MessageKind string
+
+const (
+	TaskRequested MessageKind = "requested" // manager -> workflow
+	TaskAssigned  MessageKind = "assigned"  // workflow -> developer
+	TaskDone      MessageKind = "done"      // developer -> workflow
+	ReviewNeeded  MessageKind = "review"    // workflow -> team lead
+	TaskApproved  MessageKind = "approved"  // team lead -> workflow
+	TaskShipped   MessageKind = "shipped"   // workflow -> manager
+)
+
+type Message struct {
+	From string
+	Kind MessageKind
+	Task string
+}
+
+// Participants only know the mediator, never each other
+type Participant interface {
+	Name() string
+	Receive(msg Message)
+}
+
+type WorkflowMediator interface {
+	Register(p Participant)
+	Send(msg Message)
+}
+
+// ============================================================================
+// 2. COLLEAGUES - the tour's employee types
+// ============================================================================
+
+type Employee struct {
+	name     string
+	mediator WorkflowMediator
+}
+
+func (e *Employee) Name() string { return e.name }
+
+func (e *Employee) send(kind MessageKind, task string) {
+	e.mediator.Send(Message{From: e.name, Kind: kind, Task: task})
+}
+
+type Manager struct {
+	Employee
+	shipped []string
+}
+
+func (m *Manager) Request(task string) { m.send(TaskRequested, task) }
+
+func (m *Manager) Receive(msg Message) {
+	if msg.Kind == TaskShipped {
+		m.shipped = append(m.shipped, msg.Task)
+		fmt.Printf("  %s: great, %q is shipped\n", m.name, msg.Task)
+	}
+}
+
+type Developer struct {
+	Employee
+	queue []string
+}
+
+func (d *Developer) Receive(msg Message) {
+	if msg.Kind == TaskAssigned {
+		d.queue = append(d.queue, msg.Task)
+		fmt.Printf("  %s: picked up %q\n", d.name, msg.Task)
+	}
+}
+
+// Work finishes the oldest task in the queue
+func (d *Developer) Work() {
+	if len(d.queue) == 0 {
+		return
+	}
+	task := d.queue[0]
+	d.queue = d.queue[1:]
+	d.send(TaskDone, task)
+}
+
+type TeamLead struct {
+	Employee
+}
+
+func (t *TeamLead) Receive(msg Message) {
+	if msg.Kind == ReviewNeeded {
+		fmt.Printf("  %s: reviewed %q (by %s), approving\n", t.name, msg.Task, msg.From)
+		t.send(TaskApproved, msg.Task)
+	}
+}
+
+// ============================================================================
+// 3. CONCRETE MEDIATOR - all routing rules live here
+// ============================================================================
+
+type TaskBoard struct {
+	managers   []*Manager
+	leads      []*TeamLead
+	developers []*Developer
+	log        []Message
+}
+
+func (b *TaskBoard) Register(p Participant) {
+	switch v := p.(type) {
+	case *Manager:
+		b.managers = append(b.managers, v)
+	case *TeamLead:
+		b.leads = append(b.leads, v)
+	case *Developer:
+		b.developers = append(b.developers, v)
+	}
+}
+
+// Send decides who hears what; changing the workflow only touches this method
+func (b *TaskBoard) Send(msg Message) {
+	b.log = append(b.log, msg)
+	fmt.Printf("[board] %s %s %q\n", msg.From, msg.Kind, msg.Task)
+	switch msg.Kind {
+	case TaskRequested:
+		if dev := b.leastBusyDeveloper(); dev != nil {
+			dev.Receive(Message{From: msg.From, Kind: TaskAssigned, Task: msg.Task})
+		}
+	case TaskDone:
+		for _, lead := range b.leads {
+			lead.Receive(Message{From: msg.From, Kind: ReviewNeeded, Task: msg.Task})
+		}
+	case TaskApproved:
+		for _, manager := range b.managers {
+			manager.Receive(Message{From: msg.From, Kind: TaskShipped, Task: msg.Task})
+		}
+	}
+}
+
+func (b *TaskBoard) leastBusyDeveloper() *Developer {
+	if len(b.developers) == 0 {
+		return nil
+	}
+	devs := append([]*Developer(nil), b.developers...)
+	sort.SliceStable(devs, func(i, j int) bool { return len(devs[i].queue) < len(devs[j].queue) })
+	return devs[0]
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Mediator Pattern in Go ===")
+
+	board := &TaskBoard{}
+	bob := &Manager{Employee: Employee{name: "Bob", mediator: board}}
+	frank := &TeamLead{Employee: Employee{name: "Frank", mediator: board}}
+	charlie := &Developer{Employee: Employee{name: "Charlie", mediator: board}}
+	david := &Developer{Employee: Employee{name: "David", mediator: board}}
+	for _, p := range []Participant{bob, frank, charlie, david} {
+		board.Register(p)
+	}
+
+	fmt.Println("\n1. Manager requests work, the board assigns it:")
+	bob.Request("login page")
+	bob.Request("payment API")
+	bob.Request("audit export")
+
+	fmt.Println("\n2. Developers finish, the board routes to review and back:")
+	charlie.Work()
+	david.Work()
+
+	fmt.Printf("\nBoard handled %d messages; Bob shipped %v\n", len(board.log), bob.shipped)
+	fmt.Println("Developer, TeamLead and Manager hold no pointers to each other.")
+
+	fmt.Println("\n=== Mediator demonstrated ===")
+}
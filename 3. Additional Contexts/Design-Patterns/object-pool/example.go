@@ -0,0 +1,171 @@
+// Object Pool Pattern - Go
+// Flow: Expensive Engine -> Channel-backed EnginePool (bounded) -> sync.Pool variant -> Fleet Simulation
+//
+// Run:        go run example.go [-trips 20000]
+// Benchmarks: go test -bench . -benchmem example.go example_test.go
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ============================================================================
+// 1. THE EXPENSIVE OBJECT - an Engine with a large telemetry buffer
+// ============================================================================
+
+const telemetrySamples = 4096
+
+type Engine struct {
+	id        int
+	rpm       [telemetrySamples]float64 // 32 KB per engine
+	samples   int
+	totalRuns int
+}
+
+var enginesBuilt atomic.Int64
+
+// NewEngine stands in for a costly constructor; kept out of line, so the
+// engine lives on the heap as a real one would instead of the caller's stack
+//
+//go:noinline
+func NewEngine() *Engine {
+	return &Engine{id: int(enginesBuilt.Add(1))}
+}
+
+// Reset clears per-trip state so a recycled engine looks brand new to callers
+func (e *Engine) Reset() {
+	e.samples = 0
+}
+
+// Run simulates one trip and returns the average rpm
+func (e *Engine) Run(distanceKm int) float64 {
+	e.totalRuns++
+	sum := 0.0
+	for i := 0; i < distanceKm && e.samples < telemetrySamples; i++ {
+		rpm := 1500 + float64((i*37)%2000)
+		e.rpm[e.samples] = rpm
+		e.samples++
+		sum += rpm
+	}
+	if e.samples == 0 {
+		return 0
+	}
+	return sum / float64(e.samples)
+}
+
+// ============================================================================
+// 2. CHANNEL-BACKED POOL - bounded, never creates more than capacity engines
+// ============================================================================
+
+type EnginePool struct {
+	engines chan *Engine
+}
+
+func NewEnginePool(capacity int) *EnginePool {
+	pool := &EnginePool{engines: make(chan *Engine, capacity)}
+	for i := 0; i < capacity; i++ {
+		pool.engines <- NewEngine()
+	}
+	return pool
+}
+
+// Acquire blocks until an engine is free: the pool also limits concurrency
+func (p *EnginePool) Acquire() *Engine {
+	return <-p.engines
+}
+
+func (p *EnginePool) Release(e *Engine) {
+	e.Reset()
+	p.engines <- e
+}
+
+// ============================================================================
+// 3. sync.Pool VARIANT - unbounded, GC may drop idle engines
+// ============================================================================
+
+var syncEnginePool = sync.Pool{New: func() any { return NewEngine() }}
+
+func acquireSyncEngine() *Engine { return syncEnginePool.Get().(*Engine) }
+
+func releaseSyncEngine(e *Engine) {
+	e.Reset()
+	syncEnginePool.Put(e)
+}
+
+// ============================================================================
+// 4. FLEET SIMULATION - many short trips, three allocation strategies
+// ============================================================================
+
+func simulateFresh(trips, workers int) {
+	runTrips(trips, workers, func(distance int) {
+		NewEngine().Run(distance)
+	})
+}
+
+func simulateChannelPool(trips, workers int, pool *EnginePool) {
+	runTrips(trips, workers, func(distance int) {
+		engine := pool.Acquire()
+		engine.Run(distance)
+		pool.Release(engine)
+	})
+}
+
+func simulateSyncPool(trips, workers int) {
+	runTrips(trips, workers, func(distance int) {
+		engine := acquireSyncEngine()
+		engine.Run(distance)
+		releaseSyncEngine(engine)
+	})
+}
+
+func runTrips(trips, workers int, trip func(distance int)) {
+	var wg sync.WaitGroup
+	per := trips / workers
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(offset int) {
+			defer wg.Done()
+			for i := 0; i < per; i++ {
+				trip(50 + (offset+i)%200)
+			}
+		}(w * per)
+	}
+	wg.Wait()
+}
+
+// ============================================================================
+// 5. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	trips := flag.Int("trips", 20000, "trips per simulation")
+	workers := flag.Int("workers", 8, "concurrent drivers")
+	flag.Parse()
+
+	fmt.Println("=== Object Pool Pattern in Go ===")
+
+	fmt.Printf("\n1. Engines built for %d trips with %d drivers:\n", *trips, *workers)
+	enginesBuilt.Store(0)
+	simulateFresh(*trips, *workers)
+	fmt.Printf("  fresh per trip:     %6d engines\n", enginesBuilt.Load())
+
+	enginesBuilt.Store(0)
+	pool := NewEnginePool(*workers)
+	simulateChannelPool(*trips, *workers, pool)
+	fmt.Printf("  channel EnginePool: %6d engines (bounded by pool size)\n", enginesBuilt.Load())
+
+	enginesBuilt.Store(0)
+	simulateSyncPool(*trips, *workers)
+	fmt.Printf("  sync.Pool:          %6d engines (reused, but GC may evict)\n", enginesBuilt.Load())
+
+	fmt.Println("\n2. A recycled engine is reset before reuse:")
+	engine := pool.Acquire()
+	fmt.Printf("  engine #%d: %d runs so far, %d samples in buffer\n", engine.id, engine.totalRuns, engine.samples)
+	pool.Release(engine)
+
+	fmt.Println("\n=== Object Pool demonstrated ===")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReleasedEngineIsResetAndReused(t *testing.T) {
+	pool := NewEnginePool(1)
+	engine := pool.Acquire()
+	engine.Run(100)
+	pool.Release(engine)
+
+	again := pool.Acquire()
+	defer pool.Release(again)
+	if again != engine {
+		t.Fatal("a pool of one handed out a different engine")
+	}
+	if again.samples != 0 || again.totalRuns != 1 {
+		t.Fatalf("recycled engine: %d samples, %d runs; want 0 samples and its run count kept", again.samples, again.totalRuns)
+	}
+}
+
+// An exhausted pool blocks Acquire until an engine comes back; it never
+// builds an extra one
+func TestExhaustedPoolBlocksUntilRelease(t *testing.T) {
+	pool := NewEnginePool(2)
+	built := enginesBuilt.Load()
+	held := []*Engine{pool.Acquire(), pool.Acquire()}
+
+	acquired := make(chan *Engine)
+	go func() { acquired <- pool.Acquire() }()
+	select {
+	case <-acquired:
+		t.Fatal("Acquire returned while every engine was in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.Release(held[0])
+	select {
+	case engine := <-acquired:
+		if engine != held[0] {
+			t.Error("the waiting caller did not get the released engine")
+		}
+		pool.Release(engine)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire still blocked after a release")
+	}
+	pool.Release(held[1])
+	if extra := enginesBuilt.Load() - built; extra != 0 {
+		t.Errorf("pool built %d engines after construction", extra)
+	}
+}
+
+// The pool size bounds how many trips run at once, whatever the driver count
+func TestPoolBoundsConcurrency(t *testing.T) {
+	const capacity = 3
+	pool := NewEnginePool(capacity)
+	var inUse, peak atomic.Int32
+	runTrips(2000, 16, func(distance int) {
+		engine := pool.Acquire()
+		n := inUse.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		engine.Run(distance)
+		inUse.Add(-1)
+		pool.Release(engine)
+	})
+	if peak.Load() > capacity {
+		t.Fatalf("%d engines in use at once, pool holds %d", peak.Load(), capacity)
+	}
+}
+
+// One op is one full simulation of benchTrips trips by benchWorkers drivers
+const (
+	benchTrips   = 2000
+	benchWorkers = 8
+)
+
+func BenchmarkFreshEnginePerTrip(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		simulateFresh(benchTrips, benchWorkers)
+	}
+}
+
+func BenchmarkChannelEnginePool(b *testing.B) {
+	pool := NewEnginePool(benchWorkers)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		simulateChannelPool(benchTrips, benchWorkers, pool)
+	}
+}
+
+func BenchmarkSyncPool(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		simulateSyncPool(benchTrips, benchWorkers)
+	}
+}
@@ -0,0 +1,181 @@
+// Prototype Pattern - Go
+// Flow: Vehicles with Pointer Parts -> Shallow Copy Bug -> Cloneable Deep Copy -> Prototype Registry
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// 1. VEHICLES - some hold pointers, which is where copying goes wrong
+// ============================================================================
+
+type Vehicular interface {
+	DisplayInfo()
+}
+
+// Cloneable returns an independent copy that shares no mutable state
+type Cloneable interface {
+	Vehicular
+	Clone() Vehicular
+}
+
+type Engine struct {
+	Horsepower int
+}
+
+type GPS struct {
+	Provider  string
+	Waypoints []string
+}
+
+type Car struct {
+	brand string
+	color string
+}
+
+func (c *Car) DisplayInfo() { fmt.Printf("Car %s (%s)\n", c.brand, c.color) }
+
+// Car has only value fields: copying the struct is already a deep copy
+func (c *Car) Clone() Vehicular {
+	clone := *c
+	return &clone
+}
+
+type SportsCar struct {
+	Car
+	engine *Engine
+}
+
+func (s *SportsCar) DisplayInfo() {
+	fmt.Printf("SportsCar %s (%s, %d HP)\n", s.brand, s.color, s.engine.Horsepower)
+}
+
+func (s *SportsCar) Clone() Vehicular {
+	clone := *s
+	engine := *s.engine // new Engine, not the same pointer
+	clone.engine = &engine
+	return &clone
+}
+
+type AdvancedCar struct {
+	Car
+	engine   *Engine
+	gps      *GPS
+	features []string
+}
+
+func (a *AdvancedCar) DisplayInfo() {
+	route := "no GPS"
+	if a.gps != nil {
+		route = a.gps.Provider + " -> " + strings.Join(a.gps.Waypoints, " -> ")
+	}
+	fmt.Printf("AdvancedCar %s (%s, %d HP) [%s] route: %s\n",
+		a.brand, a.color, a.engine.Horsepower, strings.Join(a.features, ", "), route)
+}
+
+// Clone copies every pointer and slice; the slice inside GPS too
+func (a *AdvancedCar) Clone() Vehicular {
+	clone := *a
+	engine := *a.engine
+	clone.engine = &engine
+	if a.gps != nil {
+		gps := *a.gps
+		gps.Waypoints = append([]string(nil), a.gps.Waypoints...)
+		clone.gps = &gps
+	}
+	clone.features = append([]string(nil), a.features...)
+	return &clone
+}
+
+// ============================================================================
+// 2. PROTOTYPE REGISTRY - configured templates, cloned on demand
+// ============================================================================
+
+type PrototypeRegistry struct {
+	prototypes map[string]Cloneable
+}
+
+func NewPrototypeRegistry() *PrototypeRegistry {
+	return &PrototypeRegistry{prototypes: make(map[string]Cloneable)}
+}
+
+func (r *PrototypeRegistry) Register(name string, prototype Cloneable) {
+	r.prototypes[name] = prototype
+}
+
+func (r *PrototypeRegistry) Create(name string) (Vehicular, error) {
+	prototype, ok := r.prototypes[name]
+	if !ok {
+		return nil, fmt.Errorf("no prototype named %q", name)
+	}
+	return prototype.Clone(), nil
+}
+
+func (r *PrototypeRegistry) Names() []string {
+	names := make([]string, 0, len(r.prototypes))
+	for name := range r.prototypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ============================================================================
+// 3. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Prototype Pattern in Go ===")
+
+	template := &AdvancedCar{
+		Car:      Car{brand: "Tesla", color: "white"},
+		engine:   &Engine{Horsepower: 300},
+		gps:      &GPS{Provider: "satellite", Waypoints: []string{"Depot"}},
+		features: []string{"autopilot"},
+	}
+
+	fmt.Println("\n1. The shallow-copy bug (plain struct assignment):")
+	shallow := *template // copies the pointers, not what they point to
+	shallow.color = "red"
+	shallow.engine.Horsepower = 500
+	shallow.gps.Waypoints = append(shallow.gps.Waypoints, "Airport")
+	fmt.Print("template: ")
+	template.DisplayInfo() // engine and route changed too
+	fmt.Print("copy:     ")
+	shallow.DisplayInfo()
+
+	fmt.Println("\n2. Clone() deep-copies pointers and slices:")
+	template.engine.Horsepower = 300
+	template.gps.Waypoints = []string{"Depot"}
+	deep := template.Clone().(*AdvancedCar)
+	deep.color = "blue"
+	deep.engine.Horsepower = 500
+	deep.gps.Waypoints = append(deep.gps.Waypoints, "Airport")
+	deep.features = append(deep.features, "heated seats")
+	fmt.Print("template: ")
+	template.DisplayInfo()
+	fmt.Print("clone:    ")
+	deep.DisplayInfo()
+
+	fmt.Println("\n3. Prototype registry:")
+	registry := NewPrototypeRegistry()
+	registry.Register("city-car", &Car{brand: "Fiat", color: "yellow"})
+	registry.Register("track-car", &SportsCar{Car: Car{brand: "Porsche", color: "silver"}, engine: &Engine{Horsepower: 510}})
+	registry.Register("fleet-ev", template)
+	for _, name := range registry.Names() {
+		vehicle, _ := registry.Create(name)
+		fmt.Printf("%-10s -> ", name)
+		vehicle.DisplayInfo()
+	}
+	if _, err := registry.Create("bus"); err != nil {
+		fmt.Println("error:", err)
+	}
+
+	fmt.Println("\n=== Prototype demonstrated ===")
+}
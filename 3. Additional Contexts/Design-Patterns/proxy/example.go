@@ -0,0 +1,183 @@
+// Proxy Pattern - Go
+// Flow: Account Interface -> Real BankAccount -> AccountProxy (auth + audit) -> Client
+//
+// Run: go run example.go
+// See also: ../../../1. Object-Oriented-Programming/banking (role-based SecuredAccount proxy)
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ============================================================================
+// 1. SUBJECT - the behavior surface both the real object and the proxy offer
+// ============================================================================
+
+type Account interface {
+	Balance() (float64, error)
+	Deposit(amount float64) error
+	Withdraw(amount float64) error
+}
+
+var (
+	ErrInvalidAmount     = errors.New("amount must be positive")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrUnauthenticated   = errors.New("caller is not authenticated")
+	ErrNotOwner          = errors.New("caller does not own this account")
+)
+
+// ============================================================================
+// 2. REAL SUBJECT - knows nothing about who is calling
+// ============================================================================
+
+type BankAccount struct {
+	accountNumber string
+	owner         string
+	balance       float64
+}
+
+func NewBankAccount(accountNumber, owner string, initialBalance float64) *BankAccount {
+	return &BankAccount{accountNumber: accountNumber, owner: owner, balance: initialBalance}
+}
+
+func (ba *BankAccount) Balance() (float64, error) { return ba.balance, nil }
+
+func (ba *BankAccount) Deposit(amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	ba.balance += amount
+	return nil
+}
+
+func (ba *BankAccount) Withdraw(amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if amount > ba.balance {
+		return ErrInsufficientFunds
+	}
+	ba.balance -= amount
+	return nil
+}
+
+// ============================================================================
+// 3. PROXY - same interface, checks and records before delegating
+// ============================================================================
+
+type Caller struct {
+	User          string
+	Authenticated bool
+}
+
+type AuditEntry struct {
+	At        time.Time
+	User      string
+	Operation string
+	Amount    float64
+	Err       error
+}
+
+type AuditLog interface {
+	Record(entry AuditEntry)
+}
+
+type MemoryAuditLog struct {
+	entries []AuditEntry
+}
+
+func (l *MemoryAuditLog) Record(entry AuditEntry) { l.entries = append(l.entries, entry) }
+
+// AccountProxy holds the real account privately, so callers holding a
+// proxy cannot reach around the checks
+type AccountProxy struct {
+	account *BankAccount
+	caller  Caller
+	audit   AuditLog
+	now     func() time.Time
+}
+
+func NewAccountProxy(account *BankAccount, caller Caller, audit AuditLog) *AccountProxy {
+	return &AccountProxy{account: account, caller: caller, audit: audit, now: time.Now}
+}
+
+func (p *AccountProxy) authorize() error {
+	if !p.caller.Authenticated {
+		return ErrUnauthenticated
+	}
+	if p.caller.User != p.account.owner {
+		return fmt.Errorf("%w: %s is owned by %s", ErrNotOwner, p.account.accountNumber, p.account.owner)
+	}
+	return nil
+}
+
+// guard runs every call through the same check-delegate-audit steps
+func (p *AccountProxy) guard(operation string, amount float64, call func() error) error {
+	err := p.authorize()
+	if err == nil {
+		err = call()
+	}
+	p.audit.Record(AuditEntry{At: p.now(), User: p.caller.User, Operation: operation, Amount: amount, Err: err})
+	return err
+}
+
+func (p *AccountProxy) Balance() (float64, error) {
+	var balance float64
+	err := p.guard("balance", 0, func() (err error) {
+		balance, err = p.account.Balance()
+		return err
+	})
+	return balance, err
+}
+
+func (p *AccountProxy) Deposit(amount float64) error {
+	return p.guard("deposit", amount, func() error { return p.account.Deposit(amount) })
+}
+
+func (p *AccountProxy) Withdraw(amount float64) error {
+	return p.guard("withdraw", amount, func() error { return p.account.Withdraw(amount) })
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+// payRent is client code: it cannot tell a proxy from the real account
+func payRent(account Account, rent float64) {
+	if err := account.Withdraw(rent); err != nil {
+		fmt.Printf("  rent not paid: %v\n", err)
+		return
+	}
+	balance, _ := account.Balance()
+	fmt.Printf("  rent paid, balance %.2f\n", balance)
+}
+
+func main() {
+	fmt.Println("=== Proxy Pattern in Go ===")
+
+	account := NewBankAccount("ACC001", "alice", 1000)
+	audit := &MemoryAuditLog{}
+
+	fmt.Println("\n1. Owner, authenticated:")
+	payRent(NewAccountProxy(account, Caller{User: "alice", Authenticated: true}, audit), 400)
+
+	fmt.Println("\n2. Someone else:")
+	payRent(NewAccountProxy(account, Caller{User: "mallory", Authenticated: true}, audit), 400)
+
+	fmt.Println("\n3. Owner, but not logged in:")
+	payRent(NewAccountProxy(account, Caller{User: "alice"}, audit), 400)
+
+	fmt.Println("\n4. Audit trail (every attempt, allowed or not):")
+	for _, entry := range audit.entries {
+		result := "ok"
+		if entry.Err != nil {
+			result = entry.Err.Error()
+		}
+		fmt.Printf("  %-8s %-9s %7.2f  %s\n", entry.User, entry.Operation, entry.Amount, result)
+	}
+
+	fmt.Println("\n=== Proxy demonstrated ===")
+}
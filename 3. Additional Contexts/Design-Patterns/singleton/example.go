@@ -0,0 +1,176 @@
+// Singleton Pattern - Go
+// Flow: Package Globals (problem) -> Lazy sync.Once Singleton -> Concurrent Registration -> Trade-offs
+//
+// Run: go run example.go   (try: go run -race example.go)
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ============================================================================
+// 1. THE PROBLEM - ad-hoc package counters
+// ============================================================================
+
+// The original tour kept `var totalEmployees, totalAccounts int` and bumped
+// them from constructors: racy, no lookup, nothing to describe what exists.
+
+// ============================================================================
+// 2. SINGLETON - one lazily created, concurrency-safe VehicleRegistry
+// ============================================================================
+
+type Vehicular interface {
+	DisplayInfo() string
+}
+
+type Car struct{ brand string }
+
+func (c *Car) DisplayInfo() string { return "Car " + c.brand }
+
+type Motorcycle struct{ brand string }
+
+func (m *Motorcycle) DisplayInfo() string { return "Motorcycle " + m.brand }
+
+type VehicleRegistry struct {
+	mu       sync.RWMutex
+	vehicles map[string]Vehicular
+	byKind   map[string]int
+}
+
+var (
+	registryOnce     sync.Once
+	registryInstance *VehicleRegistry
+	registryInits    int // only to prove the initializer ran once
+)
+
+// GetVehicleRegistry is the only way to reach the registry; the first caller
+// builds it, every other caller (on any goroutine) waits and gets the same one
+func GetVehicleRegistry() *VehicleRegistry {
+	registryOnce.Do(func() {
+		registryInits++
+		registryInstance = &VehicleRegistry{
+			vehicles: make(map[string]Vehicular),
+			byKind:   make(map[string]int),
+		}
+	})
+	return registryInstance
+}
+
+func (r *VehicleRegistry) Register(id string, vehicle Vehicular) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.vehicles[id]; exists {
+		return fmt.Errorf("vehicle %s already registered", id)
+	}
+	r.vehicles[id] = vehicle
+	r.byKind[fmt.Sprintf("%T", vehicle)]++
+	return nil
+}
+
+func (r *VehicleRegistry) Lookup(id string) (Vehicular, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	vehicle, ok := r.vehicles[id]
+	return vehicle, ok
+}
+
+func (r *VehicleRegistry) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.vehicles)
+}
+
+// CountsByKind replaces one global counter per type
+func (r *VehicleRegistry) CountsByKind() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	counts := make(map[string]int, len(r.byKind))
+	for kind, n := range r.byKind {
+		counts[kind] = n
+	}
+	return counts
+}
+
+// ============================================================================
+// 3. CONSTRUCTORS - register on creation instead of bumping a global
+// ============================================================================
+
+func NewCar(id, brand string) *Car {
+	car := &Car{brand: brand}
+	if err := GetVehicleRegistry().Register(id, car); err != nil {
+		fmt.Println("warning:", err)
+	}
+	return car
+}
+
+func NewMotorcycle(id, brand string) *Motorcycle {
+	motorcycle := &Motorcycle{brand: brand}
+	if err := GetVehicleRegistry().Register(id, motorcycle); err != nil {
+		fmt.Println("warning:", err)
+	}
+	return motorcycle
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Singleton Pattern in Go ===")
+
+	fmt.Println("\n1. Concurrent first access still creates one instance:")
+	var wg sync.WaitGroup
+	instances := make([]*VehicleRegistry, 50)
+	for i := range instances {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			instances[n] = GetVehicleRegistry()
+		}(i)
+	}
+	wg.Wait()
+	same := true
+	for _, instance := range instances {
+		same = same && instance == instances[0]
+	}
+	fmt.Printf("initializer ran %d time(s), all 50 callers got the same pointer: %v\n", registryInits, same)
+
+	fmt.Println("\n2. Demos register vehicles from many goroutines:")
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if n%4 == 0 {
+				NewMotorcycle(fmt.Sprintf("M-%03d", n), "Yamaha")
+				return
+			}
+			NewCar(fmt.Sprintf("C-%03d", n), "Toyota")
+		}(i)
+	}
+	wg.Wait()
+	registry := GetVehicleRegistry()
+	fmt.Printf("registered: %d\n", registry.Count())
+	counts := registry.CountsByKind()
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Printf("  %-16s %d\n", kind, counts[kind])
+	}
+	if vehicle, ok := registry.Lookup("M-004"); ok {
+		fmt.Printf("lookup M-004: %s\n", vehicle.DisplayInfo())
+	}
+	NewCar("C-001", "Duplicate")
+
+	fmt.Println("\n3. Trade-off:")
+	fmt.Println("A singleton is still global state: tests cannot swap it, and every")
+	fmt.Println("constructor now depends on it. Prefer passing a *VehicleRegistry in")
+	fmt.Println("(dependency injection) and keep the accessor for the composition root.")
+
+	fmt.Println("\n=== Singleton demonstrated ===")
+}
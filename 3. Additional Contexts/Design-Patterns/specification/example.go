@@ -0,0 +1,196 @@
+// Specification Pattern - Go
+// Flow: Generic Specification[T] -> And / Or / Not -> Payment & Vehicle specs -> Repositories query by spec
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// 1. SPECIFICATION - a named, composable predicate
+// ============================================================================
+
+type Specification[T any] interface {
+	IsSatisfiedBy(item T) bool
+	Describe() string
+}
+
+// specFunc turns a function into a Specification
+type specFunc[T any] struct {
+	test        func(T) bool
+	description string
+}
+
+func (s specFunc[T]) IsSatisfiedBy(item T) bool { return s.test(item) }
+func (s specFunc[T]) Describe() string          { return s.description }
+
+func NewSpec[T any](description string, test func(T) bool) Specification[T] {
+	return specFunc[T]{test: test, description: description}
+}
+
+func And[T any](specs ...Specification[T]) Specification[T] {
+	return specFunc[T]{
+		test: func(item T) bool {
+			for _, s := range specs {
+				if !s.IsSatisfiedBy(item) {
+					return false
+				}
+			}
+			return true
+		},
+		description: join(" AND ", specs),
+	}
+}
+
+func Or[T any](specs ...Specification[T]) Specification[T] {
+	return specFunc[T]{
+		test: func(item T) bool {
+			for _, s := range specs {
+				if s.IsSatisfiedBy(item) {
+					return true
+				}
+			}
+			return false
+		},
+		description: join(" OR ", specs),
+	}
+}
+
+func Not[T any](spec Specification[T]) Specification[T] {
+	return specFunc[T]{
+		test:        func(item T) bool { return !spec.IsSatisfiedBy(item) },
+		description: "NOT " + spec.Describe(),
+	}
+}
+
+func join[T any](sep string, specs []Specification[T]) string {
+	parts := make([]string, len(specs))
+	for i, s := range specs {
+		parts[i] = s.Describe()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// ============================================================================
+// 2. DOMAIN + CONCRETE SPECS
+// ============================================================================
+
+type Payment struct {
+	ID       string
+	Amount   float64
+	Currency string
+	Method   string
+	At       time.Time
+}
+
+func PaymentsOverAmount(min float64) Specification[Payment] {
+	return NewSpec(fmt.Sprintf("amount > %.2f", min), func(p Payment) bool { return p.Amount > min })
+}
+
+func PaymentsInCurrency(currency string) Specification[Payment] {
+	return NewSpec("currency = "+currency, func(p Payment) bool { return p.Currency == currency })
+}
+
+func PaymentsByMethod(method string) Specification[Payment] {
+	return NewSpec("method = "+method, func(p Payment) bool { return p.Method == method })
+}
+
+type Vehicle struct {
+	ID            string
+	Kind          string
+	MileageKm     float64
+	LastServiceKm float64
+	Year          int
+}
+
+const serviceIntervalKm = 10000
+
+func VehiclesNeedingMaintenance() Specification[Vehicle] {
+	return NewSpec("needs maintenance", func(v Vehicle) bool {
+		return v.MileageKm-v.LastServiceKm >= serviceIntervalKm
+	})
+}
+
+func VehiclesOfKind(kind string) Specification[Vehicle] {
+	return NewSpec("kind = "+kind, func(v Vehicle) bool { return v.Kind == kind })
+}
+
+func VehiclesOlderThan(year int) Specification[Vehicle] {
+	return NewSpec(fmt.Sprintf("year < %d", year), func(v Vehicle) bool { return v.Year < year })
+}
+
+// ============================================================================
+// 3. REPOSITORIES - one Find(spec) instead of FindByX, FindByXAndY, ...
+// ============================================================================
+
+type Repository[T any] struct {
+	items []T
+}
+
+func (r *Repository[T]) Add(items ...T) { r.items = append(r.items, items...) }
+
+func (r *Repository[T]) Find(spec Specification[T]) []T {
+	var found []T
+	for _, item := range r.items {
+		if spec.IsSatisfiedBy(item) {
+			found = append(found, item)
+		}
+	}
+	return found
+}
+
+func (r *Repository[T]) Count(spec Specification[T]) int { return len(r.Find(spec)) }
+
+type PaymentRepository = Repository[Payment]
+type VehicleRepository = Repository[Vehicle]
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Specification Pattern in Go ===")
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	payments := &PaymentRepository{}
+	payments.Add(
+		Payment{"PAY-1", 25, "USD", "card", day},
+		Payment{"PAY-2", 480, "USD", "paypal", day},
+		Payment{"PAY-3", 1200, "EUR", "card", day},
+		Payment{"PAY-4", 90, "EUR", "paypal", day},
+		Payment{"PAY-5", 5000, "USD", "card", day},
+	)
+
+	fmt.Println("\n1. Payments:")
+	largeCardUSD := And(PaymentsOverAmount(100), PaymentsInCurrency("USD"), PaymentsByMethod("card"))
+	reviewQueue := Or(largeCardUSD, And(PaymentsInCurrency("EUR"), Not(PaymentsByMethod("card"))))
+	for _, spec := range []Specification[Payment]{largeCardUSD, reviewQueue} {
+		fmt.Printf("  %s\n   ->", spec.Describe())
+		for _, p := range payments.Find(spec) {
+			fmt.Printf(" %s", p.ID)
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("\n2. Vehicles:")
+	vehicles := &VehicleRepository{}
+	vehicles.Add(
+		Vehicle{"V-1", "car", 42000, 40000, 2021},
+		Vehicle{"V-2", "truck", 210000, 195000, 2015},
+		Vehicle{"V-3", "car", 88000, 70000, 2016},
+		Vehicle{"V-4", "motorcycle", 15500, 5000, 2019},
+	)
+	urgent := And(VehiclesNeedingMaintenance(), Or(VehiclesOfKind("truck"), VehiclesOlderThan(2018)))
+	fmt.Printf("  %s\n   ->", urgent.Describe())
+	for _, v := range vehicles.Find(urgent) {
+		fmt.Printf(" %s(%s)", v.ID, v.Kind)
+	}
+	fmt.Printf("\n  due but not urgent: %d\n", vehicles.Count(And(VehiclesNeedingMaintenance(), Not(urgent))))
+
+	fmt.Println("\n=== Specification demonstrated ===")
+}
@@ -0,0 +1,165 @@
+// Strategy Pattern - Go
+// Flow: Hard-coded numbers (problem) -> FuelEfficiencyStrategy -> PricingStrategy -> Swap at runtime
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// ============================================================================
+// 1. FUEL-EFFICIENCY STRATEGIES - how a vehicle's base rating is adjusted
+// ============================================================================
+
+// Before: func (c *Car) CalculateFuelEfficiency() float64 { return 15.5 }
+// and a different constant per type, with no way to model driving conditions.
+
+type FuelEfficiencyStrategy interface {
+	Name() string
+	KmPerLiter(baseRating float64, weightKg float64) float64
+}
+
+// City driving: stop-and-go punishes heavy vehicles
+type CityDriving struct{}
+
+func (CityDriving) Name() string { return "city" }
+func (CityDriving) KmPerLiter(base, weightKg float64) float64 {
+	return base * 0.75 * math.Sqrt(1200/math.Max(weightKg, 1200))
+}
+
+type HighwayDriving struct{}
+
+func (HighwayDriving) Name() string                              { return "highway" }
+func (HighwayDriving) KmPerLiter(base, weightKg float64) float64 { return base * 1.15 }
+
+// EcoDriving wraps another strategy and adds a fixed improvement
+type EcoDriving struct {
+	Base        FuelEfficiencyStrategy
+	Improvement float64 // 0.1 = 10% better
+}
+
+func (e EcoDriving) Name() string { return "eco " + e.Base.Name() }
+func (e EcoDriving) KmPerLiter(base, weightKg float64) float64 {
+	return e.Base.KmPerLiter(base, weightKg) * (1 + e.Improvement)
+}
+
+// ============================================================================
+// 2. VEHICLES - strategy injected, swappable at runtime
+// ============================================================================
+
+type Vehicle struct {
+	brand      string
+	kind       string
+	baseRating float64 // km/l on the test cycle
+	weightKg   float64
+	efficiency FuelEfficiencyStrategy
+}
+
+func NewVehicle(brand, kind string, baseRating, weightKg float64, efficiency FuelEfficiencyStrategy) *Vehicle {
+	return &Vehicle{brand: brand, kind: kind, baseRating: baseRating, weightKg: weightKg, efficiency: efficiency}
+}
+
+func (v *Vehicle) SetEfficiencyStrategy(strategy FuelEfficiencyStrategy) { v.efficiency = strategy }
+
+func (v *Vehicle) CalculateFuelEfficiency() float64 {
+	return v.efficiency.KmPerLiter(v.baseRating, v.weightKg)
+}
+
+// ============================================================================
+// 3. PRICING STRATEGIES - rental and fee calculations
+// ============================================================================
+
+type PricingStrategy interface {
+	Name() string
+	Price(v *Vehicle, days int, km float64) float64
+}
+
+type DailyRate struct {
+	PerDay map[string]float64 // by vehicle kind
+}
+
+func (DailyRate) Name() string { return "daily rate" }
+func (d DailyRate) Price(v *Vehicle, days int, km float64) float64 {
+	return d.PerDay[v.kind] * float64(days)
+}
+
+// PerKilometer charges for distance plus the fuel the trip will need
+type PerKilometer struct {
+	PerKm        float64
+	FuelPerLiter float64
+}
+
+func (PerKilometer) Name() string { return "per km + fuel" }
+func (p PerKilometer) Price(v *Vehicle, days int, km float64) float64 {
+	liters := km / v.CalculateFuelEfficiency() // strategies compose
+	return km*p.PerKm + liters*p.FuelPerLiter
+}
+
+// WeekendDiscount decorates any pricing strategy
+type WeekendDiscount struct {
+	Base    PricingStrategy
+	Percent float64
+}
+
+func (w WeekendDiscount) Name() string { return w.Base.Name() + " - weekend discount" }
+func (w WeekendDiscount) Price(v *Vehicle, days int, km float64) float64 {
+	return w.Base.Price(v, days, km) * (1 - w.Percent/100)
+}
+
+// RentalDesk is the context: it never branches on the strategy type
+type RentalDesk struct {
+	pricing PricingStrategy
+}
+
+func (d *RentalDesk) SetPricing(strategy PricingStrategy) { d.pricing = strategy }
+
+func (d *RentalDesk) Quote(v *Vehicle, days int, km float64) string {
+	return fmt.Sprintf("%s %s, %d day(s), %.0f km: %.2f (%s)", v.kind, v.brand, days, km, d.pricing.Price(v, days, km), d.pricing.Name())
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Strategy Pattern in Go ===")
+
+	car := NewVehicle("Toyota", "car", 16, 1300, CityDriving{})
+	truck := NewVehicle("Volvo", "truck", 8, 9000, CityDriving{})
+	motorcycle := NewVehicle("Honda", "motorcycle", 30, 200, CityDriving{})
+	fleet := []*Vehicle{car, truck, motorcycle}
+
+	fmt.Println("\n1. Same vehicles, efficiency strategy swapped at runtime (km/l):")
+	strategies := []FuelEfficiencyStrategy{CityDriving{}, HighwayDriving{}, EcoDriving{Base: HighwayDriving{}, Improvement: 0.1}}
+	fmt.Printf("  %-12s", "")
+	for _, strategy := range strategies {
+		fmt.Printf("%14s", strategy.Name())
+	}
+	fmt.Println()
+	for _, v := range fleet {
+		fmt.Printf("  %-12s", v.kind)
+		for _, strategy := range strategies {
+			v.SetEfficiencyStrategy(strategy)
+			fmt.Printf("%14.1f", v.CalculateFuelEfficiency())
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("\n2. Pricing strategies for the same rental:")
+	car.SetEfficiencyStrategy(HighwayDriving{})
+	desk := &RentalDesk{}
+	daily := DailyRate{PerDay: map[string]float64{"car": 45, "truck": 120, "motorcycle": 30}}
+	for _, pricing := range []PricingStrategy{
+		daily,
+		PerKilometer{PerKm: 0.12, FuelPerLiter: 1.8},
+		WeekendDiscount{Base: daily, Percent: 15},
+	} {
+		desk.SetPricing(pricing)
+		fmt.Println("  " + desk.Quote(car, 2, 600))
+	}
+
+	fmt.Println("\n=== Strategy demonstrated ===")
+}
@@ -0,0 +1,136 @@
+// Template Method Pattern - Go
+// Flow: Fixed Test Flow (template) -> TestSteps hooks -> DefaultSteps -> SportsCar / Motorcycle overrides
+//
+// Run: go run example.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ============================================================================
+// 1. HOOKS - the steps a vehicle may customize
+// ============================================================================
+
+type Measurement struct {
+	ZeroTo100Sec float64
+	BrakingM     float64
+}
+
+type TestSteps interface {
+	Name() string
+	PreCheck() error
+	WarmUp()
+	Measure() Measurement
+	Report(m Measurement) string
+}
+
+// DefaultSteps gives every hook a sensible default; embed it and override
+// only what differs. Go has no virtual methods on the embedded struct, so the
+// template lives in a function that calls through the TestSteps interface.
+type DefaultSteps struct {
+	name string
+}
+
+func (d DefaultSteps) Name() string    { return d.name }
+func (d DefaultSteps) PreCheck() error { fmt.Println("  pre-check: tires, lights, fluids"); return nil }
+func (d DefaultSteps) WarmUp()         { fmt.Println("  warm-up: 5 minutes idle") }
+func (d DefaultSteps) Measure() Measurement {
+	fmt.Println("  measure: standard track lap")
+	return Measurement{ZeroTo100Sec: 10.5, BrakingM: 38}
+}
+func (d DefaultSteps) Report(m Measurement) string {
+	return fmt.Sprintf("0-100 in %.1fs, braking %.0fm", m.ZeroTo100Sec, m.BrakingM)
+}
+
+// ============================================================================
+// 2. TEMPLATE METHOD - the order never changes, the steps do
+// ============================================================================
+
+type VehicleManager struct {
+	results []string
+}
+
+func (vm *VehicleManager) TestVehicle(v TestSteps) error {
+	fmt.Printf("Testing %s\n", v.Name())
+	if err := v.PreCheck(); err != nil {
+		return fmt.Errorf("%s: %w", v.Name(), err)
+	}
+	v.WarmUp()
+	report := v.Report(v.Measure())
+	vm.results = append(vm.results, v.Name()+": "+report)
+	return nil
+}
+
+// ============================================================================
+// 3. CONCRETE VEHICLES - override individual steps
+// ============================================================================
+
+type Car struct {
+	DefaultSteps // all defaults
+}
+
+type SportsCar struct {
+	DefaultSteps
+	launchControl bool
+}
+
+func (s SportsCar) WarmUp() { fmt.Println("  warm-up: tire warmers + two out-laps") }
+
+func (s SportsCar) Measure() Measurement {
+	fmt.Printf("  measure: drag strip, launch control %v\n", s.launchControl)
+	m := Measurement{ZeroTo100Sec: 4.2, BrakingM: 32}
+	if s.launchControl {
+		m.ZeroTo100Sec = 3.6
+	}
+	return m
+}
+
+var ErrPreCheckFailed = errors.New("pre-check failed")
+
+type Motorcycle struct {
+	DefaultSteps
+	helmetOnBoard bool
+}
+
+func (m Motorcycle) PreCheck() error {
+	fmt.Println("  pre-check: chain tension, tires, helmet")
+	if !m.helmetOnBoard {
+		return fmt.Errorf("%w: no helmet for the test rider", ErrPreCheckFailed)
+	}
+	return nil
+}
+
+func (m Motorcycle) Report(measurement Measurement) string {
+	return m.DefaultSteps.Report(measurement) + ", lean angle 48°" // extend, not replace
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	fmt.Println("=== Template Method Pattern in Go ===")
+
+	manager := &VehicleManager{}
+	vehicles := []TestSteps{
+		Car{DefaultSteps{name: "Toyota Corolla"}},
+		SportsCar{DefaultSteps: DefaultSteps{name: "Porsche 911"}, launchControl: true},
+		Motorcycle{DefaultSteps: DefaultSteps{name: "Ducati Monster"}, helmetOnBoard: true},
+		Motorcycle{DefaultSteps: DefaultSteps{name: "Vespa"}},
+	}
+	for _, v := range vehicles {
+		if err := manager.TestVehicle(v); err != nil {
+			fmt.Println("  aborted:", err)
+		}
+	}
+
+	fmt.Println("\nResults:")
+	for _, result := range manager.results {
+		fmt.Println("  " + result)
+	}
+
+	fmt.Println("\n=== Template Method demonstrated ===")
+}
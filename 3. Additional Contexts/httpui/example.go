@@ -0,0 +1,663 @@
+// Web Dashboard - Go
+// Flow: Query Interfaces -> Demo Backend -> Dashboard (net/http + html/template)
+//       Demo Backend -> Event Bus -> WebSocket feed (per-client filters)
+//
+// Serve:        go run example.go -addr localhost:8080   (open http://localhost:8080/)
+// Live feed:    ws://localhost:8080/ws/telemetry?topics=telemetry&vehicle=V-001
+// Render once:  go run example.go -render
+// Feed demo:    go run example.go -ws-demo
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// 1. QUERY INTERFACES - what the presentation layer is allowed to ask
+// ============================================================================
+
+// Read models: plain exported snapshots, never the live domain objects
+type VehicleStatus struct {
+	ID           string
+	Kind         string
+	Brand        string
+	MileageKm    float64
+	FuelPercent  float64
+	NeedsService bool
+}
+
+type AccountSummary struct {
+	Number  string
+	Owner   string
+	Balance float64
+}
+
+type PaymentRecord struct {
+	ID       string
+	Amount   float64
+	Currency string
+	Success  bool
+	At       time.Time
+}
+
+// The dashboard depends on these small interfaces (ISP + DIP), not on Fleet,
+// BankAccount or PaymentService, so any backend can feed it
+type FleetQuery interface {
+	VehicleStatuses() []VehicleStatus
+}
+
+type AccountQuery interface {
+	AccountSummaries() []AccountSummary
+}
+
+type PaymentQuery interface {
+	RecentPayments(limit int) []PaymentRecord
+}
+
+// ============================================================================
+// 2. DEMO BACKEND - a tiny live domain implementing the query interfaces
+// ============================================================================
+
+type vehicle struct {
+	id, kind, brand string
+	mileage, fuel   float64 // fuel in percent
+}
+
+type DemoBackend struct {
+	mu       sync.RWMutex
+	rng      *rand.Rand
+	vehicles []*vehicle
+	accounts []AccountSummary
+	payments []PaymentRecord
+	nextPay  int
+	events   *Bus
+}
+
+// NewDemoBackend publishes every change to events; pass nil to stay silent
+func NewDemoBackend(events *Bus) *DemoBackend {
+	return &DemoBackend{
+		rng:    rand.New(rand.NewPCG(1, 2)),
+		events: events,
+		vehicles: []*vehicle{
+			{id: "V-001", kind: "Car", brand: "Toyota", mileage: 12000, fuel: 80},
+			{id: "V-002", kind: "Car", brand: "Honda", mileage: 4800, fuel: 35},
+			{id: "V-003", kind: "Motorcycle", brand: "Yamaha", mileage: 9900, fuel: 60},
+		},
+		accounts: []AccountSummary{
+			{Number: "ACC001", Owner: "alice", Balance: 1300},
+			{Number: "ACC002", Owner: "bob", Balance: 250},
+		},
+	}
+}
+
+// Tick advances the simulation one step: vehicles drive, a payment happens
+func (b *DemoBackend) Tick(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, v := range b.vehicles {
+		v.mileage += b.rng.Float64() * 40
+		v.fuel -= b.rng.Float64() * 5
+		if v.fuel < 10 {
+			v.fuel = 100
+		}
+		b.publish(Event{Topic: TopicTelemetry, Vehicle: v.id, At: now, Data: map[string]float64{
+			"mileageKm": v.mileage, "fuelPercent": v.fuel,
+		}})
+	}
+	b.nextPay++
+	amount := float64(b.rng.IntN(20000)) / 100
+	success := b.rng.IntN(10) > 0
+	payment := PaymentRecord{
+		ID: fmt.Sprintf("PAY-%03d", b.nextPay), Amount: amount, Currency: "USD", Success: success, At: now,
+	}
+	b.payments = append(b.payments, payment)
+	b.publish(Event{Topic: TopicPayment, At: now, Data: payment})
+	if success {
+		account := &b.accounts[b.nextPay%len(b.accounts)]
+		account.Balance += amount
+		b.publish(Event{Topic: TopicAccount, At: now, Data: *account})
+	}
+}
+
+// publish never blocks, so it is safe while holding the backend lock
+func (b *DemoBackend) publish(event Event) {
+	if b.events != nil {
+		b.events.Publish(event)
+	}
+}
+
+func (b *DemoBackend) VehicleStatuses() []VehicleStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	statuses := make([]VehicleStatus, 0, len(b.vehicles))
+	for _, v := range b.vehicles {
+		statuses = append(statuses, VehicleStatus{
+			ID: v.id, Kind: v.kind, Brand: v.brand, MileageKm: v.mileage,
+			FuelPercent: v.fuel, NeedsService: int(v.mileage)%10000 > 9000,
+		})
+	}
+	return statuses
+}
+
+func (b *DemoBackend) AccountSummaries() []AccountSummary {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]AccountSummary(nil), b.accounts...)
+}
+
+// RecentPayments returns the newest payments first
+func (b *DemoBackend) RecentPayments(limit int) []PaymentRecord {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	recent := make([]PaymentRecord, 0, limit)
+	for i := len(b.payments) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, b.payments[i])
+	}
+	return recent
+}
+
+// ============================================================================
+// 3. DASHBOARD - presentation layer over the query interfaces
+// ============================================================================
+
+const dashboardTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.RefreshSeconds}}">
+<title>OOP Context Dashboard</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+.warn { color: #b00; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Dashboard</h1>
+<p>Generated {{.GeneratedAt.Format "15:04:05"}}</p>
+
+<h2>Fleet</h2>
+<table>
+<tr><th>ID</th><th>Kind</th><th>Brand</th><th>Mileage</th><th>Fuel</th><th>Service</th></tr>
+{{range .Vehicles}}<tr><td>{{.ID}}</td><td>{{.Kind}}</td><td>{{.Brand}}</td><td>{{printf "%.0f km" .MileageKm}}</td><td>{{printf "%.0f%%" .FuelPercent}}</td><td>{{if .NeedsService}}<span class="warn">due</span>{{else}}ok{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Accounts</h2>
+<table>
+<tr><th>Number</th><th>Owner</th><th>Balance</th></tr>
+{{range .Accounts}}<tr><td>{{.Number}}</td><td>{{.Owner}}</td><td>{{money .Balance}}</td></tr>
+{{end}}</table>
+
+<h2>Recent payments</h2>
+<table>
+<tr><th>ID</th><th>Amount</th><th>Status</th><th>At</th></tr>
+{{range .Payments}}<tr><td>{{.ID}}</td><td>{{money .Amount}} {{.Currency}}</td><td>{{if .Success}}ok{{else}}<span class="warn">failed</span>{{end}}</td><td>{{.At.Format "15:04:05"}}</td></tr>
+{{else}}<tr><td colspan="4">no payments yet</td></tr>
+{{end}}</table>
+</body>
+</html>
+`
+
+type dashboardData struct {
+	GeneratedAt    time.Time
+	RefreshSeconds int
+	Vehicles       []VehicleStatus
+	Accounts       []AccountSummary
+	Payments       []PaymentRecord
+}
+
+type Dashboard struct {
+	fleet    FleetQuery
+	accounts AccountQuery
+	payments PaymentQuery
+	events   *Bus
+	page     *template.Template
+	now      func() time.Time
+}
+
+func NewDashboard(fleet FleetQuery, accounts AccountQuery, payments PaymentQuery) (*Dashboard, error) {
+	page, err := template.New("dashboard").Funcs(template.FuncMap{
+		"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	}).Parse(dashboardTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse dashboard template: %w", err)
+	}
+	return &Dashboard{fleet: fleet, accounts: accounts, payments: payments, page: page, now: time.Now}, nil
+}
+
+// WithEvents enables the live WebSocket feed at /ws/telemetry
+func (d *Dashboard) WithEvents(events *Bus) *Dashboard {
+	d.events = events
+	return d
+}
+
+func (d *Dashboard) snapshot() dashboardData {
+	return dashboardData{
+		GeneratedAt:    d.now(),
+		RefreshSeconds: 2,
+		Vehicles:       d.fleet.VehicleStatuses(),
+		Accounts:       d.accounts.AccountSummaries(),
+		Payments:       d.payments.RecentPayments(10),
+	}
+}
+
+func (d *Dashboard) handlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := d.page.Execute(w, d.snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleStatus serves the same snapshot as JSON for scripts
+func (d *Dashboard) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (d *Dashboard) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", d.handlePage)
+	mux.HandleFunc("GET /api/status", d.handleStatus)
+	if d.events != nil {
+		mux.HandleFunc("GET /ws/telemetry", d.handleTelemetry)
+	}
+	return mux
+}
+
+// ============================================================================
+// 4. EVENT BUS - pub/sub with bounded per-subscriber queues
+// ============================================================================
+
+const (
+	TopicTelemetry = "telemetry"
+	TopicPayment   = "payment"
+	TopicAccount   = "account"
+)
+
+type Event struct {
+	Topic   string    `json:"topic"`
+	Vehicle string    `json:"vehicle,omitempty"`
+	At      time.Time `json:"at"`
+	Data    any       `json:"data"`
+}
+
+type Filter func(Event) bool
+
+type Subscription struct {
+	events  chan Event
+	filter  Filter
+	dropped atomic.Int64
+}
+
+func (s *Subscription) Events() <-chan Event { return s.events }
+
+// Dropped counts events discarded because this subscriber fell behind
+func (s *Subscription) Dropped() int64 { return s.dropped.Load() }
+
+type Bus struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+func (b *Bus) Subscribe(filter Filter, buffer int) *Subscription {
+	sub := &Subscription{events: make(chan Event, buffer), filter: filter}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	b.mu.Unlock()
+}
+
+// Publish never waits on a slow subscriber: when its queue is full the
+// oldest event is dropped so the newest telemetry always gets through
+func (b *Bus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		for {
+			select {
+			case sub.events <- event:
+			default:
+				select {
+				case <-sub.events:
+					sub.dropped.Add(1)
+				default:
+				}
+				continue
+			}
+			break
+		}
+	}
+}
+
+// ParseFilter builds a filter from ?topics=telemetry,payment&vehicle=V-001
+func ParseFilter(query map[string][]string) Filter {
+	topics := make(map[string]bool)
+	for _, value := range query["topics"] {
+		for _, topic := range strings.Split(value, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				topics[topic] = true
+			}
+		}
+	}
+	vehicles := make(map[string]bool)
+	for _, vehicle := range query["vehicle"] {
+		vehicles[vehicle] = true
+	}
+	return func(event Event) bool {
+		if len(topics) > 0 && !topics[event.Topic] {
+			return false
+		}
+		if len(vehicles) > 0 && event.Topic == TopicTelemetry && !vehicles[event.Vehicle] {
+			return false
+		}
+		return true
+	}
+}
+
+// ============================================================================
+// 5. WEBSOCKET - minimal RFC 6455 server (text frames out, close/ping in)
+// ============================================================================
+
+const (
+	websocketGUID  = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	opText         = 0x1
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+	clientBuffer   = 32
+	writeTimeout   = 5 * time.Second
+	maxClientFrame = 1 << 16
+)
+
+var ErrNotWebSocket = errors.New("not a websocket upgrade request")
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		return nil, nil, ErrNotWebSocket
+	}
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", acceptKey(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, rw, nil
+}
+
+// writeFrame writes one final frame; clients must pass a mask key, servers nil
+func writeFrame(w io.Writer, opcode byte, payload, mask []byte) error {
+	header := []byte{0x80 | opcode, 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if mask != nil {
+		header[1] |= 0x80
+		header = append(header, mask...)
+		masked := make([]byte, len(payload))
+		for i, b := range payload {
+			masked[i] = b ^ mask[i%4]
+		}
+		payload = masked
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readFrame(r io.Reader) (opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode = header[0] & 0x0F
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxClientFrame {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds %d", length, maxClientFrame)
+	}
+	var mask [4]byte
+	masked := header[1]&0x80 != 0
+	if masked {
+		if _, err = io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
+
+// telemetryMessage tells the client how many events it missed by being slow
+type telemetryMessage struct {
+	Event
+	Dropped int64 `json:"dropped,omitempty"`
+}
+
+// handleTelemetry streams filtered bus events; a client that cannot keep up
+// loses its oldest events, and one that stops reading is disconnected
+func (d *Dashboard) handleTelemetry(w http.ResponseWriter, r *http.Request) {
+	sub := d.events.Subscribe(ParseFilter(r.URL.Query()), clientBuffer)
+	defer d.events.Unsubscribe(sub)
+
+	conn, rw, err := upgradeWebSocket(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	var writeMu sync.Mutex
+	send := func(opcode byte, payload []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := writeFrame(rw, opcode, payload, nil); err != nil {
+			return err
+		}
+		return rw.Flush()
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			opcode, payload, err := readFrame(rw)
+			if err != nil || opcode == opClose {
+				send(opClose, nil)
+				return
+			}
+			if opcode == opPing {
+				send(opPong, payload)
+			}
+		}
+	}()
+
+	var reported int64
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case event := <-sub.Events():
+			message := telemetryMessage{Event: event}
+			if dropped := sub.Dropped(); dropped > reported {
+				message.Dropped, reported = dropped-reported, dropped
+			}
+			payload, err := json.Marshal(message)
+			if err != nil {
+				log.Printf("telemetry: %v", err)
+				continue
+			}
+			if err := send(opText, payload); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// dialTelemetry is a bare-bones client used by the -ws-demo flag
+func dialTelemetry(addr, path string) (net.Conn, *bufio.Reader, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString([]byte("oop-context-demo"))
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", path, addr, key)
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, nil, fmt.Errorf("handshake failed: %s", response.Status)
+	}
+	return conn, reader, nil
+}
+
+func runFeedDemo(dashboard *Dashboard, backend *DemoBackend) error {
+	server := httptest.NewServer(dashboard.Routes())
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	conn, reader, err := dialTelemetry(addr, "/ws/telemetry?topics=telemetry,payment&vehicle=V-002")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// The handler subscribes before upgrading, so events published now are queued
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		backend.Tick(start.Add(time.Duration(i) * time.Second))
+	}
+	for i := 0; i < 4; i++ {
+		_, payload, err := readFrame(reader)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  <- %s\n", payload)
+	}
+	return writeFrame(conn, opClose, nil, []byte{1, 2, 3, 4})
+}
+
+// ============================================================================
+// 6. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "listen address")
+	render := flag.Bool("render", false, "print the dashboard HTML once and exit")
+	wsDemo := flag.Bool("ws-demo", false, "subscribe to the WebSocket feed in-process and print a few events")
+	flag.Parse()
+
+	events := NewBus()
+	backend := NewDemoBackend(events)
+	dashboard, err := NewDashboard(backend, backend, backend)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dashboard.WithEvents(events)
+
+	if *wsDemo {
+		fmt.Println("=== WebSocket feed: telemetry for V-002 plus payments ===")
+		if err := runFeedDemo(dashboard, backend); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *render {
+		for i := 0; i < 3; i++ {
+			backend.Tick(time.Now())
+		}
+		recorder := httptest.NewRecorder()
+		dashboard.Routes().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		os.Stdout.Write(recorder.Body.Bytes())
+		return
+	}
+
+	go func() {
+		for now := range time.Tick(time.Second) {
+			backend.Tick(now)
+		}
+	}()
+	fmt.Printf("Dashboard on http://%s/ (JSON at /api/status, live feed at ws://%s/ws/telemetry)\n", *addr, *addr)
+	log.Fatal(http.ListenAndServe(*addr, dashboard.Routes()))
+}
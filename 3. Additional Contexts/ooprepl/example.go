@@ -0,0 +1,364 @@
+// OOP REPL - Go
+// Flow: Domain Objects -> Reflection Command Binder -> Line-Oriented Shell
+//
+// Demo script:  go run example.go
+// Interactive:  go run example.go -interactive
+//   > new account acc ACC001 1000
+//   > call acc Deposit 250
+//   > methods acc
+//   > inspect acc
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// 1. DOMAIN OBJECTS - small versions of the tour's account, vehicle, payment
+// ============================================================================
+
+var (
+	ErrInvalidAmount     = errors.New("amount must be positive")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrOutOfFuel         = errors.New("not enough fuel")
+)
+
+type BankAccount struct {
+	accountNumber string
+	balance       float64
+}
+
+func NewBankAccount(accountNumber string, initialBalance float64) *BankAccount {
+	return &BankAccount{accountNumber: accountNumber, balance: initialBalance}
+}
+
+func (ba *BankAccount) Balance() float64 { return ba.balance }
+
+func (ba *BankAccount) Deposit(amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	ba.balance += amount
+	return nil
+}
+
+func (ba *BankAccount) Withdraw(amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if amount > ba.balance {
+		return ErrInsufficientFunds
+	}
+	ba.balance -= amount
+	return nil
+}
+
+type Car struct {
+	brand     string
+	mileage   float64
+	fuelLiter float64
+}
+
+const litersPerKm = 0.07
+
+func NewCar(brand string) *Car {
+	return &Car{brand: brand, fuelLiter: 40}
+}
+
+func (c *Car) Drive(km float64) error {
+	if need := km * litersPerKm; need > c.fuelLiter {
+		return fmt.Errorf("%w: need %.1f L, have %.1f L", ErrOutOfFuel, need, c.fuelLiter)
+	}
+	c.mileage += km
+	c.fuelLiter -= km * litersPerKm
+	return nil
+}
+
+func (c *Car) Refuel(liters float64) { c.fuelLiter += liters }
+func (c *Car) Mileage() float64      { return c.mileage }
+
+type Payment struct {
+	amount    float64
+	method    string
+	processed bool
+}
+
+func NewPayment(amount float64, method string) *Payment {
+	return &Payment{amount: amount, method: method}
+}
+
+func (p *Payment) Process() (string, error) {
+	if p.processed {
+		return "", errors.New("payment already processed")
+	}
+	p.processed = true
+	return fmt.Sprintf("processed %.2f via %s", p.amount, p.method), nil
+}
+
+// ============================================================================
+// 2. COMMAND BINDER - turn "Deposit 250" into a typed method call via reflect
+// ============================================================================
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// bindArgs converts string arguments into the parameter types of fnType
+func bindArgs(fnType reflect.Type, args []string) ([]reflect.Value, error) {
+	if fnType.NumIn() != len(args) {
+		return nil, fmt.Errorf("want %d argument(s) %s, got %d", fnType.NumIn(), signature(fnType), len(args))
+	}
+	values := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		param := fnType.In(i)
+		value := reflect.New(param).Elem()
+		var err error
+		switch param.Kind() {
+		case reflect.String:
+			value.SetString(arg)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			n, err = strconv.ParseInt(arg, 10, param.Bits())
+			value.SetInt(n)
+		case reflect.Float32, reflect.Float64:
+			var f float64
+			f, err = strconv.ParseFloat(arg, param.Bits())
+			value.SetFloat(f)
+		case reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(arg)
+			value.SetBool(b)
+		default:
+			return nil, fmt.Errorf("argument %d: unsupported type %s", i+1, param)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %q is not a %s", i+1, arg, param)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// invoke calls fn and splits a trailing error result from the rest
+func invoke(fn reflect.Value, args []string) ([]interface{}, error) {
+	in, err := bindArgs(fn.Type(), args)
+	if err != nil {
+		return nil, err
+	}
+	out := fn.Call(in)
+	var results []interface{}
+	for i, value := range out {
+		if i == len(out)-1 && value.Type() == errorType {
+			if !value.IsNil() {
+				return results, value.Interface().(error)
+			}
+			continue
+		}
+		results = append(results, value.Interface())
+	}
+	return results, nil
+}
+
+func signature(fnType reflect.Type) string {
+	params := make([]string, fnType.NumIn())
+	for i := range params {
+		params[i] = fnType.In(i).String()
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// CallMethod dispatches by name; only exported methods are reachable
+func CallMethod(target interface{}, name string, args []string) ([]interface{}, error) {
+	method := reflect.ValueOf(target).MethodByName(name)
+	if !method.IsValid() {
+		return nil, fmt.Errorf("%T has no method %q", target, name)
+	}
+	return invoke(method, args)
+}
+
+// Methods lists a value's method set with parameter types
+func Methods(target interface{}) []string {
+	value := reflect.ValueOf(target)
+	methods := make([]string, value.NumMethod())
+	for i := range methods {
+		methods[i] = value.Type().Method(i).Name + signature(value.Method(i).Type())
+	}
+	return methods
+}
+
+// ============================================================================
+// 3. REPL - a session of named objects and the commands that act on them
+// ============================================================================
+
+// Constructors are plain functions; the binder reads their signatures too
+var constructors = map[string]interface{}{
+	"account": NewBankAccount,
+	"car":     NewCar,
+	"payment": NewPayment,
+}
+
+type REPL struct {
+	objects map[string]interface{}
+	out     io.Writer
+}
+
+func NewREPL(out io.Writer) *REPL {
+	return &REPL{objects: make(map[string]interface{}), out: out}
+}
+
+func (r *REPL) object(name string) (interface{}, error) {
+	obj, ok := r.objects[name]
+	if !ok {
+		return nil, fmt.Errorf("no object named %q (try: list)", name)
+	}
+	return obj, nil
+}
+
+// Execute runs one command line
+func (r *REPL) Execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	switch cmd, args := fields[0], fields[1:]; cmd {
+	case "help":
+		fmt.Fprintln(r.out, "commands: new <kind> <name> args... | call <name> <Method> args... | methods <name> | inspect <name> | list | quit")
+		kinds := make([]string, 0, len(constructors))
+		for kind, ctor := range constructors {
+			kinds = append(kinds, kind+signature(reflect.TypeOf(ctor)))
+		}
+		sort.Strings(kinds)
+		fmt.Fprintf(r.out, "kinds: %s\n", strings.Join(kinds, ", "))
+	case "new":
+		if len(args) < 2 {
+			return errors.New("usage: new <kind> <name> args...")
+		}
+		ctor, ok := constructors[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown kind %q (try: help)", args[0])
+		}
+		if _, exists := r.objects[args[1]]; exists {
+			return fmt.Errorf("%q already exists", args[1])
+		}
+		results, err := invoke(reflect.ValueOf(ctor), args[2:])
+		if err != nil {
+			return fmt.Errorf("new %s: %w", args[0], err)
+		}
+		r.objects[args[1]] = results[0]
+		fmt.Fprintf(r.out, "%s = %T\n", args[1], results[0])
+	case "call":
+		if len(args) < 2 {
+			return errors.New("usage: call <name> <Method> args...")
+		}
+		obj, err := r.object(args[0])
+		if err != nil {
+			return err
+		}
+		results, err := CallMethod(obj, args[1], args[2:])
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Fprintln(r.out, "ok")
+		}
+		for _, result := range results {
+			fmt.Fprintf(r.out, "%v\n", result)
+		}
+	case "methods":
+		if len(args) != 1 {
+			return errors.New("usage: methods <name>")
+		}
+		obj, err := r.object(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, strings.Join(Methods(obj), "\n"))
+	case "inspect":
+		if len(args) != 1 {
+			return errors.New("usage: inspect <name>")
+		}
+		obj, err := r.object(args[0])
+		if err != nil {
+			return err
+		}
+		// %+v can print unexported fields; code outside the package still cannot touch them
+		fmt.Fprintf(r.out, "%T %+v\n", obj, reflect.ValueOf(obj).Elem())
+	case "list":
+		names := make([]string, 0, len(r.objects))
+		for name := range r.objects {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(r.out, "%-8s %T\n", name, r.objects[name])
+		}
+	default:
+		return fmt.Errorf("unknown command %q (try: help)", cmd)
+	}
+	return nil
+}
+
+// Run reads commands until EOF or "quit"
+func (r *REPL) Run(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(r.out, "> ")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "quit" || line == "exit" {
+			return
+		}
+		if err := r.Execute(line); err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+		}
+		fmt.Fprint(r.out, "> ")
+	}
+}
+
+// ============================================================================
+// 4. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	interactive := flag.Bool("interactive", false, "read commands from stdin")
+	flag.Parse()
+
+	repl := NewREPL(os.Stdout)
+	if *interactive {
+		fmt.Println("OOP REPL - type help")
+		repl.Run(os.Stdin)
+		return
+	}
+
+	fmt.Println("=== OOP REPL (scripted session) ===")
+	script := []string{
+		"new account acc ACC001 1000",
+		"new car car Toyota",
+		"new payment pay 49.99 credit",
+		"list",
+		"call acc Deposit 250",
+		"call acc Withdraw 5000",
+		"call acc Balance",
+		"call acc Deposit lots",
+		"call acc balance",
+		"methods car",
+		"call car Drive 120",
+		"call car Drive 1000",
+		"inspect car",
+		"call pay Process",
+		"call pay Process",
+	}
+	for _, line := range script {
+		fmt.Printf("> %s\n", line)
+		if err := repl.Execute(line); err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,810 @@
+// Fleet Simulation Harness - Go
+// Flow: Vehicles -> Fleet -> Simulate (global lock vs shards) -> GPS Route Cache -> Scheduler -> Profiling -> Runtime Metrics
+//
+// Run:               go run example.go -vehicles 100000 -ticks 100
+// Old global lock:   go run example.go -mode locked
+// Before/after:      go test -bench . -benchmem example.go example_test.go
+// CPU/heap profiles: go run example.go -cpuprofile cpu.out -memprofile mem.out
+//                    go tool pprof -top cpu.out
+// Live pprof:        go run example.go -pprof localhost:6060   (open /debug/pprof/)
+// Runtime metrics:   go run example.go -metrics 100ms
+
+package main
+
+import (
+	"container/heap"
+	"container/list"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	_ "net/http/pprof" // registers /debug/pprof/* on http.DefaultServeMux
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// 1. VEHICLES (same shape as the OOP tutorial: base struct + embedding)
+// ============================================================================
+
+type Vehicular interface {
+	Drive(km float64)
+	Refuel()
+	Mileage() float64
+	FuelLevel() float64
+}
+
+type Vehicle struct {
+	brand       string
+	mileage     float64
+	fuel        float64 // liters in tank
+	tankSize    float64
+	consumption float64 // liters per km
+}
+
+func (v *Vehicle) Drive(km float64) {
+	needed := km * v.consumption
+	if needed > v.fuel {
+		km = v.fuel / v.consumption
+		needed = v.fuel
+	}
+	v.mileage += km
+	v.fuel -= needed
+}
+
+func (v *Vehicle) Refuel()            { v.fuel = v.tankSize }
+func (v *Vehicle) Mileage() float64   { return v.mileage }
+func (v *Vehicle) FuelLevel() float64 { return v.fuel }
+
+type Car struct {
+	Vehicle
+}
+
+func NewCar(brand string) *Car {
+	return &Car{Vehicle: Vehicle{brand: brand, fuel: 50, tankSize: 50, consumption: 0.07}}
+}
+
+type Motorcycle struct {
+	Vehicle
+}
+
+func NewMotorcycle(brand string) *Motorcycle {
+	return &Motorcycle{Vehicle: Vehicle{brand: brand, fuel: 15, tankSize: 15, consumption: 0.04}}
+}
+
+// ============================================================================
+// 2. FLEET SIMULATION
+// ============================================================================
+
+// FleetStats is the telemetry every worker reports
+type FleetStats struct {
+	TotalKm     float64
+	Refuels     int
+	LowFuelHits int
+	Updates     int
+}
+
+func (s *FleetStats) Merge(other FleetStats) {
+	s.TotalKm += other.TotalKm
+	s.Refuels += other.Refuels
+	s.LowFuelHits += other.LowFuelHits
+	s.Updates += other.Updates
+}
+
+type Fleet struct {
+	vehicles []Vehicular
+	mu       sync.Mutex // only used by SimulateGlobalLock
+	stats    FleetStats
+}
+
+func NewFleet(size int) *Fleet {
+	f := &Fleet{vehicles: make([]Vehicular, 0, size)}
+	for i := 0; i < size; i++ {
+		if i%3 == 0 {
+			f.vehicles = append(f.vehicles, NewMotorcycle("Yamaha"))
+		} else {
+			f.vehicles = append(f.vehicles, NewCar("Toyota"))
+		}
+	}
+	return f
+}
+
+// drive moves one vehicle and returns its telemetry delta; it touches no shared state
+func drive(v Vehicular, km float64) FleetStats {
+	before := v.Mileage()
+	v.Drive(km)
+	delta := FleetStats{Updates: 1}
+	if v.FuelLevel() < 2 {
+		v.Refuel()
+		delta.LowFuelHits++
+		delta.Refuels++
+	}
+	delta.TotalKm = v.Mileage() - before
+	return delta
+}
+
+// partition splits the fleet into at most n contiguous shards
+func (f *Fleet) partition(n int) [][]Vehicular {
+	chunk := (len(f.vehicles) + n - 1) / n
+	var shards [][]Vehicular
+	for start := 0; start < len(f.vehicles); start += chunk {
+		shards = append(shards, f.vehicles[start:min(start+chunk, len(f.vehicles))])
+	}
+	return shards
+}
+
+// SimulateGlobalLock is the original design, kept as the "before" baseline:
+// workers split the fleet, but every single update takes one global lock.
+func (f *Fleet) SimulateGlobalLock(ticks, workers int) FleetStats {
+	f.stats = FleetStats{}
+	var wg sync.WaitGroup
+	for w, part := range f.partition(workers) {
+		wg.Add(1)
+		go func(part []Vehicular, seed uint64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(seed, 42))
+			for t := 0; t < ticks; t++ {
+				for _, v := range part {
+					delta := drive(v, rng.Float64()*30)
+					f.mu.Lock()
+					f.stats.Merge(delta)
+					f.mu.Unlock()
+				}
+			}
+		}(part, uint64(w))
+	}
+	wg.Wait()
+	return f.stats
+}
+
+// telemetryBatchTicks is how many ticks a shard accumulates before reporting
+const telemetryBatchTicks = 10
+
+// Simulate shards the fleet: each shard goroutine exclusively owns its vehicles
+// (no lock needed to drive them), accumulates telemetry locally, and sends one
+// batch every telemetryBatchTicks ticks to a single collector. Shared state is
+// touched ticks/telemetryBatchTicks times per shard instead of once per update.
+func (f *Fleet) Simulate(ticks, shards int) FleetStats {
+	batches := make(chan FleetStats, shards)
+	var wg sync.WaitGroup
+	for i, shard := range f.partition(shards) {
+		wg.Add(1)
+		go func(shard []Vehicular, seed uint64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(seed, 42))
+			var batch FleetStats
+			for t := 1; t <= ticks; t++ {
+				for _, v := range shard {
+					batch.Merge(drive(v, rng.Float64()*30))
+				}
+				if t%telemetryBatchTicks == 0 || t == ticks {
+					batches <- batch
+					batch = FleetStats{}
+				}
+			}
+		}(shard, uint64(i))
+	}
+	go func() {
+		wg.Wait()
+		close(batches)
+	}()
+
+	var total FleetStats // owned by the collector (this goroutine) only
+	for batch := range batches {
+		total.Merge(batch)
+	}
+	f.stats = total
+	return total
+}
+
+// ============================================================================
+// 3. GPS ROUTING with a BOUNDED ROUTE CACHE
+// ============================================================================
+
+var (
+	ErrUnknownLocation = errors.New("unknown location")
+	ErrNoRoute         = errors.New("no route")
+)
+
+type Route struct {
+	Path     []string
+	Distance float64 // km
+}
+
+// RoadMap is a weighted, undirected graph of locations. Every change bumps the
+// version so cached routes computed on an older map can be recognized as stale.
+type RoadMap struct {
+	mu      sync.RWMutex
+	roads   map[string]map[string]float64
+	version uint64
+}
+
+func NewRoadMap() *RoadMap {
+	return &RoadMap{roads: make(map[string]map[string]float64)}
+}
+
+func (m *RoadMap) SetRoad(a, b string, km float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, from := range []string{a, b} {
+		if m.roads[from] == nil {
+			m.roads[from] = make(map[string]float64)
+		}
+	}
+	m.roads[a][b], m.roads[b][a] = km, km
+	m.version++
+}
+
+func (m *RoadMap) Version() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.version
+}
+
+// ShortestRoute runs Dijkstra's algorithm
+func (m *RoadMap) ShortestRoute(from, to string) (Route, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.roads[from] == nil || m.roads[to] == nil {
+		return Route{}, fmt.Errorf("%w: %s -> %s", ErrUnknownLocation, from, to)
+	}
+	dist := map[string]float64{from: 0}
+	prev := map[string]string{}
+	queue := &routeQueue{{location: from}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(routeStep)
+		if current.location == to {
+			break
+		}
+		if current.distance > dist[current.location] {
+			continue // stale queue entry
+		}
+		for next, km := range m.roads[current.location] {
+			candidate := current.distance + km
+			if known, seen := dist[next]; !seen || candidate < known {
+				dist[next], prev[next] = candidate, current.location
+				heap.Push(queue, routeStep{location: next, distance: candidate})
+			}
+		}
+	}
+	total, reached := dist[to]
+	if !reached {
+		return Route{}, fmt.Errorf("%w: %s -> %s", ErrNoRoute, from, to)
+	}
+	path := []string{to}
+	for at := to; at != from; {
+		at = prev[at]
+		path = append([]string{at}, path...)
+	}
+	return Route{Path: path, Distance: total}, nil
+}
+
+type routeStep struct {
+	location string
+	distance float64
+}
+
+// routeQueue is a min-heap of routeSteps for container/heap
+type routeQueue []routeStep
+
+func (q routeQueue) Len() int            { return len(q) }
+func (q routeQueue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q routeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x interface{}) { *q = append(*q, x.(routeStep)) }
+func (q *routeQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+type RouteKey struct {
+	From, To string
+}
+
+type cachedRoute struct {
+	key     RouteKey
+	route   Route
+	version uint64 // RoadMap version the route was computed on
+}
+
+// RouteCache is a concurrency-safe LRU cache of computed routes
+type RouteCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[RouteKey]*list.Element
+	order    *list.List // front = most recently used
+	hits     int
+	misses   int
+}
+
+func NewRouteCache(capacity int) *RouteCache {
+	return &RouteCache{capacity: capacity, entries: make(map[RouteKey]*list.Element), order: list.New()}
+}
+
+// Get returns a cached route only if it was computed on the given map version
+func (c *RouteCache) Get(key RouteKey, version uint64) (Route, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok || element.Value.(*cachedRoute).version != version {
+		c.misses++
+		return Route{}, false
+	}
+	c.order.MoveToFront(element)
+	c.hits++
+	return element.Value.(*cachedRoute).route, true
+}
+
+func (c *RouteCache) Put(key RouteKey, route Route, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value = &cachedRoute{key: key, route: route, version: version}
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cachedRoute{key: key, route: route, version: version})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedRoute).key)
+	}
+}
+
+// Invalidate drops every entry, e.g. when the whole map is reloaded
+func (c *RouteCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[RouteKey]*list.Element)
+	c.order.Init()
+}
+
+func (c *RouteCache) Stats() (hits, misses, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.order.Len()
+}
+
+// GPS navigates on a RoadMap; cache may be nil to always compute
+type GPS struct {
+	roads *RoadMap
+	cache *RouteCache
+}
+
+func NewGPS(roads *RoadMap, cache *RouteCache) *GPS {
+	return &GPS{roads: roads, cache: cache}
+}
+
+func (g *GPS) Navigate(from, to string) (Route, error) {
+	if g.cache == nil {
+		return g.roads.ShortestRoute(from, to)
+	}
+	key, version := RouteKey{From: from, To: to}, g.roads.Version()
+	if route, ok := g.cache.Get(key, version); ok {
+		return route, nil
+	}
+	route, err := g.roads.ShortestRoute(from, to)
+	if err != nil {
+		return Route{}, err
+	}
+	g.cache.Put(key, route, version)
+	return route, nil
+}
+
+// NewGridCity builds a size x size grid of intersections "r-c" with 1 km blocks
+func NewGridCity(size int) *RoadMap {
+	roads := NewRoadMap()
+	for r := 0; r < size; r++ {
+		for c := 0; c < size; c++ {
+			if c+1 < size {
+				roads.SetRoad(gridLocation(r, c), gridLocation(r, c+1), 1)
+			}
+			if r+1 < size {
+				roads.SetRoad(gridLocation(r, c), gridLocation(r+1, c), 1+float64((r+c)%3)/10)
+			}
+		}
+	}
+	return roads
+}
+
+func gridLocation(r, c int) string { return strconv.Itoa(r) + "-" + strconv.Itoa(c) }
+
+// fleetRoutes are the depot-to-customer trips vehicles repeat every day
+func fleetRoutes(size, count int) []RouteKey {
+	routes := make([]RouteKey, count)
+	for i := range routes {
+		routes[i] = RouteKey{From: gridLocation(i%size, 0), To: gridLocation(size-1-i%size, size-1)}
+	}
+	return routes
+}
+
+// ============================================================================
+// 4. SCHEDULER - recurring and one-shot jobs driven by a Clock
+// ============================================================================
+
+// Clock abstracts time so the scheduler runs on wall-clock time in production
+// and on simulated time here (and in tests)
+type Clock interface {
+	Now() time.Time
+}
+
+type RealClock struct{}
+
+func (RealClock) Now() time.Time { return time.Now() }
+
+// SimClock only moves when Advance is called
+type SimClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewSimClock(start time.Time) *SimClock { return &SimClock{now: start} }
+
+func (c *SimClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *SimClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type scheduledJob struct {
+	name     string
+	next     time.Time
+	interval time.Duration // 0 for one-shot jobs
+	jitter   time.Duration
+	run      func(now time.Time)
+	runs     int
+}
+
+type Scheduler struct {
+	mu    sync.Mutex
+	clock Clock
+	rng   *rand.Rand
+	jobs  []*scheduledJob
+}
+
+func NewScheduler(clock Clock, seed uint64) *Scheduler {
+	return &Scheduler{clock: clock, rng: rand.New(rand.NewPCG(seed, 7))}
+}
+
+// Every runs the job each interval, delayed by a random [0, jitter) so many
+// jobs with the same interval do not all fire at the same instant
+func (s *Scheduler) Every(name string, interval, jitter time.Duration, run func(now time.Time)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := &scheduledJob{name: name, interval: interval, jitter: jitter, run: run}
+	job.next = s.clock.Now().Add(interval + s.jitterLocked(jitter))
+	s.jobs = append(s.jobs, job)
+}
+
+// After runs the job once, delay from now
+func (s *Scheduler) After(name string, delay time.Duration, run func(now time.Time)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &scheduledJob{name: name, next: s.clock.Now().Add(delay), run: run})
+}
+
+func (s *Scheduler) jitterLocked(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	return time.Duration(s.rng.Int64N(int64(jitter)))
+}
+
+// RunDue runs every job whose time has come and returns how many ran. Jobs run
+// outside the lock so they may schedule further jobs.
+func (s *Scheduler) RunDue() int {
+	now := s.clock.Now()
+	s.mu.Lock()
+	var due []*scheduledJob
+	remaining := s.jobs[:0]
+	for _, job := range s.jobs {
+		if job.next.After(now) {
+			remaining = append(remaining, job)
+			continue
+		}
+		due = append(due, job)
+		if job.interval > 0 {
+			job.next = job.next.Add(job.interval + s.jitterLocked(job.jitter))
+			remaining = append(remaining, job)
+		}
+	}
+	s.jobs = remaining
+	s.mu.Unlock()
+
+	for _, job := range due {
+		job.run(now)
+		job.runs++
+	}
+	return len(due)
+}
+
+// Run polls RunDue until ctx is done - the wall-clock mode with RealClock
+func (s *Scheduler) Run(ctx context.Context, poll time.Duration) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunDue()
+		}
+	}
+}
+
+// Domain jobs the simulation schedules
+
+type SavingsAccount struct {
+	number     string
+	balance    float64
+	annualRate float64
+}
+
+// AccrueDailyInterest adds one day of simple interest
+func (a *SavingsAccount) AccrueDailyInterest() {
+	a.balance += a.balance * a.annualRate / 365
+}
+
+// IdempotencyStore remembers processed request keys for a while
+type IdempotencyStore struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{seen: make(map[string]time.Time)}
+}
+
+func (s *IdempotencyStore) Remember(key string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen[key] = at
+}
+
+// Cleanup forgets keys older than ttl and returns how many were removed
+func (s *IdempotencyStore) Cleanup(now time.Time, ttl time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for key, at := range s.seen {
+		if now.Sub(at) > ttl {
+			delete(s.seen, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (s *IdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen)
+}
+
+// maintenanceDueKm is the service interval used by the maintenance check
+const maintenanceDueKm = 1500
+
+// runScheduledOperations simulates days of operations on simulated time
+func runScheduledOperations(fleet *Fleet, days int) {
+	clock := NewSimClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scheduler := NewScheduler(clock, 1)
+	accounts := []*SavingsAccount{{"SAV-1", 10000, 0.04}, {"SAV-2", 2500, 0.05}}
+	keys := NewIdempotencyStore()
+	maintenanceFlags, keysRemoved := 0, 0
+
+	scheduler.Every("interest-accrual", 24*time.Hour, 0, func(time.Time) {
+		for _, account := range accounts {
+			account.AccrueDailyInterest()
+		}
+	})
+	scheduler.Every("maintenance-check", 6*time.Hour, 30*time.Minute, func(time.Time) {
+		maintenanceFlags = 0
+		for _, v := range fleet.vehicles {
+			if v.Mileage() >= maintenanceDueKm {
+				maintenanceFlags++
+			}
+		}
+	})
+	scheduler.Every("idempotency-cleanup", time.Hour, 5*time.Minute, func(now time.Time) {
+		keysRemoved += keys.Cleanup(now, 24*time.Hour)
+	})
+	scheduler.After("fleet-audit", 7*24*time.Hour, func(now time.Time) {
+		fmt.Printf("  one-shot fleet audit at %s: %d vehicles\n", now.Format("Jan 2 15:04"), len(fleet.vehicles))
+	})
+
+	for hour := 0; hour < days*24; hour++ {
+		clock.Advance(time.Hour)
+		keys.Remember(fmt.Sprintf("payment-%d", hour), clock.Now()) // one retried payment per hour
+		scheduler.RunDue()
+	}
+
+	for _, account := range accounts {
+		fmt.Printf("  %s balance after %d days of daily accrual: %.2f\n", account.number, days, account.balance)
+	}
+	fmt.Printf("  vehicles due for maintenance: %d, idempotency keys purged: %d (kept %d)\n",
+		maintenanceFlags, keysRemoved, keys.Len())
+}
+
+// ============================================================================
+// 5. PROFILING - CPU/heap files and a live pprof endpoint
+// ============================================================================
+
+func startCPUProfile(path string) (stop func()) {
+	if path == "" {
+		return func() {}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("cpu profile: %v", err)
+	}
+	if err := pprof.StartCPUProfile(file); err != nil {
+		log.Fatalf("cpu profile: %v", err)
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		file.Close()
+		fmt.Printf("CPU profile written to %s\n", path)
+	}
+}
+
+func writeHeapProfile(path string) {
+	if path == "" {
+		return
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("heap profile: %v", err)
+	}
+	defer file.Close()
+	runtime.GC() // up-to-date live-heap numbers
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		log.Fatalf("heap profile: %v", err)
+	}
+	fmt.Printf("Heap profile written to %s\n", path)
+}
+
+func servePprof(addr string) {
+	if addr == "" {
+		return
+	}
+	go func() {
+		fmt.Printf("pprof listening on http://%s/debug/pprof/\n", addr)
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			log.Printf("pprof server: %v", err)
+		}
+	}()
+}
+
+// ============================================================================
+// 6. RUNTIME METRICS SAMPLING - goroutines, heap, GC pauses
+// ============================================================================
+
+var sampledMetrics = []string{
+	"/sched/goroutines:goroutines",
+	"/memory/classes/heap/objects:bytes",
+	"/gc/cycles/total:gc-cycles",
+	"/sched/pauses/total/gc:seconds",
+}
+
+// sampleMetrics prints one line per interval until done is closed
+func sampleMetrics(interval time.Duration, done <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	if interval <= 0 {
+		return &wg
+	}
+	samples := make([]metrics.Sample, len(sampledMetrics))
+	for i, name := range sampledMetrics {
+		samples[i].Name = name
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				metrics.Read(samples)
+				fmt.Printf("[metrics] goroutines=%d heap=%.1fMB gc-cycles=%d max-gc-pause=%v\n",
+					samples[0].Value.Uint64(),
+					float64(samples[1].Value.Uint64())/(1<<20),
+					samples[2].Value.Uint64(),
+					histogramMax(samples[3].Value.Float64Histogram()))
+			}
+		}
+	}()
+	return &wg
+}
+
+// histogramMax returns the upper bound of the highest non-empty bucket
+func histogramMax(h *metrics.Float64Histogram) time.Duration {
+	for i := len(h.Counts) - 1; i >= 0; i-- {
+		if h.Counts[i] > 0 {
+			return time.Duration(h.Buckets[i+1] * float64(time.Second))
+		}
+	}
+	return 0
+}
+
+// ============================================================================
+// 7. MAIN FUNCTION
+// ============================================================================
+
+func main() {
+	vehicles := flag.Int("vehicles", 100000, "fleet size")
+	ticks := flag.Int("ticks", 100, "simulation steps per vehicle")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0)*4, "worker goroutines / shards")
+	mode := flag.String("mode", "sharded", "sharded or locked (the global-lock baseline)")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile to this file")
+	memProfile := flag.String("memprofile", "", "write a heap profile to this file")
+	pprofAddr := flag.String("pprof", "", "serve live pprof on this address, e.g. localhost:6060")
+	metricsEvery := flag.Duration("metrics", 0, "sample runtime metrics at this interval, e.g. 100ms")
+	days := flag.Int("days", 30, "simulated days of scheduled jobs")
+	flag.Parse()
+
+	fmt.Println("=== Fleet Simulation in Go ===")
+
+	servePprof(*pprofAddr)
+	stopCPU := startCPUProfile(*cpuProfile)
+	done := make(chan struct{})
+	sampler := sampleMetrics(*metricsEvery, done)
+
+	fleet := NewFleet(*vehicles)
+	simulate := fleet.Simulate
+	if *mode == "locked" {
+		simulate = fleet.SimulateGlobalLock
+	}
+	start := time.Now()
+	stats := simulate(*ticks, *workers)
+	elapsed := time.Since(start)
+
+	close(done)
+	sampler.Wait()
+	stopCPU()
+	writeHeapProfile(*memProfile)
+
+	fmt.Printf("%s: %d vehicles x %d ticks on %d workers in %v (%.0f updates/s)\n",
+		*mode, *vehicles, *ticks, *workers, elapsed.Round(time.Millisecond), float64(stats.Updates)/elapsed.Seconds())
+	fmt.Printf("total km: %.0f, refuels: %d\n", stats.TotalKm, stats.Refuels)
+
+	// GPS: the fleet repeats the same trips, so most routes come from the cache
+	city := NewGridCity(20)
+	gps := NewGPS(city, NewRouteCache(32))
+	routes := fleetRoutes(20, 50)
+	for day := 0; day < 3; day++ {
+		if day == 2 {
+			city.SetRoad(gridLocation(0, 0), gridLocation(0, 1), 5) // roadworks: cached routes go stale
+		}
+		for _, route := range routes {
+			if _, err := gps.Navigate(route.From, route.To); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	hits, misses, size := gps.cache.Stats()
+	fmt.Printf("GPS route cache: %d hits, %d misses, %d cached (capacity 32)\n", hits, misses, size)
+	if _, err := gps.Navigate("0-0", "nowhere"); errors.Is(err, ErrUnknownLocation) {
+		fmt.Printf("GPS error: %v\n", err)
+	}
+
+	// Scheduled jobs on simulated time: a month passes in milliseconds
+	fmt.Printf("Scheduled operations over %d simulated days:\n", *days)
+	runScheduledOperations(fleet, *days)
+}
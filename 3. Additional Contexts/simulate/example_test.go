@@ -0,0 +1,55 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+// The global lock against the shards, on the fleet size main uses by default:
+//
+//	go test -bench Simulate example.go example_test.go
+func BenchmarkSimulateGlobalLock(b *testing.B) {
+	benchmarkSimulation(b, (*Fleet).SimulateGlobalLock)
+}
+
+func BenchmarkSimulateSharded(b *testing.B) {
+	benchmarkSimulation(b, (*Fleet).Simulate)
+}
+
+// benchmarkSimulation reports fleet updates per second next to ns/op
+func benchmarkSimulation(b *testing.B, simulate func(f *Fleet, ticks, workers int) FleetStats) {
+	const (
+		vehicles   = 100000
+		ticksPerOp = 10
+	)
+	workers := runtime.GOMAXPROCS(0) * 4
+	fleet := NewFleet(vehicles)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		simulate(fleet, ticksPerOp, workers)
+	}
+	b.ReportMetric(float64(vehicles*ticksPerOp*b.N)/b.Elapsed().Seconds(), "updates/s")
+}
+
+// Repeated navigation over 50 daily fleet trips, without and with the cache
+func BenchmarkNavigateUncached(b *testing.B) {
+	benchmarkNavigation(b, nil)
+}
+
+func BenchmarkNavigateCached(b *testing.B) {
+	benchmarkNavigation(b, NewRouteCache(64))
+}
+
+func benchmarkNavigation(b *testing.B, cache *RouteCache) {
+	const citySize = 20
+	gps := NewGPS(NewGridCity(citySize), cache)
+	routes := fleetRoutes(citySize, 50)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		route := routes[i%len(routes)]
+		if _, err := gps.Navigate(route.From, route.To); err != nil {
+			b.Fatal(err)
+		}
+	}
+}